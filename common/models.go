@@ -16,5 +16,3 @@ const (
 	Idle UserStateSimple = iota
 	InCommand
 )
-
-var UserStates = make(map[UserID]UserState)
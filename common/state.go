@@ -0,0 +1,59 @@
+package common
+
+import "sync"
+
+// userStates — состояния диалогов пользователей. Обработчики обновлений работают
+// в отдельных горутинах, поэтому доступ идёт только через функции ниже.
+var (
+	userStatesMu sync.RWMutex
+	userStates   = make(map[UserID]UserState)
+)
+
+// GetUserState возвращает текущее состояние диалога пользователя.
+func GetUserState(userID UserID) UserState {
+	userStatesMu.RLock()
+	defer userStatesMu.RUnlock()
+	return userStates[userID]
+}
+
+// SetUserState сохраняет состояние диалога пользователя.
+func SetUserState(userID UserID, state UserState) {
+	userStatesMu.Lock()
+	defer userStatesMu.Unlock()
+	userStates[userID] = state
+}
+
+// CountActiveConversations возвращает число пользователей, от которых бот ждёт ответа.
+func CountActiveConversations() int {
+	userStatesMu.RLock()
+	defer userStatesMu.RUnlock()
+
+	count := 0
+	for _, state := range userStates {
+		if state.State == InCommand || state.WaitingChannel != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// DeliverReply передаёт ответ пользователя в канал диалога, который ждёт обработчик.
+// Отправка идёт под блокировкой состояний и не блокируется: обработчик, сбросивший
+// состояние, больше ничего не получит, а ответ, который никто не успевает прочитать,
+// отбрасывается. Каналы диалогов поэтому не закрываются, а создаются с буфером на
+// один ответ. Возвращает false, если ответ не был передан.
+func DeliverReply(userID UserID, text string) bool {
+	userStatesMu.RLock()
+	defer userStatesMu.RUnlock()
+
+	state := userStates[userID]
+	if state.WaitingChannel == nil {
+		return false
+	}
+	select {
+	case *state.WaitingChannel <- text:
+		return true
+	default:
+		return false
+	}
+}
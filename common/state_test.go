@@ -0,0 +1,56 @@
+package common
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDeliverReply(t *testing.T) {
+	const userID UserID = 7
+	t.Cleanup(func() { SetUserState(userID, UserState{}) })
+
+	if DeliverReply(userID, "nobody waits") {
+		t.Error("DeliverReply() without a dialog = true, want false")
+	}
+
+	replies := make(chan string, 1)
+	SetUserState(userID, UserState{State: InCommand, WaitingChannel: &replies})
+	if !DeliverReply(userID, "first") {
+		t.Fatal("DeliverReply() to a waiting dialog = false, want true")
+	}
+	// Обработчик ещё не прочитал первый ответ: второй отбрасывается, а не блокирует роутер.
+	if DeliverReply(userID, "second") {
+		t.Error("DeliverReply() to a full channel = true, want false")
+	}
+	if got := <-replies; got != "first" {
+		t.Errorf("dialog got %q, want %q", got, "first")
+	}
+
+	SetUserState(userID, UserState{State: Idle})
+	if DeliverReply(userID, "late") {
+		t.Error("DeliverReply() after the dialog ended = true, want false")
+	}
+}
+
+// TestDeliverReplyWhileDialogEnds проверяет, что ответы, пришедшие одновременно с
+// завершением диалога, не блокируют отправителя.
+func TestDeliverReplyWhileDialogEnds(t *testing.T) {
+	const userID UserID = 8
+	t.Cleanup(func() { SetUserState(userID, UserState{}) })
+
+	for range 100 {
+		replies := make(chan string, 1)
+		SetUserState(userID, UserState{State: InCommand, WaitingChannel: &replies})
+
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				DeliverReply(userID, "reply")
+			}()
+		}
+		SetUserState(userID, UserState{State: Idle})
+		wg.Wait()
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // содержимое файла конфигурации; пустое — файла нет
+		env     map[string]string
+		check   func(t *testing.T, cfg Config)
+		wantErr bool
+	}{
+		{
+			name: "defaults without file",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Database.Driver != "postgres" || cfg.Scheduler.DispatchInterval != 5*time.Second {
+					t.Errorf("Load() = %+v, want defaults", cfg)
+				}
+			},
+		},
+		{
+			name: "file overrides defaults",
+			file: "database:\n  driver: sqlite\n  url: bot.db\nscheduler:\n  dispatch_interval: 10s\n" +
+				"  timezone: Europe/Moscow\n",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Database.Driver != "sqlite" || cfg.Database.URL != "bot.db" {
+					t.Errorf("database = %+v, want sqlite bot.db", cfg.Database)
+				}
+				if cfg.Scheduler.DispatchInterval != 10*time.Second {
+					t.Errorf("dispatch_interval = %v, want 10s", cfg.Scheduler.DispatchInterval)
+				}
+				if cfg.Scheduler.AutoFillInterval != time.Minute {
+					t.Errorf("auto_fill_interval = %v, want the default 1m", cfg.Scheduler.AutoFillInterval)
+				}
+			},
+		},
+		{
+			name: "env overrides file",
+			file: "database:\n  driver: sqlite\nlogging:\n  level: debug\n",
+			env: map[string]string{
+				"DATABASE_DRIVER": "postgres", "TELEGRAM_MAX_RETRIES": "5",
+				"SHUTDOWN_TIMEOUT": "1m", "LOG_REDACT_MESSAGES": "false",
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Database.Driver != "postgres" || cfg.Telegram.MaxRetries != 5 ||
+					cfg.Scheduler.ShutdownTimeout != time.Minute || cfg.Logging.RedactMessages {
+					t.Errorf("Load() = %+v, want env values", cfg)
+				}
+				if cfg.Logging.Level != "debug" {
+					t.Errorf("logging.level = %q, want debug from the file", cfg.Logging.Level)
+				}
+			},
+		},
+		{name: "bad yaml", file: "database: [", wantErr: true},
+		{name: "bad env number", env: map[string]string{"TELEGRAM_MAX_RETRIES": "many"}, wantErr: true},
+		{name: "bad env duration", env: map[string]string{"DISPATCH_INTERVAL": "soon"}, wantErr: true},
+		{name: "invalid value", file: "logging:\n  format: xml\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Переменные окружения разработчика не должны влиять на тест.
+			for _, name := range []string{"DATABASE_DRIVER", "TELEGRAM_MAX_RETRIES", "SHUTDOWN_TIMEOUT",
+				"LOG_REDACT_MESSAGES", "DISPATCH_INTERVAL", "TIMEZONE", "LOG_LEVEL", "LOG_FORMAT"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			path := ""
+			if tt.file != "" {
+				path = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil && err == nil {
+				tt.check(t, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadMissingExplicitFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() of a missing explicit file succeeded, want an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		wantErr bool
+	}{
+		{name: "defaults", change: func(*Config) {}},
+		{name: "sqlite", change: func(cfg *Config) { cfg.Database.Driver = "sqlite" }},
+		{name: "unknown driver", change: func(cfg *Config) { cfg.Database.Driver = "mysql" }, wantErr: true},
+		{name: "zero rate", change: func(cfg *Config) { cfg.Telegram.MessagesPerSecond = 0 }, wantErr: true},
+		{name: "negative retries", change: func(cfg *Config) { cfg.Telegram.MaxRetries = -1 }, wantErr: true},
+		{name: "zero dispatch", change: func(cfg *Config) { cfg.Scheduler.DispatchInterval = 0 }, wantErr: true},
+		{name: "zero shutdown", change: func(cfg *Config) { cfg.Scheduler.ShutdownTimeout = 0 }, wantErr: true},
+		{name: "timezone", change: func(cfg *Config) { cfg.Scheduler.Timezone = "Asia/Tokyo" }},
+		{name: "bad timezone", change: func(cfg *Config) { cfg.Scheduler.Timezone = "Mars/Base" }, wantErr: true},
+		{name: "public url", change: func(cfg *Config) { cfg.HTTP.PublicURL = "https://bot.example.com" }},
+		{name: "bad public url", change: func(cfg *Config) { cfg.HTTP.PublicURL = "bot.example.com" }, wantErr: true},
+		{name: "internal addr", change: func(cfg *Config) { cfg.HTTP.Addr, cfg.HTTP.InternalAddr = ":8080", ":9090" }},
+		{
+			name:    "internal addr shared with public",
+			change:  func(cfg *Config) { cfg.HTTP.Addr, cfg.HTTP.InternalAddr = ":8080", ":8080" },
+			wantErr: true,
+		},
+		{name: "bad log level", change: func(cfg *Config) { cfg.Logging.Level = "trace" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.change(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedulerLocation(t *testing.T) {
+	tests := []struct {
+		timezone string
+		want     string
+		wantErr  bool
+	}{
+		{timezone: "", want: time.Local.String()},
+		{timezone: "UTC", want: "UTC"},
+		{timezone: "Europe/Moscow", want: "Europe/Moscow"},
+		{timezone: "Nowhere/City", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			loc, err := SchedulerConfig{Timezone: tt.timezone}.Location()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Location() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && loc.String() != tt.want {
+				t.Errorf("Location() = %q, want %q", loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireTelegram(t *testing.T) {
+	cfg := Default()
+	if err := cfg.RequireTelegram(); err == nil {
+		t.Error("RequireTelegram() without a token succeeded, want an error")
+	}
+	cfg.Telegram.Token = "123:abc"
+	if err := cfg.RequireTelegram(); err != nil {
+		t.Errorf("RequireTelegram() = %v, want nil", err)
+	}
+}
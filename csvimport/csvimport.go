@@ -0,0 +1,199 @@
+package csvimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Названия поддерживаемых форматов экспорта.
+const (
+	FormatToggl    = "Toggl"
+	FormatClockify = "Clockify"
+)
+
+// Entry — одна запись учёта времени из CSV-экспорта.
+type Entry struct {
+	Project     string
+	Task        string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+// Duration возвращает длительность записи.
+func (e Entry) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+// columns — индексы нужных колонок в заголовке CSV.
+type columns struct {
+	project, task, description int
+	startDate, startTime       int
+	endDate, endTime           int
+	duration, durationDecimal  int
+}
+
+var dateLayouts = []string{"2006-01-02", "01/02/2006", "02.01.2006", "2006/01/02"}
+
+var timeLayouts = []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM"}
+
+// Parse разбирает CSV-экспорт Toggl Track или Clockify (детальный отчёт).
+// Даты и время в этих экспортах записаны без часового пояса и трактуются как время в loc.
+// Возвращает название распознанного формата и записи; строки без длительности пропускаются.
+func Parse(data []byte, loc *time.Location) (string, []Entry, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(records) == 0 {
+		return "", nil, errors.New("empty CSV file")
+	}
+
+	format, cols, err := detectColumns(records[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	var entries []Entry
+	for i, record := range records[1:] {
+		entry, ok, err := parseRecord(record, cols, loc)
+		if err != nil {
+			return "", nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return format, entries, nil
+}
+
+// detectColumns определяет формат по заголовку и находит индексы колонок.
+func detectColumns(header []string) (string, columns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	find := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	cols := columns{
+		project:         find("project"),
+		task:            find("task"),
+		description:     find("description"),
+		startDate:       find("start date"),
+		startTime:       find("start time"),
+		endDate:         find("end date"),
+		endTime:         find("end time"),
+		duration:        find("duration", "duration (h)"),
+		durationDecimal: find("duration (decimal)"),
+	}
+	if cols.startDate == -1 || cols.startTime == -1 {
+		return "", cols, errors.New("no start date/time columns: expected a Toggl or Clockify detailed export")
+	}
+	if cols.duration == -1 && cols.durationDecimal == -1 && (cols.endDate == -1 || cols.endTime == -1) {
+		return "", cols, errors.New("no duration or end date/time columns")
+	}
+
+	format := FormatToggl
+	if _, ok := index["duration (h)"]; ok {
+		format = FormatClockify
+	}
+	return format, cols, nil
+}
+
+// parseRecord разбирает одну строку CSV; дата и время трактуются как время в loc.
+func parseRecord(record []string, cols columns, loc *time.Location) (Entry, bool, error) {
+	get := func(i int) string {
+		if i == -1 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	start, err := parseDateTime(get(cols.startDate), get(cols.startTime), loc)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	var duration time.Duration
+	switch {
+	case get(cols.duration) != "":
+		duration, err = parseClockDuration(get(cols.duration))
+	case get(cols.durationDecimal) != "":
+		duration, err = parseDecimalHours(get(cols.durationDecimal))
+	case get(cols.endDate) != "" && get(cols.endTime) != "":
+		var end time.Time
+		end, err = parseDateTime(get(cols.endDate), get(cols.endTime), loc)
+		duration = end.Sub(start)
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if duration <= 0 {
+		return Entry{}, false, nil
+	}
+
+	return Entry{
+		Project:     get(cols.project),
+		Task:        get(cols.task),
+		Description: get(cols.description),
+		Start:       start,
+		End:         start.Add(duration),
+	}, true, nil
+}
+
+// parseDateTime разбирает дату и время в одном из распространённых форматов как время в loc.
+func parseDateTime(date, clock string, loc *time.Location) (time.Time, error) {
+	for _, dateLayout := range dateLayouts {
+		for _, timeLayout := range timeLayouts {
+			ts, err := time.ParseInLocation(dateLayout+" "+timeLayout, date+" "+clock, loc)
+			if err == nil {
+				return ts, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date/time %q %q", date, clock)
+}
+
+// parseClockDuration разбирает длительность вида HH:MM:SS (часов может быть больше 24).
+func parseClockDuration(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return parseDecimalHours(value)
+	}
+
+	var total time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(n) * units[i]
+	}
+	return total, nil
+}
+
+// parseDecimalHours разбирает длительность в часах с дробной частью, например "1.50".
+func parseDecimalHours(value string) (time.Duration, error) {
+	hours, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return time.Duration(hours * float64(time.Hour)), nil
+}
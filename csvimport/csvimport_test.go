@@ -0,0 +1,87 @@
+package csvimport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 3, day, hour, minute, 0, 0, loc) }
+	tests := []struct {
+		name       string
+		data       string
+		wantFormat string
+		want       []Entry
+		wantErr    bool
+	}{
+		{
+			name: "toggl with BOM",
+			data: "\xef\xbb\xbfProject,Task,Description,Start date,Start time,End date,End time,Duration\n" +
+				"Работа,Код,Ревью,2025-03-10,09:00:00,2025-03-10,10:30:00,01:30:00\n",
+			wantFormat: FormatToggl,
+			want: []Entry{{
+				Project: "Работа", Task: "Код", Description: "Ревью", Start: at(10, 9, 0), End: at(10, 10, 30),
+			}},
+		},
+		{
+			name: "clockify decimal hours and 12-hour clock",
+			data: "Project,Description,Start Date,Start Time,Duration (h),Duration (decimal)\n" +
+				"Учёба,Курс,03/10/2025,02:15 PM,,0.75\n",
+			wantFormat: FormatClockify,
+			want:       []Entry{{Project: "Учёба", Description: "Курс", Start: at(10, 14, 15), End: at(10, 15, 0)}},
+		},
+		{
+			name: "end time past midnight without duration",
+			data: "Project,Start date,Start time,End date,End time\n" +
+				"Сон,10.03.2025,23:30,11.03.2025,07:00\n",
+			wantFormat: FormatToggl,
+			want:       []Entry{{Project: "Сон", Start: at(10, 23, 30), End: at(11, 7, 0)}},
+		},
+		{
+			name: "duration over a day and zero duration skipped",
+			data: "Project,Start date,Start time,Duration\n" +
+				"Поход,2025-03-10,08:00,26:00:00\n" +
+				"Пусто,2025-03-10,08:00,00:00:00\n",
+			wantFormat: FormatToggl,
+			want:       []Entry{{Project: "Поход", Start: at(10, 8, 0), End: at(11, 10, 0)}},
+		},
+		{name: "empty file", data: "", wantErr: true},
+		{name: "unknown columns", data: "Name,When\nx,y\n", wantErr: true},
+		{name: "no duration columns", data: "Start date,Start time\n2025-03-10,09:00\n", wantErr: true},
+		{
+			name:    "bad date",
+			data:    "Start date,Start time,Duration\n2025-13-40,09:00,01:00:00\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad duration",
+			data:    "Start date,Start time,Duration\n2025-03-10,09:00,час\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, got, err := Parse([]byte(tt.data), loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if format != tt.wantFormat {
+				t.Errorf("Parse() format = %q, want %q", format, tt.wantFormat)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Project != tt.want[i].Project || got[i].Task != tt.want[i].Task ||
+					got[i].Description != tt.want[i].Description ||
+					!got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("entry %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -13,20 +13,27 @@ import (
 )
 
 // addActivity добавляет новую активность и возвращает её ID.
-func addActivity(activity Activity) (int64, error) {
-	result := GormDB.Create(&activity)
+func (r *gormRepository) addActivity(activity Activity) (int64, error) {
+	result := r.db.Create(&activity)
 	return activity.ID, result.Error
 }
 
 // ParseAndAddActivity принимает строку активности в формате
 // "Область / Область поуже / ... / Активность" и добавляет её в базу.
-func ParseAndAddActivity(userID common.UserID, activityStr string) error {
+func (r *gormRepository) ParseAndAddActivity(userID common.UserID, activityStr string) error {
+	_, err := r.FindOrAddActivityPath(userID, activityStr)
+	return err
+}
+
+// FindOrAddActivityPath находит листовую активность по полному пути в формате
+// "Область / ... / Активность", создавая недостающие узлы, и возвращает её ID.
+func (r *gormRepository) FindOrAddActivityPath(userID common.UserID, activityStr string) (int64, error) {
 	parts := strings.Split(activityStr, " / ")
 	var parentActivityID int64 = -1
 
-	existingActivities, err := GetSimpleActivities(userID, nil, nil)
+	existingActivities, err := r.GetSimpleActivities(userID, nil, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	for i, part := range parts {
@@ -46,17 +53,18 @@ func ParseAndAddActivity(userID common.UserID, activityStr string) error {
 				ParentActivityID: parentActivityID,
 				IsLeaf:           isLeaf,
 			}
-			newID, err := addActivity(newActivity)
+			newID, err := r.addActivity(newActivity)
 			if err != nil {
-				return err
+				return 0, err
 			}
+			newActivity.ID = newID
 			parentActivityID = newID
 			existingActivities = append(existingActivities, newActivity)
 		} else {
 			parentActivityID = existingActivities[idx].ID
 		}
 	}
-	return nil
+	return parentActivityID, nil
 }
 
 // activityDFS выполняет обход активностей для построения полных путей.
@@ -93,8 +101,8 @@ func buildActivities(activities []Activity) []ActivityRoute {
 }
 
 // GetFullActivityNameByID возвращает полный путь активности по её ID.
-func GetFullActivityNameByID(activityID int64, userID common.UserID) (string, error) {
-	routes, err := GetFullActivities(userID, nil)
+func (r *gormRepository) GetFullActivityNameByID(activityID int64, userID common.UserID) (string, error) {
+	routes, err := r.GetFullActivities(userID, nil)
 	if err != nil {
 		return "", err
 	}
@@ -107,51 +115,64 @@ func GetFullActivityNameByID(activityID int64, userID common.UserID) (string, er
 }
 
 // GetSimpleActivities возвращает список активностей пользователя.
-func GetSimpleActivities(userID common.UserID, isMuted *bool, hasMutedLeaves *bool) ([]Activity, error) {
+func (r *gormRepository) GetSimpleActivities(
+	userID common.UserID, isMuted *bool, hasMutedLeaves *bool,
+) ([]Activity, error) {
 	var activities []Activity
-	query := "user_id = ?"
-	if isMuted != nil && *isMuted {
-		query += " AND is_muted = true"
-	} else if isMuted != nil && !*isMuted {
-		query += " AND is_muted = false"
-	}
-	if hasMutedLeaves != nil && *hasMutedLeaves {
-		query += " AND has_muted_leaves = true"
-	} else if hasMutedLeaves != nil && !*hasMutedLeaves {
-		query += " AND has_muted_leaves = false"
-	}
-	result := GormDB.Where(query, userID).Order("id ASC").Find(&activities)
+	// Булевы значения передаются параметрами: литералы true/false по-разному
+	// поддерживаются в PostgreSQL и SQLite.
+	query := r.db.Where("user_id = ?", userID)
+	if isMuted != nil {
+		query = query.Where("is_muted = ?", *isMuted)
+	}
+	if hasMutedLeaves != nil {
+		query = query.Where("has_muted_leaves = ?", *hasMutedLeaves)
+	}
+	result := query.Order("id ASC").Find(&activities)
 	return activities, result.Error
 }
 
 // GetFullActivities возвращает полное дерево активностей в виде ActivityRoute.
-func GetFullActivities(userID common.UserID, isMuted *bool) ([]ActivityRoute, error) {
-	activities, err := GetSimpleActivities(userID, isMuted, nil)
+func (r *gormRepository) GetFullActivities(userID common.UserID, isMuted *bool) ([]ActivityRoute, error) {
+	activities, err := r.GetSimpleActivities(userID, isMuted, nil)
 	if err != nil {
 		return nil, err
 	}
 	return buildActivities(activities), nil
 }
 
-func hasMutedLeaves(activityID int64) (bool, error) {
-	var count int64
-	err := GormDB.Raw(`
-		WITH RECURSIVE subtree AS (
-			SELECT id, is_leaf, is_muted FROM activities WHERE id = ?
-			UNION ALL
-			SELECT a.id, a.is_leaf, a.is_muted
-			FROM activities a
-			INNER JOIN subtree s ON a.parent_activity_id = s.id
-		)
-		SELECT COUNT(*) FROM subtree WHERE is_leaf = true AND is_muted = true
-	`, activityID).Scan(&count).Error
-
-	return count > 0, err
+// hasMutedLeaves проверяет, есть ли в поддереве активности замьюченные листья.
+// Обход дерева выполняется в Go, чтобы не зависеть от поддержки рекурсивных CTE в СУБД.
+func (r *gormRepository) hasMutedLeaves(activityID int64) (bool, error) {
+	var activity Activity
+	if err := r.db.First(&activity, activityID).Error; err != nil {
+		return false, err
+	}
+
+	activities, err := r.GetSimpleActivities(common.UserID(activity.UserID), nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	stack := []int64{activityID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, a := range activities {
+			if a.ID == id && a.IsLeaf && a.IsMuted {
+				return true, nil
+			}
+			if a.ParentActivityID == id {
+				stack = append(stack, a.ID)
+			}
+		}
+	}
+	return false, nil
 }
 
-func MuteActivityAndMaybeParents(activityID int64) error {
+func (r *gormRepository) MuteActivityAndMaybeParents(activityID int64) error {
 	// Мьютим саму активность
-	if err := GormDB.Model(&Activity{}).
+	if err := r.db.Model(&Activity{}).
 		Where("id = ?", activityID).
 		Updates(map[string]interface{}{
 			"is_muted":         true,
@@ -161,12 +182,12 @@ func MuteActivityAndMaybeParents(activityID int64) error {
 	}
 
 	// Поднимаемся вверх по дереву
-	return muteParentIfNeeded(activityID)
+	return r.muteParentIfNeeded(activityID)
 }
 
-func muteParentIfNeeded(childID int64) error {
+func (r *gormRepository) muteParentIfNeeded(childID int64) error {
 	var activity Activity
-	if err := GormDB.First(&activity, childID).Error; err != nil {
+	if err := r.db.First(&activity, childID).Error; err != nil {
 		return err
 	}
 
@@ -177,13 +198,13 @@ func muteParentIfNeeded(childID int64) error {
 
 	// Проверяем, остались ли у родителя незамьюченные дети
 	var count int64
-	if err := GormDB.Model(&Activity{}).
-		Where("parent_activity_id = ? AND is_muted = false", parentID).
+	if err := r.db.Model(&Activity{}).
+		Where("parent_activity_id = ? AND is_muted = ?", parentID, false).
 		Count(&count).Error; err != nil {
 		return err
 	}
 
-	hasMuted, err := hasMutedLeaves(parentID)
+	hasMuted, err := r.hasMutedLeaves(parentID)
 	if err != nil {
 		return err
 	}
@@ -195,20 +216,20 @@ func muteParentIfNeeded(childID int64) error {
 		updates["is_muted"] = true
 	}
 
-	if err := GormDB.Model(&Activity{}).
+	if err := r.db.Model(&Activity{}).
 		Where("id = ?", parentID).
 		Updates(updates).Error; err != nil {
 		return err
 	}
 
 	if count == 0 {
-		return muteParentIfNeeded(parentID)
+		return r.muteParentIfNeeded(parentID)
 	}
 	return nil
 }
 
-func UnmuteActivityAndMaybeParents(activityID int64) error {
-	if err := GormDB.Model(&Activity{}).
+func (r *gormRepository) UnmuteActivityAndMaybeParents(activityID int64) error {
+	if err := r.db.Model(&Activity{}).
 		Where("id = ?", activityID).
 		Updates(map[string]interface{}{
 			"is_muted":         false,
@@ -217,12 +238,12 @@ func UnmuteActivityAndMaybeParents(activityID int64) error {
 		return err
 	}
 
-	return recursivelyUnmuteParents(activityID)
+	return r.recursivelyUnmuteParents(activityID)
 }
 
-func recursivelyUnmuteParents(childID int64) error {
+func (r *gormRepository) recursivelyUnmuteParents(childID int64) error {
 	var activity Activity
-	if err := GormDB.First(&activity, childID).Error; err != nil {
+	if err := r.db.First(&activity, childID).Error; err != nil {
 		return err
 	}
 	if activity.ParentActivityID == -1 {
@@ -232,24 +253,24 @@ func recursivelyUnmuteParents(childID int64) error {
 
 	// Мы точно знаем, что у родителя есть хотя бы один незамьюченный ребёнок (текущий)
 	// Поэтому можно сразу размьютить родителя
-	if err := GormDB.Model(&Activity{}).
+	if err := r.db.Model(&Activity{}).
 		Where("id = ?", parentID).
 		Update("is_muted", false).Error; err != nil {
 		return err
 	}
 
-	hasMuted, err := hasMutedLeaves(parentID)
+	hasMuted, err := r.hasMutedLeaves(parentID)
 	if err != nil {
 		return err
 	}
 
-	if err := GormDB.Model(&Activity{}).
+	if err := r.db.Model(&Activity{}).
 		Where("id = ?", parentID).
 		Update("has_muted_leaves", hasMuted).Error; err != nil {
 		return err
 	}
 
-	return recursivelyUnmuteParents(parentID)
+	return r.recursivelyUnmuteParents(parentID)
 }
 
 // buildActivityTree рекурсивно строит дерево активностей для экспорта.
@@ -271,8 +292,8 @@ func buildActivityTree(activities []Activity, parentID int64) []ActivityNode {
 }
 
 // ExportActivitiesToYAML экспортирует все активности пользователя в YAML формат.
-func ExportActivitiesToYAML(userID common.UserID) ([]byte, error) {
-	activities, err := GetSimpleActivities(userID, nil, nil)
+func (r *gormRepository) ExportActivitiesToYAML(userID common.UserID) ([]byte, error) {
+	activities, err := r.GetSimpleActivities(userID, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -288,9 +309,9 @@ func ExportActivitiesToYAML(userID common.UserID) ([]byte, error) {
 }
 
 // parseActivityNode рекурсивно парсит узел активности и добавляет его в базу.
-func parseActivityNode(node ActivityNode, userID common.UserID, parentID int64) error {
+func (r *gormRepository) parseActivityNode(node ActivityNode, userID common.UserID, parentID int64) error {
 	// Проверяем, существует ли уже такая активность
-	activities, err := GetSimpleActivities(userID, nil, nil)
+	activities, err := r.GetSimpleActivities(userID, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -313,14 +334,14 @@ func parseActivityNode(node ActivityNode, userID common.UserID, parentID int64)
 			IsLeaf:           isLeaf,
 			IsMuted:          node.IsMuted,
 		}
-		activityID, err = addActivity(newActivity)
+		activityID, err = r.addActivity(newActivity)
 		if err != nil {
 			return err
 		}
 	} else {
 		// Обновляем существующую активность
 		activityID = activities[idx].ID
-		err = GormDB.Model(&Activity{}).
+		err = r.db.Model(&Activity{}).
 			Where("id = ?", activityID).
 			Update("is_muted", node.IsMuted).Error
 		if err != nil {
@@ -330,7 +351,7 @@ func parseActivityNode(node ActivityNode, userID common.UserID, parentID int64)
 
 	// Обрабатываем дочерние узлы
 	for _, child := range node.Children {
-		if err := parseActivityNode(child, userID, activityID); err != nil {
+		if err := r.parseActivityNode(child, userID, activityID); err != nil {
 			return err
 		}
 	}
@@ -339,7 +360,7 @@ func parseActivityNode(node ActivityNode, userID common.UserID, parentID int64)
 }
 
 // ImportActivitiesFromYAML импортирует активности из YAML данных.
-func ImportActivitiesFromYAML(data []byte, userID common.UserID) error {
+func (r *gormRepository) ImportActivitiesFromYAML(data []byte, userID common.UserID) error {
 	var export ActivityExport
 	if err := yaml.Unmarshal(data, &export); err != nil {
 		return err
@@ -352,7 +373,7 @@ func ImportActivitiesFromYAML(data []byte, userID common.UserID) error {
 
 	// Импортируем каждый корневой узел
 	for _, activity := range export.Activities {
-		if err := parseActivityNode(activity, userID, -1); err != nil {
+		if err := r.parseActivityNode(activity, userID, -1); err != nil {
 			return err
 		}
 	}
@@ -361,9 +382,9 @@ func ImportActivitiesFromYAML(data []byte, userID common.UserID) error {
 }
 
 // DeleteActivityRecursive удаляет активность и все её дочерние активности.
-func DeleteActivityRecursive(activityID int64, userID common.UserID) error {
+func (r *gormRepository) DeleteActivityRecursive(activityID int64, userID common.UserID) error {
 	// Сначала получаем все активности пользователя
-	activities, err := GetSimpleActivities(userID, nil, nil)
+	activities, err := r.GetSimpleActivities(userID, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -382,17 +403,17 @@ func DeleteActivityRecursive(activityID int64, userID common.UserID) error {
 	}
 
 	// Рекурсивно удаляем все дочерние активности
-	if err := deleteChildrenRecursive(activityID, activities); err != nil {
+	if err := r.deleteChildrenRecursive(activityID, activities); err != nil {
 		return err
 	}
 
-	// Удаляем саму активность
-	if err := GormDB.Delete(&Activity{}, activityID).Error; err != nil {
+	// Удаляем все связанные логи активности (до самой активности из-за внешнего ключа)
+	if err := r.db.Where("activity_id = ?", activityID).Delete(&ActivityLog{}).Error; err != nil {
 		return err
 	}
 
-	// Удаляем все связанные логи активности
-	if err := GormDB.Where("activity_id = ?", activityID).Delete(&ActivityLog{}).Error; err != nil {
+	// Удаляем саму активность
+	if err := r.db.Delete(&Activity{}, activityID).Error; err != nil {
 		return err
 	}
 
@@ -400,21 +421,21 @@ func DeleteActivityRecursive(activityID int64, userID common.UserID) error {
 }
 
 // deleteChildrenRecursive рекурсивно удаляет все дочерние активности.
-func deleteChildrenRecursive(parentID int64, activities []Activity) error {
+func (r *gormRepository) deleteChildrenRecursive(parentID int64, activities []Activity) error {
 	for _, activity := range activities {
 		if activity.ParentActivityID == parentID {
 			// Рекурсивно удаляем детей этой активности
-			if err := deleteChildrenRecursive(activity.ID, activities); err != nil {
+			if err := r.deleteChildrenRecursive(activity.ID, activities); err != nil {
 				return err
 			}
 
-			// Удаляем активность
-			if err := GormDB.Delete(&Activity{}, activity.ID).Error; err != nil {
+			// Удаляем логи активности
+			if err := r.db.Where("activity_id = ?", activity.ID).Delete(&ActivityLog{}).Error; err != nil {
 				return err
 			}
 
-			// Удаляем логи активности
-			if err := GormDB.Where("activity_id = ?", activity.ID).Delete(&ActivityLog{}).Error; err != nil {
+			// Удаляем активность
+			if err := r.db.Delete(&Activity{}, activity.ID).Error; err != nil {
 				return err
 			}
 		}
@@ -423,15 +444,18 @@ func deleteChildrenRecursive(parentID int64, activities []Activity) error {
 }
 
 // CompareActivityPeriods сравнивает активности пользователя между двумя периодами времени.
-func CompareActivityPeriods(userID common.UserID, period1Start, period1End, period2Start, period2End time.Time, period1Name, period2Name string) (*PeriodComparisonResult, error) {
+func (r *gormRepository) CompareActivityPeriods(
+	userID common.UserID, period1Start, period1End, period2Start, period2End time.Time,
+	period1Name, period2Name string,
+) (*PeriodComparisonResult, error) {
 	// Получаем активности для первого периода
-	period1Stats, err := getActivityStatsForPeriod(userID, period1Start, period1End)
+	period1Stats, err := r.getActivityStatsForPeriod(userID, period1Start, period1End)
 	if err != nil {
 		return nil, err
 	}
 
 	// Получаем активности для второго периода
-	period2Stats, err := getActivityStatsForPeriod(userID, period2Start, period2End)
+	period2Stats, err := r.getActivityStatsForPeriod(userID, period2Start, period2End)
 	if err != nil {
 		return nil, err
 	}
@@ -499,7 +523,9 @@ type ActivityStat struct {
 }
 
 // getActivityStatsForPeriod получает статистику по активностям за определенный период.
-func getActivityStatsForPeriod(userID common.UserID, startTime, endTime time.Time) ([]ActivityStat, error) {
+func (r *gormRepository) getActivityStatsForPeriod(
+	userID common.UserID, startTime, endTime time.Time,
+) ([]ActivityStat, error) {
 	var results []ActivityStat
 
 	query := `
@@ -515,7 +541,7 @@ func getActivityStatsForPeriod(userID common.UserID, startTime, endTime time.Tim
 		ORDER BY total_minutes DESC
 	`
 
-	rows, err := GormDB.Raw(query, userID, startTime, endTime).Rows()
+	rows, err := r.db.Raw(query, userID, startTime.UTC(), endTime.UTC()).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -530,7 +556,7 @@ func getActivityStatsForPeriod(userID common.UserID, startTime, endTime time.Tim
 		}
 
 		// Получаем полное название активности
-		activityName, err := GetFullActivityNameByID(activityID, userID)
+		activityName, err := r.GetFullActivityNameByID(activityID, userID)
 		if err != nil {
 			continue // Пропускаем если не можем получить название
 		}
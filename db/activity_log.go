@@ -5,30 +5,59 @@ import (
 
 	"TimeCounterBot/common"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-// AddActivityLog добавляет лог активности. При конфликте по (message_id, user_id)
-// обновляет поле activity_id.
-func AddActivityLog(activityLog ActivityLog) error {
-	result := GormDB.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "message_id"}, {Name: "user_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"activity_id"}),
+// AddActivityLog добавляет лог активности. При конфликте по (message_id, user_id, activity_id)
+// обновляет время и длительность.
+func (r *gormRepository) AddActivityLog(activityLog ActivityLog) error {
+	// SQLite хранит время строкой и сравнивает его лексикографически,
+	// поэтому все метки времени пишутся и ищутся в UTC.
+	activityLog.Timestamp = activityLog.Timestamp.UTC()
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}, {Name: "user_id"}, {Name: "activity_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timestamp", "interval_minutes"}),
 	}).Create(&activityLog)
 	return result.Error
 }
 
+// ReplaceActivityLogs заменяет ответ пользователя на уведомление messageID:
+// удаляет все его логи и записывает logs (одну или несколько частей интервала).
+func (r *gormRepository) ReplaceActivityLogs(userID common.UserID, messageID int64, logs []ActivityLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND message_id = ?", userID, messageID).Delete(&ActivityLog{}).Error
+		if err != nil {
+			return err
+		}
+		for _, activityLog := range logs {
+			activityLog.MessageID = messageID
+			activityLog.UserID = int64(userID)
+			activityLog.Timestamp = activityLog.Timestamp.UTC()
+			if err := tx.Create(&activityLog).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteActivityLog удаляет ответ пользователя на уведомление messageID (все его части).
+func (r *gormRepository) DeleteActivityLog(userID common.UserID, messageID int64) error {
+	return r.db.Where("user_id = ? AND message_id = ?", userID, messageID).Delete(&ActivityLog{}).Error
+}
+
 // GetLogDurations получает суммарную длительность для каждой активности
 // для пользователя userID за интервал [start, end].
-func GetLogDurations(userID common.UserID, start, end time.Time) (map[int64]float64, error) {
+func (r *gormRepository) GetLogDurations(userID common.UserID, start, end time.Time) (map[int64]float64, error) {
 	var results []struct {
 		ActivityID    int64
 		TotalInterval int64
 	}
 
-	err := GormDB.Model(&ActivityLog{}).
+	err := r.db.Model(&ActivityLog{}).
 		Select("activity_id, COALESCE(SUM(interval_minutes), 0) as total_interval").
-		Where("user_id = ? AND timestamp BETWEEN ? AND ?", userID, start, end).
+		Where("user_id = ? AND timestamp BETWEEN ? AND ?", userID, start.UTC(), end.UTC()).
 		Group("activity_id").
 		Scan(&results).Error
 	if err != nil {
@@ -36,8 +65,19 @@ func GetLogDurations(userID common.UserID, start, end time.Time) (map[int64]floa
 	}
 
 	logDurations := make(map[int64]float64)
-	for _, r := range results {
-		logDurations[r.ActivityID] = float64(r.TotalInterval)
+	for _, row := range results {
+		logDurations[row.ActivityID] = float64(row.TotalInterval)
 	}
 	return logDurations, nil
 }
+
+// GetActivityLogs возвращает логи пользователя userID за интервал [start, end],
+// отсортированные по времени.
+func (r *gormRepository) GetActivityLogs(userID common.UserID, start, end time.Time) ([]ActivityLog, error) {
+	var logs []ActivityLog
+	result := r.db.
+		Where("user_id = ? AND timestamp BETWEEN ? AND ?", userID, start.UTC(), end.UTC()).
+		Order("timestamp ASC").
+		Find(&logs)
+	return logs, result.Error
+}
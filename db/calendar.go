@@ -0,0 +1,71 @@
+package db
+
+import (
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/ics"
+)
+
+// calendarMergeTolerance — допустимый разрыв между логами одной активности,
+// при котором они всё ещё склеиваются в одно событие.
+const calendarMergeTolerance = 2 * time.Minute
+
+// GetCalendarEvents возвращает события календаря пользователя за интервал [start, end].
+// Лог покрывает отрезок [timestamp - interval_minutes, timestamp]; подряд идущие
+// логи одной активности склеиваются в одно событие.
+func (r *gormRepository) GetCalendarEvents(userID common.UserID, start, end time.Time) ([]CalendarEvent, error) {
+	logs, err := r.GetActivityLogs(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := r.leafNames(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []CalendarEvent
+	for _, activityLog := range logs {
+		logStart := activityLog.Timestamp.Add(-time.Duration(activityLog.IntervalMinutes) * time.Minute)
+		logEnd := activityLog.Timestamp
+
+		if len(events) > 0 {
+			last := &events[len(events)-1]
+			if last.ActivityID == activityLog.ActivityID && !logStart.After(last.End.Add(calendarMergeTolerance)) {
+				if logEnd.After(last.End) {
+					last.End = logEnd
+				}
+				continue
+			}
+		}
+
+		name, ok := names[activityLog.ActivityID]
+		if !ok {
+			continue // Активность удалена или не является листом
+		}
+
+		events = append(events, CalendarEvent{
+			ActivityID:   activityLog.ActivityID,
+			ActivityName: name,
+			Start:        logStart,
+			End:          logEnd,
+		})
+	}
+
+	return events, nil
+}
+
+// CalendarEventsToICS преобразует события календаря пользователя в события ICS.
+func CalendarEventsToICS(userID common.UserID, events []CalendarEvent) []ics.Event {
+	result := make([]ics.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, ics.Event{
+			UID:     ics.EventUID(int64(userID), event.ActivityID, event.Start),
+			Summary: event.ActivityName,
+			Start:   event.Start,
+			End:     event.End,
+		})
+	}
+	return result
+}
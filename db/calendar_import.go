@@ -0,0 +1,127 @@
+package db
+
+import (
+	"regexp"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/ics"
+)
+
+// defaultImportSlotMinutes — длина слота, если у пользователя не задан интервал уведомлений.
+const defaultImportSlotMinutes = 60
+
+// SyntheticMessageID возвращает message_id для лога, не привязанного к сообщению бота
+// (например, импортированного из календаря). Такие ID отрицательные и не пересекаются
+// с настоящими ID сообщений Telegram.
+func SyntheticMessageID(ts time.Time) int64 {
+	return -ts.Unix()
+}
+
+// compiledImportRule — правило импорта с откомпилированным регулярным выражением.
+type compiledImportRule struct {
+	pattern    *regexp.Regexp
+	activityID int64
+}
+
+// ImportCalendarEvents превращает события календаря в логи активностей пользователя.
+// Каждое событие сопоставляется с первым подходящим правилом и режется на слоты
+// длиной slotMinutes; слоты, на которые пользователь уже ответил, пропускаются.
+func (r *gormRepository) ImportCalendarEvents(
+	userID common.UserID, events []ics.Event, rules []ImportRule, slotMinutes int64,
+) (*CalendarImportSummary, error) {
+	if slotMinutes <= 0 {
+		slotMinutes = defaultImportSlotMinutes
+	}
+
+	compiled := make([]compiledImportRule, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		activityID, err := r.FindOrAddActivityPath(userID, rule.ActivityPath)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, compiledImportRule{pattern: pattern, activityID: activityID})
+	}
+
+	summary := &CalendarImportSummary{}
+	for _, event := range events {
+		idx := -1
+		for i, rule := range compiled {
+			if rule.pattern.MatchString(event.Summary) {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 || !event.End.After(event.Start) {
+			summary.EventsUnmatched++
+			continue
+		}
+		summary.EventsMatched++
+
+		if err := r.importEventSlots(userID, event, compiled[idx].activityID, slotMinutes, summary); err != nil {
+			return nil, err
+		}
+	}
+
+	return summary, nil
+}
+
+// importEventSlots записывает слоты одного события, пропуская уже отвеченные.
+func (r *gormRepository) importEventSlots(
+	userID common.UserID, event ics.Event, activityID int64, slotMinutes int64, summary *CalendarImportSummary,
+) error {
+	// Логи, которые могут пересекаться с событием: лог покрывает [timestamp - interval, timestamp].
+	existing, err := r.GetActivityLogs(userID, event.Start, event.End.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	slot := time.Duration(slotMinutes) * time.Minute
+	for slotStart := event.Start; slotStart.Before(event.End); slotStart = slotStart.Add(slot) {
+		slotEnd := slotStart.Add(slot)
+		if slotEnd.After(event.End) {
+			slotEnd = event.End
+		}
+
+		if isSlotAnswered(existing, slotStart, slotEnd) {
+			summary.SlotsSkipped++
+			continue
+		}
+
+		minutes := int64(slotEnd.Sub(slotStart) / time.Minute)
+		if minutes == 0 {
+			continue
+		}
+
+		activityLog := ActivityLog{
+			MessageID:       SyntheticMessageID(slotEnd),
+			UserID:          int64(userID),
+			ActivityID:      activityID,
+			Timestamp:       slotEnd,
+			IntervalMinutes: minutes,
+		}
+		if err := r.AddActivityLog(activityLog); err != nil {
+			return err
+		}
+		existing = append(existing, activityLog)
+
+		summary.SlotsImported++
+		summary.MinutesImported += minutes
+	}
+	return nil
+}
+
+// isSlotAnswered проверяет, пересекается ли слот [start, end) с каким-либо логом.
+func isSlotAnswered(logs []ActivityLog, start, end time.Time) bool {
+	for _, activityLog := range logs {
+		logStart := activityLog.Timestamp.Add(-time.Duration(activityLog.IntervalMinutes) * time.Minute)
+		if activityLog.Timestamp.After(start) && logStart.Before(end) {
+			return true
+		}
+	}
+	return false
+}
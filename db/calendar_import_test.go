@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"TimeCounterBot/ics"
+)
+
+func TestImportCalendarEvents(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	rules := []ImportRule{{Pattern: "(?i)созвон", ActivityPath: "Работа / Встречи"}}
+
+	tests := []struct {
+		name     string
+		existing []ActivityLog // логи до импорта, ActivityID заполняется тестом
+		events   []ics.Event
+		want     CalendarImportSummary
+	}{
+		{
+			name:   "event cut into slots",
+			events: []ics.Event{{Summary: "Созвон", Start: at(0), End: at(75)}},
+			want:   CalendarImportSummary{EventsMatched: 1, SlotsImported: 3, MinutesImported: 75},
+		},
+		{
+			name:   "unmatched and empty events",
+			events: []ics.Event{{Summary: "Обед", Start: at(0), End: at(60)}, {Summary: "Созвон", Start: at(0), End: at(0)}},
+			want:   CalendarImportSummary{EventsUnmatched: 2},
+		},
+		{
+			name:     "answered slot skipped",
+			existing: []ActivityLog{{MessageID: 1, Timestamp: at(30), IntervalMinutes: 30}},
+			events:   []ics.Event{{Summary: "созвон", Start: at(0), End: at(60)}},
+			want:     CalendarImportSummary{EventsMatched: 1, SlotsImported: 1, SlotsSkipped: 1, MinutesImported: 30},
+		},
+		{
+			name:   "overlapping events import once",
+			events: []ics.Event{{Summary: "Созвон", Start: at(0), End: at(30)}, {Summary: "Созвон", Start: at(0), End: at(30)}},
+			want:   CalendarImportSummary{EventsMatched: 2, SlotsImported: 1, SlotsSkipped: 1, MinutesImported: 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			other := addTestActivity(t, repo, "Другое")
+			for _, activityLog := range tt.existing {
+				activityLog.UserID = int64(testUserID)
+				activityLog.ActivityID = other
+				if err := repo.AddActivityLog(activityLog); err != nil {
+					t.Fatalf("AddActivityLog: %v", err)
+				}
+			}
+
+			summary, err := repo.ImportCalendarEvents(testUserID, tt.events, rules, 30)
+			if err != nil {
+				t.Fatalf("ImportCalendarEvents: %v", err)
+			}
+			if *summary != tt.want {
+				t.Errorf("summary = %+v, want %+v", *summary, tt.want)
+			}
+
+			logs, err := repo.GetActivityLogs(testUserID, at(-60), at(24*60))
+			if err != nil {
+				t.Fatalf("GetActivityLogs: %v", err)
+			}
+			if got := len(logs) - len(tt.existing); got != tt.want.SlotsImported {
+				t.Errorf("got %d new logs, want %d", got, tt.want.SlotsImported)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCalendarEvents(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	// log описывает лог активности: activity — 0 или 1, end — конец в минутах от base.
+	type log struct {
+		activity int
+		end      int
+		minutes  int64
+	}
+	type event struct {
+		activity   int
+		start, end int
+	}
+	tests := []struct {
+		name string
+		logs []log
+		want []event
+	}{
+		{
+			name: "adjacent logs of one activity merge",
+			logs: []log{{0, 30, 30}, {0, 60, 30}},
+			want: []event{{0, 0, 60}},
+		},
+		{
+			name: "small gap merges",
+			logs: []log{{0, 30, 30}, {0, 61, 30}},
+			want: []event{{0, 0, 61}},
+		},
+		{
+			name: "large gap splits",
+			logs: []log{{0, 30, 30}, {0, 90, 30}},
+			want: []event{{0, 0, 30}, {0, 60, 90}},
+		},
+		{
+			name: "different activities split",
+			logs: []log{{0, 30, 30}, {1, 60, 30}, {0, 90, 30}},
+			want: []event{{0, 0, 30}, {1, 30, 60}, {0, 60, 90}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			activities := []int64{addTestActivity(t, repo, "Работа"), addTestActivity(t, repo, "Отдых")}
+			for i, l := range tt.logs {
+				err := repo.AddActivityLog(ActivityLog{
+					MessageID:       int64(i + 1),
+					UserID:          int64(testUserID),
+					ActivityID:      activities[l.activity],
+					Timestamp:       at(l.end),
+					IntervalMinutes: l.minutes,
+				})
+				if err != nil {
+					t.Fatalf("AddActivityLog: %v", err)
+				}
+			}
+
+			got, err := repo.GetCalendarEvents(testUserID, at(0), at(24*60))
+			if err != nil {
+				t.Fatalf("GetCalendarEvents: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetCalendarEvents() = %+v, want %d events", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got[i].ActivityID != activities[want.activity] ||
+					!got[i].Start.Equal(at(want.start)) || !got[i].End.Equal(at(want.end)) {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
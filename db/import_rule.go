@@ -0,0 +1,32 @@
+package db
+
+import (
+	"TimeCounterBot/common"
+
+	"gorm.io/gorm"
+)
+
+// GetImportRules возвращает правила импорта пользователя в порядке применения.
+func (r *gormRepository) GetImportRules(userID common.UserID) ([]ImportRule, error) {
+	var rules []ImportRule
+	result := r.db.Where("user_id = ?", userID).Order("position ASC").Find(&rules)
+	return rules, result.Error
+}
+
+// ReplaceImportRules заменяет все правила импорта пользователя на rules.
+func (r *gormRepository) ReplaceImportRules(userID common.UserID, rules []ImportRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&ImportRule{}).Error; err != nil {
+			return err
+		}
+		for i := range rules {
+			rules[i].ID = 0
+			rules[i].UserID = int64(userID)
+			rules[i].Position = i
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
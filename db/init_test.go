@@ -0,0 +1,70 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+)
+
+func TestOpenDialector(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		dsn     string
+		wantDSN string
+		wantErr bool
+	}{
+		{name: "sqlite default path", driver: DriverSQLite, wantDSN: defaultSQLitePath + "?" + sqlitePragmas},
+		{name: "sqlite path", driver: DriverSQLite, dsn: "data/bot.db", wantDSN: "data/bot.db?" + sqlitePragmas},
+		{
+			name:    "sqlite path with options",
+			driver:  DriverSQLite,
+			dsn:     "file:bot.db?mode=rwc",
+			wantDSN: "file:bot.db?mode=rwc&" + sqlitePragmas,
+		},
+		{name: "postgres", driver: DriverPostgres, dsn: "postgres://localhost/bot"},
+		{name: "unknown driver", driver: "mysql", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := openDialector(tt.driver, tt.dsn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openDialector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if dialector.Name() != tt.driver {
+				t.Errorf("dialector = %q, want %q", dialector.Name(), tt.driver)
+			}
+			if sqliteDialector, ok := dialector.(*sqlite.Dialector); ok && sqliteDialector.DSN != tt.wantDSN {
+				t.Errorf("dsn = %q, want %q", sqliteDialector.DSN, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestInitDBSQLiteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	repo, err := InitDB(DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	if err := repo.AddUser(User{ID: testUserID, ChatID: 10}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+
+	// Повторное открытие не применяет миграции заново и видит сохранённые данные.
+	repo, err = InitDB(DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("InitDB again: %v", err)
+	}
+	user, err := repo.GetUserByID(testUserID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.ChatID != 10 {
+		t.Errorf("ChatID = %d, want 10", user.ChatID)
+	}
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold — запросы дольше этого порога попадают в лог как медленные.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// slogWriter передаёт сообщения GORM в slog вместо цветного вывода в stdout.
+type slogWriter struct{}
+
+func (slogWriter) Printf(format string, args ...any) {
+	slog.Warn("GORM", "details", fmt.Sprintf(format, args...))
+}
+
+// newGormLogger возвращает логгер GORM, который пишет только ошибки и медленные
+// запросы. "record not found" — штатный ответ хранилищ, его не логируем.
+func newGormLogger() logger.Interface {
+	return logger.New(slogWriter{}, logger.Config{
+		SlowThreshold:             slowQueryThreshold,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  false,
+	})
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewMemoryRepository создаёт хранилище в памяти для тестов. Под капотом это SQLite
+// в режиме :memory:, поэтому поведение совпадает с боевыми хранилищами, а данные
+// пропадают вместе с процессом.
+func NewMemoryRepository() (Repository, error) {
+	gormDB, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Каждое соединение с :memory: видит свою пустую базу, поэтому оставляем одно.
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := registerMetricsCallbacks(gormDB); err != nil {
+		return nil, err
+	}
+	if err := applyMigrations(gormDB); err != nil {
+		return nil, err
+	}
+	return &gormRepository{db: gormDB}, nil
+}
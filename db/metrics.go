@@ -0,0 +1,49 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"TimeCounterBot/metrics"
+
+	"gorm.io/gorm"
+)
+
+// queryStartKey — ключ в gorm.Statement, под которым хранится время начала запроса.
+const queryStartKey = "metrics:query_start"
+
+// registerMetricsCallbacks добавляет в GORM измерение длительности и ошибок запросов.
+func registerMetricsCallbacks(gormDB *gorm.DB) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(queryStartKey, time.Now())
+	}
+	finish := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(queryStartKey)
+			if !ok {
+				return
+			}
+			metrics.DBQueryDuration.WithLabelValues(operation).Observe(time.Since(value.(time.Time)).Seconds())
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				metrics.DBQueryErrors.WithLabelValues(operation).Inc()
+			}
+		}
+	}
+
+	callback := gormDB.Callback()
+	errs := []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", start),
+		callback.Create().After("gorm:create").Register("metrics:after_create", finish("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", start),
+		callback.Query().After("gorm:query").Register("metrics:after_query", finish("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", start),
+		callback.Update().After("gorm:update").Register("metrics:after_update", finish("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", start),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", finish("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", start),
+		callback.Row().After("gorm:row").Register("metrics:after_row", finish("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", start),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", finish("raw")),
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration — одна версионированная миграция схемы. Up и Down выполняются
+// в транзакции вместе с записью в schema_version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// schemaVersion — запись о применённой миграции.
+type schemaVersion struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// Migrator применяет и откатывает миграции из списка migrations.
+type Migrator struct {
+	db *gorm.DB
+}
+
+// OpenMigrator подключается к базе driver по адресу dsn без применения миграций.
+func OpenMigrator(driver, dsn string) (*Migrator, error) {
+	gormDB, err := openDB(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(gormDB)
+}
+
+// newMigrator создаёт Migrator и при необходимости таблицу schema_version.
+func newMigrator(gormDB *gorm.DB) (*Migrator, error) {
+	if err := gormDB.AutoMigrate(&schemaVersion{}); err != nil {
+		return nil, err
+	}
+	return &Migrator{db: gormDB}, nil
+}
+
+// CurrentVersion возвращает номер последней применённой миграции (0, если миграций не было).
+func (m *Migrator) CurrentVersion() (int, error) {
+	var versions []schemaVersion
+	err := m.db.Order("version DESC").Limit(1).Find(&versions).Error
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[0].Version, nil
+}
+
+// Pending возвращает миграции, которые ещё не применены.
+func (m *Migrator) Pending() ([]Migration, error) {
+	current, err := m.CurrentVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up применяет все неприменённые миграции по порядку и возвращает их список.
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaVersion{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down откатывает steps последних применённых миграций и возвращает их список.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	for range steps {
+		current, err := m.CurrentVersion()
+		if err != nil {
+			return rolledBack, err
+		}
+		if current == 0 {
+			break
+		}
+
+		migration, ok := findMigration(current)
+		if !ok {
+			return rolledBack, fmt.Errorf("unknown schema version %d", current)
+		}
+
+		err = m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&schemaVersion{}, migration.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rollback %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// findMigration ищет миграцию по номеру версии.
+func findMigration(version int) (Migration, bool) {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
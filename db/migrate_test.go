@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// newTestMigrator создаёт Migrator над пустой базой SQLite во временном файле.
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+
+	migrator, err := OpenMigrator(DriverSQLite, filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("OpenMigrator: %v", err)
+	}
+	return migrator
+}
+
+func TestMigrationsVersions(t *testing.T) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, migration.Version, i+1)
+		}
+		if migration.Name == "" || migration.Up == nil || migration.Down == nil {
+			t.Errorf("migration %d is incomplete: %+v", migration.Version, migration)
+		}
+	}
+}
+
+func TestMigrationUpDown(t *testing.T) {
+	latest := migrations[len(migrations)-1].Version
+	for _, migration := range migrations {
+		t.Run(fmt.Sprintf("%04d_%s", migration.Version, migration.Name), func(t *testing.T) {
+			migrator := newTestMigrator(t)
+			if _, err := migrator.Up(); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+
+			// Откатываем все миграции начиная с проверяемой и применяем их снова.
+			rolledBack, err := migrator.Down(latest - migration.Version + 1)
+			if err != nil {
+				t.Fatalf("Down: %v", err)
+			}
+			if got := rolledBack[len(rolledBack)-1].Version; got != migration.Version {
+				t.Errorf("last rolled back migration = %d, want %d", got, migration.Version)
+			}
+			assertVersion(t, migrator, migration.Version-1)
+
+			applied, err := migrator.Up()
+			if err != nil {
+				t.Fatalf("Up after Down: %v", err)
+			}
+			if len(applied) != len(rolledBack) {
+				t.Errorf("reapplied %d migrations, want %d", len(applied), len(rolledBack))
+			}
+			assertVersion(t, migrator, latest)
+			// Откат столбцов пользователей не должен терять индексы таблицы users.
+			if !migrator.db.Migrator().HasIndex(&userV2{}, "idx_users_calendar_token") {
+				t.Error("index idx_users_calendar_token is lost after Down and Up")
+			}
+		})
+	}
+}
+
+func TestMigrationsDownToEmptySchema(t *testing.T) {
+	migrator := newTestMigrator(t)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if _, err := migrator.Down(len(migrations) + 1); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	assertVersion(t, migrator, 0)
+
+	for _, table := range []string{"users", "activities", "activity_logs", "prompts", "scheduled_messages"} {
+		if migrator.db.Migrator().HasTable(table) {
+			t.Errorf("table %s exists after rolling back all migrations", table)
+		}
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		t.Fatalf("Pending: %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("%d pending migrations, want %d", len(pending), len(migrations))
+	}
+}
+
+// assertVersion проверяет текущую версию схемы.
+func assertVersion(t *testing.T, migrator *Migrator, want int) {
+	t.Helper()
+
+	got, err := migrator.CurrentVersion()
+	if err != nil {
+		t.Fatalf("CurrentVersion: %v", err)
+	}
+	if got != want {
+		t.Errorf("CurrentVersion() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,579 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrations — все миграции схемы в порядке применения. Новые миграции
+// добавляются только в конец; уже выпущенные не меняются.
+//
+// Миграции описывают таблицы собственными структурами, а не моделями из models.go:
+// модели меняются вместе с кодом, а миграция должна навсегда остаться такой,
+// какой была выпущена.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up:      migrateInitialSchemaUp,
+		Down:    migrateInitialSchemaDown,
+	},
+	{
+		Version: 2,
+		Name:    "calendar_import",
+		Up:      migrateCalendarImportUp,
+		Down:    migrateCalendarImportDown,
+	},
+	{
+		Version: 3,
+		Name:    "activity_logs_activity_fk",
+		Up:      migrateActivityLogsFKUp,
+		Down:    migrateActivityLogsFKDown,
+	},
+	{
+		Version: 4,
+		Name:    "scheduled_messages",
+		Up:      migrateScheduledMessagesUp,
+		Down:    migrateScheduledMessagesDown,
+	},
+	{
+		Version: 5,
+		Name:    "user_delivery_status",
+		Up:      migrateUserDeliveryStatusUp,
+		Down:    migrateUserDeliveryStatusDown,
+	},
+	{
+		Version: 6,
+		Name:    "prompts",
+		Up:      migratePromptsUp,
+		Down:    migratePromptsDown,
+	},
+	{
+		Version: 7,
+		Name:    "activity_logs_split",
+		Up:      migrateActivityLogsSplitUp,
+		Down:    migrateActivityLogsSplitDown,
+	},
+	{
+		Version: 8,
+		Name:    "unanswered_policy",
+		Up:      migrateUnansweredPolicyUp,
+		Down:    migrateUnansweredPolicyDown,
+	},
+	{
+		Version: 9,
+		Name:    "prompts_closed_at",
+		Up:      migratePromptsClosedAtUp,
+		Down:    migratePromptsClosedAtDown,
+	},
+	{
+		Version: 10,
+		Name:    "adaptive_interval",
+		Up:      migrateAdaptiveIntervalUp,
+		Down:    migrateAdaptiveIntervalDown,
+	},
+	{
+		Version: 11,
+		Name:    "schedules_and_days_off",
+		Up:      migrateSchedulesUp,
+		Down:    migrateSchedulesDown,
+	},
+	{
+		Version: 12,
+		Name:    "snoozed_until",
+		Up:      migrateSnoozedUntilUp,
+		Down:    migrateSnoozedUntilDown,
+	},
+	{
+		Version: 13,
+		Name:    "schedule_minutes",
+		Up:      migrateScheduleMinutesUp,
+		Down:    migrateScheduleMinutesDown,
+	},
+	{
+		Version: 14,
+		Name:    "user_language",
+		Up:      migrateUserLanguageUp,
+		Down:    migrateUserLanguageDown,
+	},
+	{
+		Version: 15,
+		Name:    "prompt_text",
+		Up:      migratePromptTextUp,
+		Down:    migratePromptTextDown,
+	},
+}
+
+type activityV1 struct {
+	ID               int64  `gorm:"primaryKey;autoIncrement"`
+	UserID           int64  `gorm:"not null;index"`
+	Name             string `gorm:"not null"`
+	ParentActivityID int64  `gorm:"not null"`
+	IsLeaf           bool   `gorm:"not null"`
+	IsMuted          bool   `gorm:"default:false;not null"`
+	HasMutedLeaves   bool   `gorm:"default:false;not null"`
+}
+
+func (activityV1) TableName() string { return "activities" }
+
+type activityLogV1 struct {
+	MessageID       int64     `gorm:"primaryKey;autoIncrement:false"`
+	UserID          int64     `gorm:"primaryKey;autoIncrement:false"`
+	ActivityID      int64     `gorm:"not null"`
+	Timestamp       time.Time `gorm:"not null"`
+	IntervalMinutes int64     `gorm:"not null"`
+}
+
+func (activityLogV1) TableName() string { return "activity_logs" }
+
+type userV1 struct {
+	ID                        int64 `gorm:"primaryKey"`
+	ChatID                    int64
+	TimerEnabled              bool `gorm:"not null"`
+	TimerMinutes              sql.NullInt64
+	ScheduleMorningStartHour  sql.NullInt64
+	ScheduleEveningFinishHour sql.NullInt64
+	LastNotify                sql.NullTime
+}
+
+func (userV1) TableName() string { return "users" }
+
+// migrateInitialSchemaUp создаёт исходные таблицы. Базы, созданные ещё через
+// AutoMigrate, уже содержат их, поэтому существующие таблицы пропускаются.
+func migrateInitialSchemaUp(tx *gorm.DB) error {
+	for _, table := range []interface{}{&activityV1{}, &activityLogV1{}, &userV1{}} {
+		if tx.Migrator().HasTable(table) {
+			continue
+		}
+		if err := tx.Migrator().CreateTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateInitialSchemaDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&activityLogV1{}, &activityV1{}, &userV1{})
+}
+
+type userV2 struct {
+	CalendarToken sql.NullString `gorm:"uniqueIndex:idx_users_calendar_token"`
+}
+
+func (userV2) TableName() string { return "users" }
+
+type importRuleV2 struct {
+	ID           int64  `gorm:"primaryKey;autoIncrement"`
+	UserID       int64  `gorm:"not null;index"`
+	Position     int    `gorm:"not null"`
+	Pattern      string `gorm:"not null"`
+	ActivityPath string `gorm:"not null"`
+}
+
+func (importRuleV2) TableName() string { return "import_rules" }
+
+// migrateCalendarImportUp добавляет токен ICS-ленты и правила импорта календаря.
+func migrateCalendarImportUp(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&userV2{}, "CalendarToken") {
+		if err := tx.Migrator().AddColumn(&userV2{}, "CalendarToken"); err != nil {
+			return err
+		}
+	}
+	if !tx.Migrator().HasIndex(&userV2{}, "idx_users_calendar_token") {
+		if err := tx.Migrator().CreateIndex(&userV2{}, "idx_users_calendar_token"); err != nil {
+			return err
+		}
+	}
+	if !tx.Migrator().HasTable(&importRuleV2{}) {
+		return tx.Migrator().CreateTable(&importRuleV2{})
+	}
+	return nil
+}
+
+func migrateCalendarImportDown(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&importRuleV2{}); err != nil {
+		return err
+	}
+	if err := tx.Migrator().DropIndex(&userV2{}, "idx_users_calendar_token"); err != nil {
+		return err
+	}
+	return dropColumn(tx, &userV2{}, "CalendarToken")
+}
+
+// migrateActivityLogsFKUp удаляет логи несуществующих активностей и добавляет
+// внешний ключ activity_logs.activity_id → activities.id с каскадным удалением.
+func migrateActivityLogsFKUp(tx *gorm.DB) error {
+	err := tx.Exec(`DELETE FROM activity_logs WHERE activity_id NOT IN (SELECT id FROM activities)`).Error
+	if err != nil {
+		return err
+	}
+
+	if tx.Dialector.Name() == DriverSQLite {
+		// SQLite не умеет добавлять ограничения к существующей таблице — пересоздаём её.
+		return rebuildSQLiteActivityLogs(tx,
+			"activity_id integer NOT NULL REFERENCES activities(id) ON DELETE CASCADE", "message_id, user_id")
+	}
+	return tx.Exec(`
+		ALTER TABLE activity_logs
+		ADD CONSTRAINT fk_activity_logs_activity
+		FOREIGN KEY (activity_id) REFERENCES activities(id) ON DELETE CASCADE
+	`).Error
+}
+
+func migrateActivityLogsFKDown(tx *gorm.DB) error {
+	if tx.Dialector.Name() == DriverSQLite {
+		return rebuildSQLiteActivityLogs(tx, "activity_id integer NOT NULL", "message_id, user_id")
+	}
+	return tx.Exec(`ALTER TABLE activity_logs DROP CONSTRAINT fk_activity_logs_activity`).Error
+}
+
+// rebuildSQLiteActivityLogs пересоздаёт activity_logs в SQLite с новым определением
+// activity_id и первичного ключа.
+func rebuildSQLiteActivityLogs(tx *gorm.DB, activityIDColumn, primaryKey string) error {
+	statements := []string{
+		`CREATE TABLE activity_logs__new (
+			message_id integer NOT NULL,
+			user_id integer NOT NULL,
+			` + activityIDColumn + `,
+			timestamp datetime NOT NULL,
+			interval_minutes integer NOT NULL,
+			PRIMARY KEY (` + primaryKey + `)
+		)`,
+		`INSERT INTO activity_logs__new (message_id, user_id, activity_id, timestamp, interval_minutes)
+			SELECT message_id, user_id, activity_id, timestamp, interval_minutes FROM activity_logs`,
+		`DROP TABLE activity_logs`,
+		`ALTER TABLE activity_logs__new RENAME TO activity_logs`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropColumn удаляет столбец column таблицы модели model. GORM в SQLite удаляет столбец,
+// пересоздавая таблицу, и теряет при этом её индексы (например, уникальный индекс
+// calendar_token), поэтому в SQLite используется ALTER TABLE ... DROP COLUMN.
+func dropColumn(tx *gorm.DB, model any, column string) error {
+	if tx.Dialector.Name() != DriverSQLite {
+		return tx.Migrator().DropColumn(model, column)
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	if field := stmt.Schema.LookUpField(column); field != nil {
+		column = field.DBName
+	}
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: stmt.Table}, clause.Column{Name: column}).Error
+}
+
+type scheduledMessageV4 struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	UserID    int64     `gorm:"not null;index"`
+	ChatID    int64     `gorm:"not null"`
+	Kind      string    `gorm:"not null"`
+	SendAt    time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+	Attempts  int64     `gorm:"not null;default:0"`
+}
+
+func (scheduledMessageV4) TableName() string { return "scheduled_messages" }
+
+// migrateScheduledMessagesUp создаёт таблицу отложенных сообщений.
+func migrateScheduledMessagesUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&scheduledMessageV4{})
+}
+
+func migrateScheduledMessagesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&scheduledMessageV4{})
+}
+
+type userV5 struct {
+	DeliveryDisabledReason sql.NullString
+	DeliveryDisabledAt     sql.NullTime
+}
+
+func (userV5) TableName() string { return "users" }
+
+// migrateUserDeliveryStatusUp добавляет пользователям причину отключения доставки сообщений.
+func migrateUserDeliveryStatusUp(tx *gorm.DB) error {
+	for _, column := range []string{"DeliveryDisabledReason", "DeliveryDisabledAt"} {
+		if err := tx.Migrator().AddColumn(&userV5{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateUserDeliveryStatusDown(tx *gorm.DB) error {
+	for _, column := range []string{"DeliveryDisabledAt", "DeliveryDisabledReason"} {
+		if err := dropColumn(tx, &userV5{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type promptV6 struct {
+	MessageID       int64     `gorm:"primaryKey;autoIncrement:false"`
+	UserID          int64     `gorm:"primaryKey;autoIncrement:false"`
+	ChatID          int64     `gorm:"not null"`
+	SentAt          time.Time `gorm:"not null;index"`
+	IntervalMinutes int64     `gorm:"not null"`
+}
+
+func (promptV6) TableName() string { return "prompts" }
+
+// migratePromptsUp создаёт таблицу отправленных уведомлений.
+func migratePromptsUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&promptV6{})
+}
+
+func migratePromptsDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&promptV6{})
+}
+
+// migrateActivityLogsSplitUp добавляет activity_id в первичный ключ activity_logs,
+// чтобы интервал одного уведомления можно было разделить между несколькими активностями.
+func migrateActivityLogsSplitUp(tx *gorm.DB) error {
+	if tx.Dialector.Name() == DriverSQLite {
+		return rebuildSQLiteActivityLogs(tx,
+			"activity_id integer NOT NULL REFERENCES activities(id) ON DELETE CASCADE", "message_id, user_id, activity_id")
+	}
+	return tx.Exec(`
+		ALTER TABLE activity_logs
+		DROP CONSTRAINT activity_logs_pkey,
+		ADD PRIMARY KEY (message_id, user_id, activity_id)
+	`).Error
+}
+
+// migrateActivityLogsSplitDown возвращает прежний первичный ключ. От разделённых
+// интервалов остаётся только часть с наименьшим activity_id.
+func migrateActivityLogsSplitDown(tx *gorm.DB) error {
+	err := tx.Exec(`
+		DELETE FROM activity_logs
+		WHERE EXISTS (
+			SELECT 1 FROM activity_logs other
+			WHERE other.message_id = activity_logs.message_id
+				AND other.user_id = activity_logs.user_id
+				AND other.activity_id < activity_logs.activity_id
+		)
+	`).Error
+	if err != nil {
+		return err
+	}
+
+	if tx.Dialector.Name() == DriverSQLite {
+		return rebuildSQLiteActivityLogs(tx,
+			"activity_id integer NOT NULL REFERENCES activities(id) ON DELETE CASCADE", "message_id, user_id")
+	}
+	return tx.Exec(`
+		ALTER TABLE activity_logs
+		DROP CONSTRAINT activity_logs_pkey,
+		ADD PRIMARY KEY (message_id, user_id)
+	`).Error
+}
+
+type userV8 struct {
+	UnansweredPolicy   string `gorm:"not null;default:empty"`
+	FallbackActivityID sql.NullInt64
+}
+
+func (userV8) TableName() string { return "users" }
+
+type promptV8 struct {
+	AutoFilledAt sql.NullTime
+}
+
+func (promptV8) TableName() string { return "prompts" }
+
+// migrateUnansweredPolicyUp добавляет правило заполнения пропущенных уведомлений
+// и отметку о заполнении в prompts.
+func migrateUnansweredPolicyUp(tx *gorm.DB) error {
+	for _, column := range []string{"UnansweredPolicy", "FallbackActivityID"} {
+		if err := tx.Migrator().AddColumn(&userV8{}, column); err != nil {
+			return err
+		}
+	}
+	return tx.Migrator().AddColumn(&promptV8{}, "AutoFilledAt")
+}
+
+func migrateUnansweredPolicyDown(tx *gorm.DB) error {
+	if err := dropColumn(tx, &promptV8{}, "AutoFilledAt"); err != nil {
+		return err
+	}
+	for _, column := range []string{"FallbackActivityID", "UnansweredPolicy"} {
+		if err := dropColumn(tx, &userV8{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type promptV9 struct {
+	ClosedAt sql.NullTime
+}
+
+func (promptV9) TableName() string { return "prompts" }
+
+// migratePromptsClosedAtUp добавляет отметку о свёрнутой клавиатуре уведомления.
+func migratePromptsClosedAtUp(tx *gorm.DB) error {
+	return tx.Migrator().AddColumn(&promptV9{}, "ClosedAt")
+}
+
+func migratePromptsClosedAtDown(tx *gorm.DB) error {
+	return dropColumn(tx, &promptV9{}, "ClosedAt")
+}
+
+type userV10 struct {
+	AdaptiveInterval       bool `gorm:"not null;default:false"`
+	AdaptiveMinMinutes     sql.NullInt64
+	AdaptiveMaxMinutes     sql.NullInt64
+	AdaptiveCurrentMinutes sql.NullInt64
+}
+
+func (userV10) TableName() string { return "users" }
+
+var adaptiveIntervalColumnsV10 = []string{
+	"AdaptiveInterval", "AdaptiveMinMinutes", "AdaptiveMaxMinutes", "AdaptiveCurrentMinutes",
+}
+
+// migrateAdaptiveIntervalUp добавляет пользователям настройки адаптивного интервала уведомлений.
+func migrateAdaptiveIntervalUp(tx *gorm.DB) error {
+	for _, column := range adaptiveIntervalColumnsV10 {
+		if err := tx.Migrator().AddColumn(&userV10{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateAdaptiveIntervalDown(tx *gorm.DB) error {
+	for _, column := range adaptiveIntervalColumnsV10 {
+		if err := dropColumn(tx, &userV10{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type weekdayScheduleV11 struct {
+	UserID       int64 `gorm:"primaryKey;autoIncrement:false"`
+	Weekday      int   `gorm:"primaryKey;autoIncrement:false"`
+	Enabled      bool  `gorm:"not null"`
+	StartMinute  int64 `gorm:"not null"`
+	FinishMinute int64 `gorm:"not null"`
+}
+
+func (weekdayScheduleV11) TableName() string { return "weekday_schedules" }
+
+type dayOffV11 struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement"`
+	UserID    int64     `gorm:"not null;index"`
+	StartDate time.Time `gorm:"not null"`
+	EndDate   time.Time `gorm:"not null"`
+}
+
+func (dayOffV11) TableName() string { return "day_offs" }
+
+// migrateSchedulesUp создаёт расписания по дням недели и выходные пользователей.
+func migrateSchedulesUp(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&weekdayScheduleV11{}, &dayOffV11{})
+}
+
+func migrateSchedulesDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&dayOffV11{}, &weekdayScheduleV11{})
+}
+
+type userV12 struct {
+	SnoozedUntil sql.NullTime
+}
+
+func (userV12) TableName() string { return "users" }
+
+// migrateSnoozedUntilUp добавляет пользователям время окончания паузы уведомлений.
+func migrateSnoozedUntilUp(tx *gorm.DB) error {
+	return tx.Migrator().AddColumn(&userV12{}, "SnoozedUntil")
+}
+
+func migrateSnoozedUntilDown(tx *gorm.DB) error {
+	return dropColumn(tx, &userV12{}, "SnoozedUntil")
+}
+
+type userV13 struct {
+	ScheduleMorningStartMinute  int64 `gorm:"not null;default:0"`
+	ScheduleEveningFinishMinute int64 `gorm:"not null;default:0"`
+}
+
+func (userV13) TableName() string { return "users" }
+
+var scheduleMinutesColumnsV13 = []string{"ScheduleMorningStartMinute", "ScheduleEveningFinishMinute"}
+
+// migrateScheduleMinutesUp добавляет минуты к часам начала и конца общего расписания.
+func migrateScheduleMinutesUp(tx *gorm.DB) error {
+	for _, column := range scheduleMinutesColumnsV13 {
+		if err := tx.Migrator().AddColumn(&userV13{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateScheduleMinutesDown(tx *gorm.DB) error {
+	for _, column := range scheduleMinutesColumnsV13 {
+		if err := dropColumn(tx, &userV13{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type userV14 struct {
+	Language string `gorm:"not null;default:''"`
+}
+
+func (userV14) TableName() string { return "users" }
+
+// migrateUserLanguageUp добавляет пользователям язык интерфейса.
+func migrateUserLanguageUp(tx *gorm.DB) error {
+	return tx.Migrator().AddColumn(&userV14{}, "Language")
+}
+
+func migrateUserLanguageDown(tx *gorm.DB) error {
+	return dropColumn(tx, &userV14{}, "Language")
+}
+
+type userV15 struct {
+	PromptTone      string `gorm:"not null;default:playful"`
+	PromptTemplate  string `gorm:"not null;default:''"`
+	PromptShowSince bool   `gorm:"not null;default:false"`
+}
+
+func (userV15) TableName() string { return "users" }
+
+var promptTextColumnsV15 = []string{"PromptTone", "PromptTemplate", "PromptShowSince"}
+
+// migratePromptTextUp добавляет пользователям настройки текста уведомлений.
+func migratePromptTextUp(tx *gorm.DB) error {
+	for _, column := range promptTextColumnsV15 {
+		if err := tx.Migrator().AddColumn(&userV15{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migratePromptTextDown(tx *gorm.DB) error {
+	for _, column := range promptTextColumnsV15 {
+		if err := dropColumn(tx, &userV15{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
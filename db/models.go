@@ -19,11 +19,14 @@ type Activity struct {
 }
 
 // ActivityLog — модель для таблицы activity_logs.
-// Обратите внимание, что первичный ключ составной: (message_id, user_id).
+// Обратите внимание, что первичный ключ составной: (message_id, user_id, activity_id).
+// Если интервал уведомления разделён между несколькими активностями, на одно
+// сообщение приходится несколько логов; их отрезки идут подряд и заканчиваются
+// временем уведомления.
 type ActivityLog struct {
 	MessageID       int64     `gorm:"primaryKey;autoIncrement:false"`
 	UserID          int64     `gorm:"primaryKey;autoIncrement:false"`
-	ActivityID      int64     `gorm:"not null"`
+	ActivityID      int64     `gorm:"primaryKey;autoIncrement:false"`
 	Timestamp       time.Time `gorm:"not null"`
 	IntervalMinutes int64     `gorm:"not null"`
 }
@@ -36,15 +39,162 @@ type User struct {
 	TimerMinutes              sql.NullInt64
 	ScheduleMorningStartHour  sql.NullInt64
 	ScheduleEveningFinishHour sql.NullInt64
-	LastNotify                sql.NullTime
+	// ScheduleMorningStartMinute и ScheduleEveningFinishMinute — минуты (0–59) к часам
+	// начала и конца общего расписания.
+	ScheduleMorningStartMinute  int64 `gorm:"not null;default:0"`
+	ScheduleEveningFinishMinute int64 `gorm:"not null;default:0"`
+	LastNotify                  sql.NullTime
+	CalendarToken               sql.NullString `gorm:"uniqueIndex"`
+	// DeliveryDisabledReason — почему бот перестал писать пользователю (DeliveryBlocked,
+	// DeliveryChatNotFound); не задано, пока сообщения доставляются.
+	DeliveryDisabledReason sql.NullString
+	DeliveryDisabledAt     sql.NullTime
+	// UnansweredPolicy — что делать с уведомлениями, на которые не ответили до
+	// следующего; FallbackActivityID — активность для UnansweredFallback.
+	UnansweredPolicy   string `gorm:"not null;default:empty"`
+	FallbackActivityID sql.NullInt64
+	// AdaptiveInterval включает адаптивный интервал уведомлений: он растёт, пока
+	// пользователь отвечает одной и той же активностью, и сокращается, когда ответы
+	// меняются, оставаясь в границах [AdaptiveMinMinutes, AdaptiveMaxMinutes].
+	// AdaptiveCurrentMinutes — текущий интервал; TimerMinutes — стартовый.
+	AdaptiveInterval       bool `gorm:"not null;default:false"`
+	AdaptiveMinMinutes     sql.NullInt64
+	AdaptiveMaxMinutes     sql.NullInt64
+	AdaptiveCurrentMinutes sql.NullInt64
+	// SnoozedUntil — до какого момента уведомления приостановлены (пауза с уведомления
+	// или /dnd). После этого момента уведомления возобновляются автоматически.
+	SnoozedUntil sql.NullTime
+	// Language — язык интерфейса (i18n.Lang); пустой, пока язык не определён.
+	Language string `gorm:"not null;default:''"`
+	// PromptTone — тон текста уведомлений (PromptTonePlayful и др.). PromptTemplate —
+	// собственный шаблон текста с плейсхолдерами; если задан, тон не используется.
+	// PromptShowSince добавляет к уведомлению интервал, о котором оно спрашивает.
+	PromptTone      string `gorm:"not null;default:playful"`
+	PromptTemplate  string `gorm:"not null;default:''"`
+	PromptShowSince bool   `gorm:"not null;default:false"`
 }
 
+// Причины отключения доставки (User.DeliveryDisabledReason).
+const (
+	// DeliveryBlocked — пользователь заблокировал бота или удалил аккаунт.
+	DeliveryBlocked = "blocked"
+	// DeliveryChatNotFound — чат пользователя больше не существует.
+	DeliveryChatNotFound = "chat_not_found"
+)
+
+// WeekdaySchedule — расписание уведомлений пользователя на день недели. Дни без
+// записи используют общее расписание User.ScheduleMorningStartHour..ScheduleEveningFinishHour.
+// Время задаётся в минутах от полуночи по часовому поясу бота; если FinishMinute <= StartMinute,
+// окно продолжается после полуночи.
+type WeekdaySchedule struct {
+	UserID       common.UserID `gorm:"primaryKey;autoIncrement:false"`
+	Weekday      time.Weekday  `gorm:"primaryKey;autoIncrement:false"`
+	Enabled      bool          `gorm:"not null"`
+	StartMinute  int64         `gorm:"not null"`
+	FinishMinute int64         `gorm:"not null"`
+}
+
+// DayOff — выходной или отпуск: в даты [StartDate, EndDate] (включительно, по часовому
+// поясу бота; хранятся как полночь UTC) уведомления и итоги дня не присылаются.
+type DayOff struct {
+	ID        int64         `gorm:"primaryKey;autoIncrement"`
+	UserID    common.UserID `gorm:"not null;index"`
+	StartDate time.Time     `gorm:"not null"`
+	EndDate   time.Time     `gorm:"not null"`
+}
+
+// Правила заполнения пропущенных уведомлений (User.UnansweredPolicy).
+const (
+	// UnansweredLeaveEmpty — оставлять пропуск незаполненным.
+	UnansweredLeaveEmpty = "empty"
+	// UnansweredFallback — записывать в активность User.FallbackActivityID.
+	UnansweredFallback = "fallback"
+	// UnansweredSameAsNext — записывать то же, что ответили на следующее уведомление.
+	UnansweredSameAsNext = "next"
+)
+
+// Тоны текста уведомлений (User.PromptTone).
+const (
+	// PromptTonePlayful — шуточный вопрос, как было изначально.
+	PromptTonePlayful = "playful"
+	// PromptToneNeutral — нейтральный вопрос о прошедшем интервале.
+	PromptToneNeutral = "neutral"
+	// PromptToneWork — деловой вопрос для рабочих команд.
+	PromptToneWork = "work"
+)
+
+// ImportRule — правило сопоставления событий календаря с активностями:
+// если название события подходит под регулярное выражение Pattern,
+// время записывается в активность ActivityPath.
+type ImportRule struct {
+	ID           int64  `gorm:"primaryKey;autoIncrement"`
+	UserID       int64  `gorm:"not null;index"`
+	Position     int    `gorm:"not null"`
+	Pattern      string `gorm:"not null"`
+	ActivityPath string `gorm:"not null"`
+}
+
+// Prompt — отправленное пользователю уведомление с выбором активности. Ответ на него
+// сохраняется как ActivityLog с тем же message_id; уведомления без логов — пропуски.
+type Prompt struct {
+	MessageID       int64         `gorm:"primaryKey;autoIncrement:false"`
+	UserID          common.UserID `gorm:"primaryKey;autoIncrement:false"`
+	ChatID          common.ChatID `gorm:"not null"`
+	SentAt          time.Time     `gorm:"not null;index"`
+	IntervalMinutes int64         `gorm:"not null"`
+	// AutoFilledAt — когда пропуск заполнен по правилу пользователя. Такие
+	// уведомления больше не заполняются, даже если ответ потом удалили.
+	AutoFilledAt sql.NullTime
+	// ClosedAt — когда клавиатура неотвеченного уведомления свёрнута (пришло
+	// следующее уведомление или ответ опоздал); отвечать на него уже нельзя.
+	ClosedAt sql.NullTime
+}
+
+// ScheduledMessage — отложенное сообщение пользователю (например, предложение
+// посмотреть итоги дня). Хранится в базе, чтобы не потеряться при перезапуске бота.
+type ScheduledMessage struct {
+	ID        int64         `gorm:"primaryKey;autoIncrement"`
+	UserID    common.UserID `gorm:"not null;index"`
+	ChatID    common.ChatID `gorm:"not null"`
+	Kind      string        `gorm:"not null"`
+	SendAt    time.Time     `gorm:"not null;index"`
+	CreatedAt time.Time     `gorm:"not null"`
+	// Attempts — число неудачных попыток отправки.
+	Attempts int64 `gorm:"not null;default:0"`
+}
+
+// Виды отложенных сообщений (ScheduledMessage.Kind).
+const (
+	ScheduledMessageDayStats = "day_stats"
+)
+
 // ActivityRoute — вспомогательная структура для формирования полного пути к листовой активности.
 type ActivityRoute struct {
 	Name   string
 	LeafID int64
 }
 
+// TimelineSlot — отрезок хронологии дня: ответ на уведомление (Answered) или
+// пропуск, если на уведомление не ответили. Слот покрывает [Start, End], End — время уведомления.
+type TimelineSlot struct {
+	MessageID       int64
+	Start           time.Time
+	End             time.Time
+	IntervalMinutes int64
+	Answered        bool
+	ActivityID      int64
+	ActivityName    string
+}
+
+// CalendarEvent — непрерывный отрезок времени, занятый одной активностью.
+// Получается склейкой подряд идущих логов одной и той же активности.
+type CalendarEvent struct {
+	ActivityID   int64
+	ActivityName string
+	Start        time.Time
+	End          time.Time
+}
+
 // ActivityNode — структура для представления активности в YAML формате.
 type ActivityNode struct {
 	Name     string         `yaml:"name"`
@@ -60,6 +210,70 @@ type ActivityExport struct {
 	Activities []ActivityNode `yaml:"activities"`
 }
 
+// UserDataExport — полная выгрузка данных пользователя (настройки, дерево активностей,
+// логи и правила импорта) для переноса между инсталляциями бота.
+type UserDataExport struct {
+	Version     string                  `yaml:"version"`
+	ExportDate  string                  `yaml:"export_date"`
+	User        UserSettingsExport      `yaml:"user"`
+	Activities  []ActivityNode          `yaml:"activities"`
+	Logs        []ActivityLogExport     `yaml:"logs,omitempty"`
+	ImportRules []ImportRuleExport      `yaml:"import_rules,omitempty"`
+	Schedules   []WeekdayScheduleExport `yaml:"schedules,omitempty"`
+	DaysOff     []DayOffExport          `yaml:"days_off,omitempty"`
+}
+
+// UserSettingsExport — настройки пользователя в выгрузке. Токен ICS-ленты не
+// выгружается: после переноса пользователь получает новую ссылку.
+type UserSettingsExport struct {
+	ID                          int64  `yaml:"id"`
+	ChatID                      int64  `yaml:"chat_id"`
+	TimerEnabled                bool   `yaml:"timer_enabled"`
+	TimerMinutes                *int64 `yaml:"timer_minutes,omitempty"`
+	ScheduleMorningStartHour    *int64 `yaml:"schedule_morning_start_hour,omitempty"`
+	ScheduleEveningFinishHour   *int64 `yaml:"schedule_evening_finish_hour,omitempty"`
+	ScheduleMorningStartMinute  int64  `yaml:"schedule_morning_start_minute,omitempty"`
+	ScheduleEveningFinishMinute int64  `yaml:"schedule_evening_finish_minute,omitempty"`
+	UnansweredPolicy            string `yaml:"unanswered_policy,omitempty"`
+	FallbackActivity            string `yaml:"fallback_activity,omitempty"`
+	AdaptiveInterval            bool   `yaml:"adaptive_interval,omitempty"`
+	AdaptiveMinMinutes          *int64 `yaml:"adaptive_min_minutes,omitempty"`
+	AdaptiveMaxMinutes          *int64 `yaml:"adaptive_max_minutes,omitempty"`
+	Language                    string `yaml:"language,omitempty"`
+	PromptTone                  string `yaml:"prompt_tone,omitempty"`
+	PromptTemplate              string `yaml:"prompt_template,omitempty"`
+	PromptShowSince             bool   `yaml:"prompt_show_since,omitempty"`
+}
+
+// ActivityLogExport — лог активности в выгрузке. Активность задаётся полным
+// путём ("Работа / Созвоны"), так как идентификаторы в новой базе другие.
+type ActivityLogExport struct {
+	MessageID       int64     `yaml:"message_id"`
+	Activity        string    `yaml:"activity"`
+	Timestamp       time.Time `yaml:"timestamp"`
+	IntervalMinutes int64     `yaml:"interval_minutes"`
+}
+
+// WeekdayScheduleExport — расписание на день недели в выгрузке (0 — воскресенье).
+type WeekdayScheduleExport struct {
+	Weekday      int   `yaml:"weekday"`
+	Enabled      bool  `yaml:"enabled"`
+	StartMinute  int64 `yaml:"start_minute"`
+	FinishMinute int64 `yaml:"finish_minute"`
+}
+
+// DayOffExport — выходной или отпуск в выгрузке.
+type DayOffExport struct {
+	StartDate time.Time `yaml:"start_date"`
+	EndDate   time.Time `yaml:"end_date"`
+}
+
+// ImportRuleExport — правило импорта календаря в выгрузке.
+type ImportRuleExport struct {
+	Pattern  string `yaml:"pattern"`
+	Activity string `yaml:"activity"`
+}
+
 // ActivityComparison — структура для сравнения активностей между периодами.
 type ActivityComparison struct {
 	ActivityName   string
@@ -69,6 +283,24 @@ type ActivityComparison struct {
 	PercentChange  float64 // Процентное изменение
 }
 
+// CalendarImportSummary — итоги импорта событий календаря.
+type CalendarImportSummary struct {
+	EventsMatched   int
+	EventsUnmatched int
+	SlotsImported   int
+	SlotsSkipped    int
+	MinutesImported int64
+}
+
+// TimeEntriesImportSummary — итоги импорта записей из CSV-экспорта трекера времени.
+type TimeEntriesImportSummary struct {
+	EntriesImported   int
+	EntriesSkipped    int
+	ActivitiesCreated int
+	MinutesImported   int64
+	ProjectMinutes    map[string]int64
+}
+
 // PeriodComparisonResult — результат сравнения двух периодов.
 type PeriodComparisonResult struct {
 	Period1Name  string
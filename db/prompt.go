@@ -0,0 +1,200 @@
+package db
+
+import (
+	"sort"
+	"time"
+
+	"TimeCounterBot/common"
+
+	"gorm.io/gorm"
+)
+
+// AddPrompt сохраняет отправленное уведомление.
+func (r *gormRepository) AddPrompt(prompt Prompt) error {
+	prompt.SentAt = prompt.SentAt.UTC()
+	return r.db.Create(&prompt).Error
+}
+
+// GetPrompt возвращает отправленное уведомление по message_id.
+func (r *gormRepository) GetPrompt(userID common.UserID, messageID int64) (*Prompt, error) {
+	var prompt Prompt
+	if err := r.db.First(&prompt, "user_id = ? AND message_id = ?", userID, messageID).Error; err != nil {
+		return nil, err
+	}
+	return &prompt, nil
+}
+
+// GetUnansweredOpenPrompts возвращает уведомления без ответа, клавиатура которых
+// ещё не свёрнута, в порядке отправки.
+func (r *gormRepository) GetUnansweredOpenPrompts(userID common.UserID) ([]Prompt, error) {
+	var prompts []Prompt
+	err := r.db.
+		Where("user_id = ? AND closed_at IS NULL", userID).
+		Where("NOT EXISTS (?)", r.answerOfPrompt()).
+		Order("sent_at ASC").
+		Find(&prompts).Error
+	return prompts, err
+}
+
+// ClosePrompt отмечает, что клавиатура уведомления свёрнута.
+func (r *gormRepository) ClosePrompt(userID common.UserID, messageID int64, now time.Time) error {
+	return r.db.Model(&Prompt{}).
+		Where("user_id = ? AND message_id = ?", userID, messageID).
+		Update("closed_at", now.UTC()).Error
+}
+
+// GetTimeline возвращает хронологию пользователя за интервал [start, end]: ответы
+// на уведомления и пропуски (уведомления без ответа), отсортированные по времени.
+func (r *gormRepository) GetTimeline(userID common.UserID, start, end time.Time) ([]TimelineSlot, error) {
+	logs, err := r.GetActivityLogs(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var prompts []Prompt
+	err = r.db.
+		Where("user_id = ? AND sent_at BETWEEN ? AND ?", userID, start.UTC(), end.UTC()).
+		Find(&prompts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := r.leafNames(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	answered := make(map[int64]bool, len(logs))
+	slots := make([]TimelineSlot, 0, len(logs)+len(prompts))
+	for _, activityLog := range logs {
+		answered[activityLog.MessageID] = true
+		slots = append(slots, logSlot(activityLog, names))
+	}
+	for _, prompt := range prompts {
+		if answered[prompt.MessageID] {
+			continue
+		}
+		slots = append(slots, promptSlot(prompt))
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if !slots[i].End.Equal(slots[j].End) {
+			return slots[i].End.Before(slots[j].End)
+		}
+		return slots[i].Start.Before(slots[j].Start)
+	})
+	return slots, nil
+}
+
+// GetTimelineSlot возвращает слот хронологии по message_id уведомления
+// (или синтетическому message_id импортированного лога). Если интервал разделён
+// между несколькими активностями, слот покрывает все части, а их названия
+// перечисляются через " + ".
+func (r *gormRepository) GetTimelineSlot(userID common.UserID, messageID int64) (*TimelineSlot, error) {
+	var logs []ActivityLog
+	err := r.db.Where("user_id = ? AND message_id = ?", userID, messageID).Order("timestamp ASC").Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) > 0 {
+		names, err := r.leafNames(userID)
+		if err != nil {
+			return nil, err
+		}
+		slot := logSlot(logs[0], names)
+		for _, part := range logs[1:] {
+			partSlot := logSlot(part, names)
+			if partSlot.Start.Before(slot.Start) {
+				slot.Start = partSlot.Start
+			}
+			if partSlot.End.After(slot.End) {
+				slot.End = partSlot.End
+			}
+			slot.IntervalMinutes += partSlot.IntervalMinutes
+			slot.ActivityName += " + " + partSlot.ActivityName
+		}
+		return &slot, nil
+	}
+
+	var prompt Prompt
+	if err := r.db.First(&prompt, "user_id = ? AND message_id = ?", userID, messageID).Error; err != nil {
+		return nil, err
+	}
+	slot := promptSlot(prompt)
+	return &slot, nil
+}
+
+// GetMissedPrompts возвращает пропущенные уведомления пользователя, отправленные после
+// since: без ответа, ещё не заполненные автоматически, и после которых уже пришло
+// следующее уведомление. Уведомления упорядочены по времени отправки.
+func (r *gormRepository) GetMissedPrompts(userID common.UserID, since time.Time) ([]Prompt, error) {
+	var prompts []Prompt
+	err := r.db.
+		Where("user_id = ? AND sent_at >= ? AND auto_filled_at IS NULL", userID, since.UTC()).
+		Where("NOT EXISTS (?)", r.answerOfPrompt()).
+		Where("EXISTS (?)", r.db.Table("prompts AS later").Select("1").
+			Where("later.user_id = prompts.user_id AND later.sent_at > prompts.sent_at")).
+		Order("sent_at ASC").
+		Find(&prompts).Error
+	return prompts, err
+}
+
+// AutoFillPrompt записывает пропущенное уведомление в активность activityID
+// и отмечает его заполненным.
+func (r *gormRepository) AutoFillPrompt(prompt Prompt, activityID int64, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&ActivityLog{
+			MessageID:       prompt.MessageID,
+			UserID:          int64(prompt.UserID),
+			ActivityID:      activityID,
+			Timestamp:       prompt.SentAt.UTC(),
+			IntervalMinutes: prompt.IntervalMinutes,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Prompt{}).
+			Where("user_id = ? AND message_id = ?", prompt.UserID, prompt.MessageID).
+			Update("auto_filled_at", now.UTC()).Error
+	})
+}
+
+// answerOfPrompt — подзапрос логов, отвечающих на уведомление из внешнего запроса к prompts.
+func (r *gormRepository) answerOfPrompt() *gorm.DB {
+	return r.db.Model(&ActivityLog{}).Select("1").
+		Where("activity_logs.user_id = prompts.user_id AND activity_logs.message_id = prompts.message_id")
+}
+
+// leafNames возвращает полные названия листовых активностей пользователя по их ID.
+func (r *gormRepository) leafNames(userID common.UserID) (map[int64]string, error) {
+	routes, err := r.GetFullActivities(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(routes))
+	for _, route := range routes {
+		names[route.LeafID] = route.Name
+	}
+	return names, nil
+}
+
+func logSlot(activityLog ActivityLog, names map[int64]string) TimelineSlot {
+	return TimelineSlot{
+		MessageID:       activityLog.MessageID,
+		Start:           activityLog.Timestamp.Add(-time.Duration(activityLog.IntervalMinutes) * time.Minute),
+		End:             activityLog.Timestamp,
+		IntervalMinutes: activityLog.IntervalMinutes,
+		Answered:        true,
+		ActivityID:      activityLog.ActivityID,
+		ActivityName:    names[activityLog.ActivityID],
+	}
+}
+
+func promptSlot(prompt Prompt) TimelineSlot {
+	return TimelineSlot{
+		MessageID:       prompt.MessageID,
+		Start:           prompt.SentAt.Add(-time.Duration(prompt.IntervalMinutes) * time.Minute),
+		End:             prompt.SentAt,
+		IntervalMinutes: prompt.IntervalMinutes,
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeline(t *testing.T) {
+	repo := newTestRepository(t)
+	code := addTestActivity(t, repo, "Работа / Код")
+	lunch := addTestActivity(t, repo, "Дом / Обед")
+	base := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+
+	prompts := []Prompt{
+		{MessageID: 1, SentAt: base, IntervalMinutes: 30},
+		{MessageID: 2, SentAt: base.Add(30 * time.Minute), IntervalMinutes: 30},
+		{MessageID: 3, SentAt: base.Add(time.Hour), IntervalMinutes: 30},
+		// Вне запрошенного дня.
+		{MessageID: 4, SentAt: base.Add(24 * time.Hour), IntervalMinutes: 30},
+	}
+	for _, prompt := range prompts {
+		prompt.UserID = testUserID
+		prompt.ChatID = 1
+		if err := repo.AddPrompt(prompt); err != nil {
+			t.Fatalf("AddPrompt(%d): %v", prompt.MessageID, err)
+		}
+	}
+	// На первое уведомление ответили, третье разделено между двумя активностями.
+	err := repo.AddActivityLog(ActivityLog{
+		MessageID: 1, UserID: int64(testUserID), ActivityID: code, Timestamp: base, IntervalMinutes: 30,
+	})
+	if err != nil {
+		t.Fatalf("AddActivityLog: %v", err)
+	}
+	err = repo.ReplaceActivityLogs(testUserID, 3, []ActivityLog{
+		{ActivityID: code, Timestamp: base.Add(50 * time.Minute), IntervalMinutes: 20},
+		{ActivityID: lunch, Timestamp: base.Add(time.Hour), IntervalMinutes: 10},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceActivityLogs: %v", err)
+	}
+
+	dayStart := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	slots, err := repo.GetTimeline(testUserID, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetTimeline: %v", err)
+	}
+
+	want := []TimelineSlot{
+		{MessageID: 1, Start: base.Add(-30 * time.Minute), End: base, IntervalMinutes: 30,
+			Answered: true, ActivityID: code, ActivityName: "Работа / Код"},
+		{MessageID: 2, Start: base, End: base.Add(30 * time.Minute), IntervalMinutes: 30},
+		{MessageID: 3, Start: base.Add(30 * time.Minute), End: base.Add(50 * time.Minute), IntervalMinutes: 20,
+			Answered: true, ActivityID: code, ActivityName: "Работа / Код"},
+		{MessageID: 3, Start: base.Add(50 * time.Minute), End: base.Add(time.Hour), IntervalMinutes: 10,
+			Answered: true, ActivityID: lunch, ActivityName: "Дом / Обед"},
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("got %d slots, want %d: %+v", len(slots), len(want), slots)
+	}
+	for i := range want {
+		if !sameSlot(slots[i], want[i]) {
+			t.Errorf("slot %d = %+v, want %+v", i, slots[i], want[i])
+		}
+	}
+
+	t.Run("split slot", func(t *testing.T) {
+		slot, err := repo.GetTimelineSlot(testUserID, 3)
+		if err != nil {
+			t.Fatalf("GetTimelineSlot: %v", err)
+		}
+		want := TimelineSlot{MessageID: 3, Start: base.Add(30 * time.Minute), End: base.Add(time.Hour),
+			IntervalMinutes: 30, Answered: true, ActivityID: code, ActivityName: "Работа / Код + Дом / Обед"}
+		if !sameSlot(*slot, want) {
+			t.Errorf("GetTimelineSlot(3) = %+v, want %+v", *slot, want)
+		}
+	})
+
+	t.Run("unanswered slot", func(t *testing.T) {
+		slot, err := repo.GetTimelineSlot(testUserID, 2)
+		if err != nil {
+			t.Fatalf("GetTimelineSlot: %v", err)
+		}
+		if !sameSlot(*slot, want[1]) {
+			t.Errorf("GetTimelineSlot(2) = %+v, want %+v", *slot, want[1])
+		}
+	})
+
+	t.Run("unknown slot", func(t *testing.T) {
+		if _, err := repo.GetTimelineSlot(testUserID, 100); err == nil {
+			t.Error("GetTimelineSlot(100) succeeded, want an error")
+		}
+	})
+}
+
+// sameSlot сравнивает слоты по моменту времени, а не по представлению time.Time.
+func sameSlot(a, b TimelineSlot) bool {
+	return a.MessageID == b.MessageID && a.Start.Equal(b.Start) && a.End.Equal(b.End) &&
+		a.IntervalMinutes == b.IntervalMinutes && a.Answered == b.Answered &&
+		a.ActivityID == b.ActivityID && a.ActivityName == b.ActivityName
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/csvimport"
+	"TimeCounterBot/ics"
+
+	"gorm.io/gorm"
+)
+
+// UserStore — хранилище пользователей бота.
+type UserStore interface {
+	AddUser(user User) error
+	GetUserByID(userID common.UserID) (*User, error)
+	GetUserByCalendarToken(token string) (*User, error)
+	UpdateUser(user User) error
+	UpdateUserColumns(userID common.UserID, columns map[string]any) error
+	MigrateChatID(oldChatID, newChatID common.ChatID) error
+	GetUsers() ([]User, error)
+
+	GetWeekdaySchedules(userID common.UserID) ([]WeekdaySchedule, error)
+	SetWeekdaySchedule(schedule WeekdaySchedule) error
+	DeleteWeekdaySchedule(userID common.UserID, weekday time.Weekday) error
+	AddDayOff(dayOff DayOff) error
+	GetDaysOff(userID common.UserID, from time.Time) ([]DayOff, error)
+	DeleteDayOff(userID common.UserID, id int64) error
+	IsDayOff(userID common.UserID, date time.Time) (bool, error)
+
+	ExportUserData(userID common.UserID) ([]byte, error)
+	ImportUserData(data []byte) (common.UserID, error)
+}
+
+// ActivityStore — хранилище дерева активностей пользователей.
+type ActivityStore interface {
+	ParseAndAddActivity(userID common.UserID, activityStr string) error
+	FindOrAddActivityPath(userID common.UserID, activityStr string) (int64, error)
+	GetFullActivityNameByID(activityID int64, userID common.UserID) (string, error)
+	GetSimpleActivities(userID common.UserID, isMuted *bool, hasMutedLeaves *bool) ([]Activity, error)
+	GetFullActivities(userID common.UserID, isMuted *bool) ([]ActivityRoute, error)
+	MuteActivityAndMaybeParents(activityID int64) error
+	UnmuteActivityAndMaybeParents(activityID int64) error
+	DeleteActivityRecursive(activityID int64, userID common.UserID) error
+	ExportActivitiesToYAML(userID common.UserID) ([]byte, error)
+	ImportActivitiesFromYAML(data []byte, userID common.UserID) error
+}
+
+// ActivityLogStore — хранилище логов активностей: ответы на уведомления,
+// импорт из календарей и трекеров времени, аналитика по записанному времени.
+type ActivityLogStore interface {
+	AddActivityLog(activityLog ActivityLog) error
+	ReplaceActivityLogs(userID common.UserID, messageID int64, logs []ActivityLog) error
+	DeleteActivityLog(userID common.UserID, messageID int64) error
+	GetActivityLogs(userID common.UserID, start, end time.Time) ([]ActivityLog, error)
+	GetLogDurations(userID common.UserID, start, end time.Time) (map[int64]float64, error)
+	CompareActivityPeriods(
+		userID common.UserID, period1Start, period1End, period2Start, period2End time.Time,
+		period1Name, period2Name string,
+	) (*PeriodComparisonResult, error)
+
+	GetCalendarEvents(userID common.UserID, start, end time.Time) ([]CalendarEvent, error)
+	GetImportRules(userID common.UserID) ([]ImportRule, error)
+	ReplaceImportRules(userID common.UserID, rules []ImportRule) error
+	ImportCalendarEvents(
+		userID common.UserID, events []ics.Event, rules []ImportRule, slotMinutes int64,
+	) (*CalendarImportSummary, error)
+	ImportTimeEntries(
+		userID common.UserID, entries []csvimport.Entry, noProjectName string,
+	) (*TimeEntriesImportSummary, error)
+
+	AddPrompt(prompt Prompt) error
+	GetTimeline(userID common.UserID, start, end time.Time) ([]TimelineSlot, error)
+	GetTimelineSlot(userID common.UserID, messageID int64) (*TimelineSlot, error)
+	GetPrompt(userID common.UserID, messageID int64) (*Prompt, error)
+	GetUnansweredOpenPrompts(userID common.UserID) ([]Prompt, error)
+	ClosePrompt(userID common.UserID, messageID int64, now time.Time) error
+	GetMissedPrompts(userID common.UserID, since time.Time) ([]Prompt, error)
+	AutoFillPrompt(prompt Prompt, activityID int64, now time.Time) error
+}
+
+// ScheduledMessageStore — хранилище отложенных сообщений.
+type ScheduledMessageStore interface {
+	AddScheduledMessage(message ScheduledMessage) error
+	GetDueScheduledMessages(now time.Time) ([]ScheduledMessage, error)
+	DeleteScheduledMessage(id int64) error
+	IncrementScheduledMessageAttempts(id int64) error
+}
+
+// Repository — хранилище данных бота целиком. Реализация выбирается при инициализации
+// (PostgreSQL, SQLite или SQLite в памяти для тестов), остальной код работает только
+// через интерфейсы хранилищ.
+type Repository interface {
+	UserStore
+	ActivityStore
+	ActivityLogStore
+	ScheduledMessageStore
+
+	// Ping проверяет, что база доступна.
+	Ping(ctx context.Context) error
+}
+
+// gormRepository — реализация Repository поверх GORM, общая для всех поддерживаемых СУБД.
+type gormRepository struct {
+	db *gorm.DB
+}
+
+// Ping проверяет соединение с базой.
+func (r *gormRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"TimeCounterBot/common"
+)
+
+// testUserID — пользователь, которого создаёт newTestRepository.
+const testUserID common.UserID = 1
+
+// newTestRepository создаёт хранилище в памяти с одним пользователем testUserID.
+func newTestRepository(t *testing.T) *gormRepository {
+	t.Helper()
+
+	repo, err := NewMemoryRepository()
+	if err != nil {
+		t.Fatalf("NewMemoryRepository: %v", err)
+	}
+	err = repo.AddUser(User{
+		ID:           testUserID,
+		ChatID:       common.ChatID(testUserID),
+		TimerMinutes: sql.NullInt64{Int64: 30, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	return repo.(*gormRepository)
+}
+
+// addTestActivity добавляет пользователю testUserID активность по пути path и возвращает её ID.
+func addTestActivity(t *testing.T, repo *gormRepository, path string) int64 {
+	t.Helper()
+
+	id, err := repo.FindOrAddActivityPath(testUserID, path)
+	if err != nil {
+		t.Fatalf("FindOrAddActivityPath(%q): %v", path, err)
+	}
+	return id
+}
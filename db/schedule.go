@@ -0,0 +1,69 @@
+package db
+
+import (
+	"time"
+
+	"TimeCounterBot/common"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetWeekdaySchedules возвращает расписания пользователя по дням недели.
+func (r *gormRepository) GetWeekdaySchedules(userID common.UserID) ([]WeekdaySchedule, error) {
+	var schedules []WeekdaySchedule
+	err := r.db.Where("user_id = ?", userID).Order("weekday ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// SetWeekdaySchedule сохраняет расписание на день недели, заменяя прежнее.
+func (r *gormRepository) SetWeekdaySchedule(schedule WeekdaySchedule) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "weekday"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "start_minute", "finish_minute"}),
+	}).Create(&schedule).Error
+}
+
+// DeleteWeekdaySchedule возвращает день недели к общему расписанию.
+func (r *gormRepository) DeleteWeekdaySchedule(userID common.UserID, weekday time.Weekday) error {
+	return r.db.Where("user_id = ? AND weekday = ?", userID, weekday).Delete(&WeekdaySchedule{}).Error
+}
+
+// calendarDate возвращает календарную дату t (в часовом поясе самого t) как полночь
+// UTC: так в базе хранятся даты выходных, независимо от часового пояса бота.
+func calendarDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDayOff добавляет выходной или отпуск. Берутся только календарные даты.
+func (r *gormRepository) AddDayOff(dayOff DayOff) error {
+	dayOff.StartDate = calendarDate(dayOff.StartDate)
+	dayOff.EndDate = calendarDate(dayOff.EndDate)
+	return r.db.Create(&dayOff).Error
+}
+
+// GetDaysOff возвращает выходные пользователя, которые заканчиваются не раньше даты from.
+func (r *gormRepository) GetDaysOff(userID common.UserID, from time.Time) ([]DayOff, error) {
+	var daysOff []DayOff
+	err := r.db.
+		Where("user_id = ? AND end_date >= ?", userID, calendarDate(from)).
+		Order("start_date ASC").
+		Find(&daysOff).Error
+	return daysOff, err
+}
+
+// DeleteDayOff удаляет выходной пользователя.
+func (r *gormRepository) DeleteDayOff(userID common.UserID, id int64) error {
+	return r.db.Where("user_id = ? AND id = ?", userID, id).Delete(&DayOff{}).Error
+}
+
+// IsDayOff проверяет, попадает ли календарная дата date (в часовом поясе самого date)
+// в выходные пользователя.
+func (r *gormRepository) IsDayOff(userID common.UserID, date time.Time) (bool, error) {
+	day := calendarDate(date)
+	var count int64
+	err := r.db.Model(&DayOff{}).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, day, day).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekdaySchedules(t *testing.T) {
+	repo := newTestRepository(t)
+
+	schedules := []WeekdaySchedule{
+		{UserID: testUserID, Weekday: time.Sunday},
+		{UserID: testUserID, Weekday: time.Saturday, Enabled: true, StartMinute: 600, FinishMinute: 720},
+		// Повторная запись заменяет прежнее расписание дня.
+		{UserID: testUserID, Weekday: time.Saturday, Enabled: true, StartMinute: 660, FinishMinute: 780},
+	}
+	for _, schedule := range schedules {
+		if err := repo.SetWeekdaySchedule(schedule); err != nil {
+			t.Fatalf("SetWeekdaySchedule: %v", err)
+		}
+	}
+
+	got, err := repo.GetWeekdaySchedules(testUserID)
+	if err != nil {
+		t.Fatalf("GetWeekdaySchedules: %v", err)
+	}
+	want := []WeekdaySchedule{schedules[0], schedules[2]}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("GetWeekdaySchedules() = %+v, want %+v", got, want)
+	}
+
+	if err := repo.DeleteWeekdaySchedule(testUserID, time.Sunday); err != nil {
+		t.Fatalf("DeleteWeekdaySchedule: %v", err)
+	}
+	got, err = repo.GetWeekdaySchedules(testUserID)
+	if err != nil {
+		t.Fatalf("GetWeekdaySchedules: %v", err)
+	}
+	if len(got) != 1 || got[0] != schedules[2] {
+		t.Errorf("GetWeekdaySchedules() after delete = %+v, want %+v", got, schedules[2:])
+	}
+}
+
+func TestDaysOff(t *testing.T) {
+	repo := newTestRepository(t)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, moscow) }
+
+	// Даты берутся по часовому поясу самого значения: полночь по Москве — ещё предыдущий день в UTC.
+	if err := repo.AddDayOff(DayOff{UserID: testUserID, StartDate: date(5, 10), EndDate: date(5, 10)}); err != nil {
+		t.Fatalf("AddDayOff: %v", err)
+	}
+	if err := repo.AddDayOff(DayOff{UserID: testUserID, StartDate: date(6, 1), EndDate: date(6, 14)}); err != nil {
+		t.Fatalf("AddDayOff: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{name: "single day", date: date(5, 10), want: true},
+		{name: "late evening of the day off", date: date(5, 10).Add(23 * time.Hour), want: true},
+		{name: "day before", date: date(5, 9)},
+		{name: "day after", date: date(5, 11)},
+		{name: "vacation start", date: date(6, 1), want: true},
+		{name: "inside vacation", date: date(6, 7).Add(12 * time.Hour), want: true},
+		{name: "vacation end", date: date(6, 14), want: true},
+		{name: "after vacation", date: date(6, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.IsDayOff(testUserID, tt.date)
+			if err != nil {
+				t.Fatalf("IsDayOff: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsDayOff(%v) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+
+	daysOff, err := repo.GetDaysOff(testUserID, date(5, 11))
+	if err != nil {
+		t.Fatalf("GetDaysOff: %v", err)
+	}
+	if len(daysOff) != 1 || !daysOff[0].StartDate.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("GetDaysOff() = %+v, want only the June vacation", daysOff)
+	}
+
+	if err := repo.DeleteDayOff(testUserID, daysOff[0].ID); err != nil {
+		t.Fatalf("DeleteDayOff: %v", err)
+	}
+	if dayOff, err := repo.IsDayOff(testUserID, date(6, 7)); err != nil || dayOff {
+		t.Errorf("IsDayOff() after delete = %v, %v, want false", dayOff, err)
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AddScheduledMessage сохраняет отложенное сообщение. Время отправки хранится в UTC,
+// чтобы сравнение в GetDueScheduledMessages не зависело от часового пояса.
+func (r *gormRepository) AddScheduledMessage(message ScheduledMessage) error {
+	message.ID = 0
+	message.SendAt = message.SendAt.UTC()
+	return r.db.Create(&message).Error
+}
+
+// GetDueScheduledMessages возвращает сообщения, время отправки которых уже наступило,
+// в порядке отправки.
+func (r *gormRepository) GetDueScheduledMessages(now time.Time) ([]ScheduledMessage, error) {
+	var messages []ScheduledMessage
+	result := r.db.Where("send_at <= ?", now.UTC()).Order("send_at ASC, id ASC").Find(&messages)
+	return messages, result.Error
+}
+
+// DeleteScheduledMessage удаляет отправленное сообщение.
+func (r *gormRepository) DeleteScheduledMessage(id int64) error {
+	return r.db.Delete(&ScheduledMessage{}, id).Error
+}
+
+// IncrementScheduledMessageAttempts увеличивает число неудачных попыток отправить сообщение.
+func (r *gormRepository) IncrementScheduledMessageAttempts(id int64) error {
+	return r.db.Model(&ScheduledMessage{}).Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + ?", 1)).Error
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledMessages(t *testing.T) {
+	repo := newTestRepository(t)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	// Время отправки в разных поясах: сравнение должно идти по моменту, а не по часам.
+	messages := []ScheduledMessage{
+		{UserID: testUserID, ChatID: 1, Kind: "late", SendAt: now.Add(time.Hour)},
+		{UserID: testUserID, ChatID: 1, Kind: "second", SendAt: now.Add(-time.Minute).In(moscow)},
+		{UserID: testUserID, ChatID: 1, Kind: "first", SendAt: now.Add(-time.Hour)},
+		{UserID: testUserID, ChatID: 1, Kind: "exact", SendAt: now.In(moscow)},
+	}
+	for _, message := range messages {
+		if err := repo.AddScheduledMessage(message); err != nil {
+			t.Fatalf("AddScheduledMessage(%s): %v", message.Kind, err)
+		}
+	}
+
+	due, err := repo.GetDueScheduledMessages(now.In(moscow))
+	if err != nil {
+		t.Fatalf("GetDueScheduledMessages: %v", err)
+	}
+	wantKinds := []string{"first", "second", "exact"}
+	if len(due) != len(wantKinds) {
+		t.Fatalf("got %d due messages, want %d: %+v", len(due), len(wantKinds), due)
+	}
+	for i, message := range due {
+		if message.Kind != wantKinds[i] {
+			t.Errorf("due[%d].Kind = %q, want %q", i, message.Kind, wantKinds[i])
+		}
+	}
+	if !due[1].SendAt.Equal(now.Add(-time.Minute)) {
+		t.Errorf("SendAt = %v, want %v", due[1].SendAt, now.Add(-time.Minute))
+	}
+
+	for range 2 {
+		if err := repo.IncrementScheduledMessageAttempts(due[0].ID); err != nil {
+			t.Fatalf("IncrementScheduledMessageAttempts: %v", err)
+		}
+	}
+	due, err = repo.GetDueScheduledMessages(now)
+	if err != nil {
+		t.Fatalf("GetDueScheduledMessages: %v", err)
+	}
+	for i, message := range due {
+		wantAttempts := int64(0)
+		if i == 0 {
+			wantAttempts = 2
+		}
+		if message.Attempts != wantAttempts {
+			t.Errorf("due[%d].Attempts = %d, want %d", i, message.Attempts, wantAttempts)
+		}
+	}
+
+	for _, message := range due {
+		if err := repo.DeleteScheduledMessage(message.ID); err != nil {
+			t.Fatalf("DeleteScheduledMessage(%d): %v", message.ID, err)
+		}
+	}
+	due, err = repo.GetDueScheduledMessages(now.Add(2 * time.Hour))
+	if err != nil {
+		t.Fatalf("GetDueScheduledMessages: %v", err)
+	}
+	if len(due) != 1 || due[0].Kind != "late" {
+		t.Errorf("due after delete = %+v, want only %q", due, "late")
+	}
+}
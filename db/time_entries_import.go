@@ -0,0 +1,121 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/csvimport"
+)
+
+// ImportTimeEntries превращает записи из CSV-экспорта Toggl/Clockify в логи активностей.
+// Колонки "Project / Task / Description" становятся путём в дереве активностей,
+// каждая запись — одним логом длиной в её длительность. Записи, пересекающиеся
+// с уже записанным временем, пропускаются, поэтому повторный импорт ничего не дублирует.
+// Записи без проекта, задачи и описания попадают в активность noProjectName.
+func (r *gormRepository) ImportTimeEntries(
+	userID common.UserID, entries []csvimport.Entry, noProjectName string,
+) (*TimeEntriesImportSummary, error) {
+	activitiesBefore, err := r.GetSimpleActivities(userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Логи за весь период импорта читаются одним запросом, пересечения проверяются в памяти.
+	existing, err := r.timeEntriesExistingLogs(userID, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &TimeEntriesImportSummary{ProjectMinutes: make(map[string]int64)}
+	activityIDs := make(map[string]int64)
+
+	for _, entry := range entries {
+		minutes := int64(entry.Duration() / time.Minute)
+		if minutes == 0 {
+			summary.EntriesSkipped++
+			continue
+		}
+
+		if isSlotAnswered(existing, entry.Start, entry.End) {
+			summary.EntriesSkipped++
+			continue
+		}
+
+		path := timeEntryActivityPath(entry, noProjectName)
+		activityID, ok := activityIDs[path]
+		if !ok {
+			activityID, err = r.FindOrAddActivityPath(userID, path)
+			if err != nil {
+				return nil, err
+			}
+			activityIDs[path] = activityID
+		}
+
+		activityLog := ActivityLog{
+			MessageID:       SyntheticMessageID(entry.End),
+			UserID:          int64(userID),
+			ActivityID:      activityID,
+			Timestamp:       entry.End,
+			IntervalMinutes: minutes,
+		}
+		if err := r.AddActivityLog(activityLog); err != nil {
+			return nil, err
+		}
+		// Пересекающиеся записи внутри одного файла тоже не должны дублировать время.
+		existing = append(existing, activityLog)
+
+		project := entry.Project
+		if project == "" {
+			project = noProjectName
+		}
+		summary.EntriesImported++
+		summary.MinutesImported += minutes
+		summary.ProjectMinutes[project] += minutes
+	}
+
+	activitiesAfter, err := r.GetSimpleActivities(userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	summary.ActivitiesCreated = len(activitiesAfter) - len(activitiesBefore)
+
+	return summary, nil
+}
+
+// timeEntriesExistingLogs возвращает логи, которые могут пересекаться с записями entries:
+// лог заканчивается не раньше начала самой ранней записи и длится не больше суток.
+func (r *gormRepository) timeEntriesExistingLogs(
+	userID common.UserID, entries []csvimport.Entry,
+) ([]ActivityLog, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	minStart, maxEnd := entries[0].Start, entries[0].End
+	for _, entry := range entries[1:] {
+		if entry.Start.Before(minStart) {
+			minStart = entry.Start
+		}
+		if entry.End.After(maxEnd) {
+			maxEnd = entry.End
+		}
+	}
+	return r.GetActivityLogs(userID, minStart, maxEnd.Add(24*time.Hour))
+}
+
+// timeEntryActivityPath строит путь активности "Project / Task / Description",
+// пропуская пустые части; без них запись попадает в активность noProjectName.
+func timeEntryActivityPath(entry csvimport.Entry, noProjectName string) string {
+	var parts []string
+	for _, part := range []string{entry.Project, entry.Task, entry.Description} {
+		// Разделитель пути не может встречаться внутри имени активности.
+		part = strings.TrimSpace(strings.ReplaceAll(part, " / ", "/"))
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return noProjectName
+	}
+	return strings.Join(parts, " / ")
+}
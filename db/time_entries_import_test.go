@@ -0,0 +1,106 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"TimeCounterBot/csvimport"
+)
+
+func TestImportTimeEntries(t *testing.T) {
+	base := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	entry := func(project string, start, end int) csvimport.Entry {
+		return csvimport.Entry{Project: project, Start: at(start), End: at(end)}
+	}
+
+	tests := []struct {
+		name     string
+		existing []ActivityLog // логи до импорта, ActivityID заполняется тестом
+		entries  []csvimport.Entry
+		want     TimeEntriesImportSummary
+		wantPath []string // пути активностей импортированных логов по порядку
+	}{
+		{
+			name: "entries become logs",
+			entries: []csvimport.Entry{
+				{Project: "Работа", Task: "Код", Description: "a / b", Start: at(0), End: at(90)},
+				entry("", 90, 120),
+			},
+			want: TimeEntriesImportSummary{
+				EntriesImported: 2, ActivitiesCreated: 4, MinutesImported: 120,
+				ProjectMinutes: map[string]int64{"Работа": 90, "Без проекта": 30},
+			},
+			wantPath: []string{"Работа / Код / a/b", "Без проекта"},
+		},
+		{
+			name:     "overlap with existing log skipped",
+			existing: []ActivityLog{{MessageID: 1, Timestamp: at(60), IntervalMinutes: 30}},
+			entries:  []csvimport.Entry{entry("Работа", 0, 45), entry("Работа", 60, 90)},
+			want: TimeEntriesImportSummary{
+				EntriesImported: 1, EntriesSkipped: 1, ActivitiesCreated: 1, MinutesImported: 30,
+				ProjectMinutes: map[string]int64{"Работа": 30},
+			},
+			wantPath: []string{"Работа"},
+		},
+		{
+			name:    "overlapping entries in one file and empty entries skipped",
+			entries: []csvimport.Entry{entry("Работа", 0, 60), entry("Работа", 30, 90), entry("Работа", 100, 100)},
+			want: TimeEntriesImportSummary{
+				EntriesImported: 1, EntriesSkipped: 2, ActivitiesCreated: 1, MinutesImported: 60,
+				ProjectMinutes: map[string]int64{"Работа": 60},
+			},
+			wantPath: []string{"Работа"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			if len(tt.existing) > 0 {
+				other := addTestActivity(t, repo, "Другое")
+				for _, activityLog := range tt.existing {
+					activityLog.UserID = int64(testUserID)
+					activityLog.ActivityID = other
+					if err := repo.AddActivityLog(activityLog); err != nil {
+						t.Fatalf("AddActivityLog: %v", err)
+					}
+				}
+			}
+
+			summary, err := repo.ImportTimeEntries(testUserID, tt.entries, "Без проекта")
+			if err != nil {
+				t.Fatalf("ImportTimeEntries: %v", err)
+			}
+			if summary.EntriesImported != tt.want.EntriesImported || summary.EntriesSkipped != tt.want.EntriesSkipped ||
+				summary.ActivitiesCreated != tt.want.ActivitiesCreated ||
+				summary.MinutesImported != tt.want.MinutesImported {
+				t.Errorf("summary = %+v, want %+v", *summary, tt.want)
+			}
+			for project, minutes := range tt.want.ProjectMinutes {
+				if summary.ProjectMinutes[project] != minutes {
+					t.Errorf("ProjectMinutes[%q] = %d, want %d", project, summary.ProjectMinutes[project], minutes)
+				}
+			}
+
+			logs, err := repo.GetActivityLogs(testUserID, at(-60), at(24*60))
+			if err != nil {
+				t.Fatalf("GetActivityLogs: %v", err)
+			}
+			var imported []ActivityLog
+			for _, activityLog := range logs {
+				// Импортированные логи получают отрицательные синтетические message_id.
+				if activityLog.MessageID < 0 {
+					imported = append(imported, activityLog)
+				}
+			}
+			if len(imported) != len(tt.wantPath) {
+				t.Fatalf("imported %d logs, want %d", len(imported), len(tt.wantPath))
+			}
+			for i, path := range tt.wantPath {
+				if want := addTestActivity(t, repo, path); imported[i].ActivityID != want {
+					t.Errorf("imported log %d has activity %d, want %q (%d)", i, imported[i].ActivityID, path, want)
+				}
+			}
+		})
+	}
+}
@@ -5,15 +5,15 @@ import (
 )
 
 // AddUser добавляет нового пользователя в базу.
-func AddUser(user User) error {
-	result := GormDB.Create(&user)
+func (r *gormRepository) AddUser(user User) error {
+	result := r.db.Create(&user)
 	return result.Error
 }
 
 // GetUserByID возвращает пользователя по его идентификатору.
-func GetUserByID(userID common.UserID) (*User, error) {
+func (r *gormRepository) GetUserByID(userID common.UserID) (*User, error) {
 	var user User
-	result := GormDB.First(&user, "id = ?", userID)
+	result := r.db.First(&user, "id = ?", userID)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -21,14 +21,39 @@ func GetUserByID(userID common.UserID) (*User, error) {
 }
 
 // UpdateUser обновляет данные пользователя.
-func UpdateUser(user User) error {
-	result := GormDB.Save(&user)
+func (r *gormRepository) UpdateUser(user User) error {
+	result := r.db.Save(&user)
+	return result.Error
+}
+
+// UpdateUserColumns обновляет только столбцы columns пользователя userID. Фоновые
+// задачи и обработчики пишут в одну строку одновременно, поэтому каждый путь
+// записывает лишь свои столбцы, а не всю строку, прочитанную раньше.
+func (r *gormRepository) UpdateUserColumns(userID common.UserID, columns map[string]any) error {
+	result := r.db.Model(&User{}).Where("id = ?", userID).Updates(columns)
+	return result.Error
+}
+
+// MigrateChatID переносит пользователей из чата oldChatID в newChatID
+// (Telegram меняет ID группы при её преобразовании в супергруппу).
+func (r *gormRepository) MigrateChatID(oldChatID, newChatID common.ChatID) error {
+	result := r.db.Model(&User{}).Where("chat_id = ?", oldChatID).Update("chat_id", newChatID)
 	return result.Error
 }
 
 // GetUsers возвращает список всех пользователей.
-func GetUsers() ([]User, error) {
+func (r *gormRepository) GetUsers() ([]User, error) {
 	var users []User
-	result := GormDB.Find(&users)
+	result := r.db.Find(&users)
 	return users, result.Error
 }
+
+// GetUserByCalendarToken возвращает пользователя по токену приватной ICS-ленты.
+func (r *gormRepository) GetUserByCalendarToken(token string) (*User, error) {
+	var user User
+	result := r.db.First(&user, "calendar_token = ?", token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
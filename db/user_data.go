@@ -0,0 +1,248 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// userDataExportVersion — версия формата полной выгрузки пользователя.
+const userDataExportVersion = "1.0"
+
+// ExportUserData выгружает все данные пользователя в YAML.
+func (r *gormRepository) ExportUserData(userID common.UserID) ([]byte, error) {
+	user, err := r.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activities, err := r.GetSimpleActivities(userID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[int64]string)
+	for _, route := range buildActivities(activities) {
+		paths[route.LeafID] = strings.TrimPrefix(route.Name, " / ")
+	}
+
+	var logs []ActivityLog
+	err = r.db.Where("user_id = ?", userID).Order("timestamp ASC").Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := r.GetImportRules(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := r.GetWeekdaySchedules(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var daysOff []DayOff
+	err = r.db.Where("user_id = ?", userID).Order("start_date ASC").Find(&daysOff).Error
+	if err != nil {
+		return nil, err
+	}
+
+	export := UserDataExport{
+		Version:    userDataExportVersion,
+		ExportDate: time.Now().Format(time.RFC3339),
+		User: UserSettingsExport{
+			ID:                          int64(user.ID),
+			ChatID:                      int64(user.ChatID),
+			TimerEnabled:                user.TimerEnabled,
+			TimerMinutes:                nullInt64Ptr(user.TimerMinutes),
+			ScheduleMorningStartHour:    nullInt64Ptr(user.ScheduleMorningStartHour),
+			ScheduleEveningFinishHour:   nullInt64Ptr(user.ScheduleEveningFinishHour),
+			ScheduleMorningStartMinute:  user.ScheduleMorningStartMinute,
+			ScheduleEveningFinishMinute: user.ScheduleEveningFinishMinute,
+			UnansweredPolicy:            user.UnansweredPolicy,
+			FallbackActivity:            paths[user.FallbackActivityID.Int64],
+			AdaptiveInterval:            user.AdaptiveInterval,
+			AdaptiveMinMinutes:          nullInt64Ptr(user.AdaptiveMinMinutes),
+			AdaptiveMaxMinutes:          nullInt64Ptr(user.AdaptiveMaxMinutes),
+			Language:                    user.Language,
+			PromptTone:                  user.PromptTone,
+			PromptTemplate:              user.PromptTemplate,
+			PromptShowSince:             user.PromptShowSince,
+		},
+		Activities: buildActivityTree(activities, -1),
+	}
+	for _, activityLog := range logs {
+		path, ok := paths[activityLog.ActivityID]
+		if !ok {
+			continue
+		}
+		export.Logs = append(export.Logs, ActivityLogExport{
+			MessageID:       activityLog.MessageID,
+			Activity:        path,
+			Timestamp:       activityLog.Timestamp.UTC(),
+			IntervalMinutes: activityLog.IntervalMinutes,
+		})
+	}
+	for _, rule := range rules {
+		export.ImportRules = append(export.ImportRules, ImportRuleExport{
+			Pattern:  rule.Pattern,
+			Activity: rule.ActivityPath,
+		})
+	}
+	for _, schedule := range schedules {
+		export.Schedules = append(export.Schedules, WeekdayScheduleExport{
+			Weekday:      int(schedule.Weekday),
+			Enabled:      schedule.Enabled,
+			StartMinute:  schedule.StartMinute,
+			FinishMinute: schedule.FinishMinute,
+		})
+	}
+	for _, dayOff := range daysOff {
+		export.DaysOff = append(export.DaysOff, DayOffExport{
+			StartDate: dayOff.StartDate.UTC(),
+			EndDate:   dayOff.EndDate.UTC(),
+		})
+	}
+
+	return yaml.Marshal(export)
+}
+
+// ImportUserData загружает выгрузку ExportUserData одной транзакцией и возвращает
+// идентификатор пользователя. Существующий пользователь получает настройки из выгрузки,
+// активности, логи и выходные добавляются к имеющимся, правила импорта и расписания
+// по дням недели заменяются.
+func (r *gormRepository) ImportUserData(data []byte) (common.UserID, error) {
+	var export UserDataExport
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return 0, err
+	}
+	if export.Version != userDataExportVersion {
+		return 0, errors.New("unsupported user export format version: " + export.Version)
+	}
+	if export.User.ID == 0 {
+		return 0, errors.New("user id is missing in export")
+	}
+
+	userID := common.UserID(export.User.ID)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		repo := &gormRepository{db: tx}
+
+		user := User{ID: userID}
+		if existing, err := repo.GetUserByID(userID); err == nil {
+			user = *existing
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		user.ChatID = common.ChatID(export.User.ChatID)
+		user.TimerEnabled = export.User.TimerEnabled
+		user.TimerMinutes = int64PtrToNull(export.User.TimerMinutes)
+		user.ScheduleMorningStartHour = int64PtrToNull(export.User.ScheduleMorningStartHour)
+		user.ScheduleEveningFinishHour = int64PtrToNull(export.User.ScheduleEveningFinishHour)
+		user.ScheduleMorningStartMinute = export.User.ScheduleMorningStartMinute
+		user.ScheduleEveningFinishMinute = export.User.ScheduleEveningFinishMinute
+		user.UnansweredPolicy = export.User.UnansweredPolicy
+		user.FallbackActivityID = sql.NullInt64{}
+		user.AdaptiveInterval = export.User.AdaptiveInterval
+		user.AdaptiveMinMinutes = int64PtrToNull(export.User.AdaptiveMinMinutes)
+		user.AdaptiveMaxMinutes = int64PtrToNull(export.User.AdaptiveMaxMinutes)
+		user.AdaptiveCurrentMinutes = sql.NullInt64{}
+		user.Language = export.User.Language
+		if export.User.PromptTone != "" {
+			user.PromptTone = export.User.PromptTone
+		}
+		user.PromptTemplate = export.User.PromptTemplate
+		user.PromptShowSince = export.User.PromptShowSince
+		if err := repo.UpdateUser(user); err != nil {
+			return err
+		}
+
+		for _, node := range export.Activities {
+			if err := repo.parseActivityNode(node, userID, -1); err != nil {
+				return err
+			}
+		}
+
+		if export.User.FallbackActivity != "" {
+			activityID, err := repo.FindOrAddActivityPath(userID, export.User.FallbackActivity)
+			if err != nil {
+				return err
+			}
+			user.FallbackActivityID = sql.NullInt64{Int64: activityID, Valid: true}
+			if err := repo.UpdateUser(user); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range export.Logs {
+			activityID, err := repo.FindOrAddActivityPath(userID, entry.Activity)
+			if err != nil {
+				return err
+			}
+			err = repo.AddActivityLog(ActivityLog{
+				MessageID:       entry.MessageID,
+				UserID:          int64(userID),
+				ActivityID:      activityID,
+				Timestamp:       entry.Timestamp,
+				IntervalMinutes: entry.IntervalMinutes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(export.Schedules) > 0 {
+			err := tx.Where("user_id = ?", userID).Delete(&WeekdaySchedule{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, schedule := range export.Schedules {
+			err := repo.SetWeekdaySchedule(WeekdaySchedule{
+				UserID:       userID,
+				Weekday:      time.Weekday(schedule.Weekday),
+				Enabled:      schedule.Enabled,
+				StartMinute:  schedule.StartMinute,
+				FinishMinute: schedule.FinishMinute,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, dayOff := range export.DaysOff {
+			err := repo.AddDayOff(DayOff{UserID: userID, StartDate: dayOff.StartDate, EndDate: dayOff.EndDate})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(export.ImportRules) == 0 {
+			return nil
+		}
+		rules := make([]ImportRule, 0, len(export.ImportRules))
+		for _, rule := range export.ImportRules {
+			rules = append(rules, ImportRule{Pattern: rule.Pattern, ActivityPath: rule.Activity})
+		}
+		return repo.ReplaceImportRules(userID, rules)
+	})
+	return userID, err
+}
+
+func nullInt64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func int64PtrToNull(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
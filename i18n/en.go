@@ -0,0 +1,397 @@
+package i18n
+
+// en — каталог сообщений на английском языке.
+var en = catalog{
+	"language.name": "English",
+
+	"duration.minutes":       "%d min",
+	"duration.hours":         "%d h",
+	"duration.hours_minutes": "%d h %d min",
+
+	// Описания команд бота.
+	"command.start":                 "Get started with the bot",
+	"command.settings":              "Notification settings",
+	"command.register_new_activity": "Register a new activity",
+	"command.start_notify":          "Start sending notifications",
+	"command.stop_notify":           "Stop sending notifications",
+	"command.mute_activity":         "Mute an activity (hide it from regular prompts)",
+	"command.unmute_activity":       "Unmute an activity (show it in regular prompts again)",
+	"command.analytics":             "Activity analytics and statistics",
+	"command.today":                 "Today's timeline",
+	"command.day":                   "Timeline of a day: /day YYYY-MM-DD",
+	"command.unanswered":            "What to do with unanswered prompts",
+	"command.adaptive":              "Adaptive notification interval",
+	"command.dnd":                   "Pause notifications: /dnd 2h, /dnd off",
+	"command.schedule":              "Notification schedule by weekday",
+	"command.day_off":               "Day off or vacation: /day_off [YYYY-MM-DD [YYYY-MM-DD]]",
+	"command.days_off":              "Planned days off",
+	"command.export_activities":     "Export the activity tree to a YAML file",
+	"command.import_activities":     "Import activities from a YAML file",
+	"command.delete_activity":       "Delete an activity with all its subactivities",
+	"command.export_ics":            "Export tracked time to a calendar (.ics)",
+	"command.ics_feed":              "Get a link to your private ICS feed",
+	"command.import_rules":          "Rules for importing calendar (.ics) events as activities",
+	"command.prompt":                "Prompt text: tone, custom template, time window",
+
+	// Уведомления и ответы на них.
+	"notify.missed":        "\n\nUnanswered today: %d — fill them in?",
+	"notify.missed_button": "📝 Fill in gaps (%d)",
+	"notify.saved":         "Saved activity \"%s\"",
+	"notify.removed":       "Removed",
+	"button.add_activity":  "➕ Add new activity",
+	"button.refresh":       "🔄 Refresh activities",
+	"button.split":         "✂️ Split",
+	"button.change":        "✏️ Change",
+	"button.remove":        "🗑 Remove",
+
+	// Пауза уведомлений (/dnd).
+	"format.datetime_short":  "Jan 2 15:04",
+	"snooze.until":           "🔕 Paused until %s",
+	"snooze.parse_error":     "Could not parse the duration: %s. Use: /dnd 2h, /dnd 90, /dnd 3d or /dnd off",
+	"snooze.error_days":      "cannot understand number of days %q",
+	"snooze.error_duration":  "cannot understand duration %q",
+	"snooze.error_positive":  "duration must be positive",
+	"snooze.error_too_long":  "pause cannot be longer than 30 days",
+	"snooze.status_paused":   "🔕 Notifications are paused until %s and will resume automatically.",
+	"snooze.status_active":   "🔔 Notifications are not paused. Pause them with the buttons below or /dnd 2h.",
+	"snooze.button_tomorrow": "🌙 Until tomorrow",
+	"snooze.button_resume":   "▶️ Resume",
+	"snooze.button_pause":    "⏸ Pause %s",
+
+	// Меню аналитики.
+	"button.back":                     "⬅️ Back",
+	"analytics.menu":                  "📊 *Activity analytics*\n\nChoose a report:",
+	"analytics.button_stats":          "📈 Statistics for a period",
+	"analytics.button_compare":        "📊 Compare periods",
+	"analytics.stats_menu":            "📈 *Activity statistics*\n\nChoose a period:",
+	"analytics.button_today":          "📅 Today",
+	"analytics.button_yesterday":      "📅 Yesterday",
+	"analytics.button_this_week":      "📅 This week",
+	"analytics.button_last_week":      "📅 Last week",
+	"analytics.choose_stats_period":   "Choose a period for statistics",
+	"analytics.compare_menu":          "📊 *Period comparison*\n\nChoose the periods to compare:",
+	"analytics.button_weeks":          "📅 This week vs last",
+	"analytics.button_months":         "📆 This month vs last",
+	"analytics.button_custom":         "🔧 Custom periods",
+	"analytics.choose_compare_period": "Choose periods to compare",
+	"analytics.main_menu":             "Analytics main menu",
+	"analytics.compare_menu_toast":    "Period comparison menu",
+	"analytics.this_week":             "This week",
+	"analytics.last_week":             "Last week",
+	"analytics.this_month":            "This month",
+	"analytics.last_month":            "Last month",
+	"analytics.data_error":            "Failed to load data",
+	"analytics.compared":              "Comparison done",
+	"analytics.custom": "🔧 *Custom comparison*\n\nThis feature is not implemented yet.\n" +
+		"Later you will be able to pick arbitrary dates to compare.",
+	"analytics.in_progress":        "Coming soon",
+	"analytics.no_comparison_data": "📊 *Period comparison*\n\nNo data to compare.",
+	"analytics.comparison_title":   "📊 *Comparison: %s vs %s*\n\n",
+	"analytics.total_time":         "⏱ *Total time:*\n",
+	"analytics.change_up":          "📈 *Change:* +%.1f h\n\n",
+	"analytics.change_down":        "📉 *Change:* %.1f h\n\n",
+	"analytics.change_none":        "➖ *Change:* none\n\n",
+	"analytics.main_changes":       "*Main changes:*\n",
+	"analytics.new_activity":       " (new)",
+	"analytics.gone_activity":      " (gone)",
+	"analytics.activity_change":    "%s *%s*: %s%.1f h%s\n",
+	"analytics.no_changes":         "No significant changes.",
+	"analytics.unknown_period":     "Unknown period",
+	"analytics.period_today":       "for today",
+	"analytics.period_yesterday":   "for yesterday",
+	"analytics.period_this_week":   "for this week",
+	"analytics.period_last_week":   "for last week",
+	"format.date":                  "Jan 2, 2006",
+	"analytics.chart_caption":      "📊 Activity chart %s\n(%s - %s)",
+	"analytics.back_to_periods":    "⬅️ Back to periods",
+	"analytics.chart_error":        "Failed to create the chart",
+	"analytics.chart_done":         "Chart created",
+
+	// /adaptive
+	"adaptive.ask_min":       "Send the lower bound of the interval in minutes (1 to %d).",
+	"adaptive.ask_max":       "Send the upper bound of the interval in minutes (1 to %d).",
+	"adaptive.error_minutes": "the bound must be a whole number of minutes from 1 to %d",
+	"adaptive.error_bounds":  "the minimum must be less than the maximum",
+	"adaptive.disabled": "Adaptive interval is off, notifications arrive every %s.\n" +
+		"When it is on, the interval grows while you keep answering with the same activity and shrinks when the answers " +
+		"change.",
+	"adaptive.enabled": "Adaptive interval is on: from %s to %s, currently %s.\n" +
+		"The interval grows while you keep answering with the same activity and shrinks when the answers change.",
+	"adaptive.button_enable":  "✅ Turn on",
+	"adaptive.button_disable": "⏸ Turn off",
+	"adaptive.button_min":     "⬇️ Minimum: %s",
+	"adaptive.button_max":     "⬆️ Maximum: %s",
+
+	// Пропущенные уведомления
+	"unanswered.auto_filled":          "Auto-filled activity \"%s\"",
+	"unanswered.choose_fallback":      "Choose the activity to record unanswered notifications into:",
+	"unanswered.current_leave_empty":  "leave them empty",
+	"unanswered.current_same_as_next": "record the same as the next answer",
+	"unanswered.deleted_activity":     "(deleted activity)",
+	"unanswered.current_fallback":     "record them into \"%s\"",
+	"unanswered.policy": "What should happen to notifications you did not answer before the next one?\n" +
+		"Currently: %s.",
+	"unanswered.button_leave_empty":  "Leave empty",
+	"unanswered.button_fallback":     "Record into an activity…",
+	"unanswered.button_same_as_next": "Same as the next answer",
+
+	// Общие сообщения
+	"common.finish_command": "Finish the current command first",
+	"button.cancel":         "❌ Cancel",
+
+	// Разделение интервала
+	"split.too_short":       "The interval is too short to split",
+	"split.expired_restart": "This split has expired, start over",
+	"split.expired":         "This split has expired",
+	"split.ask_minutes":     "Write how many minutes each activity took, separated by spaces (%d in total):\n%s",
+	"split.parse_error":     "Could not split: %s. Press «Manual» again.",
+	"split.pick_two":        "Choose at least two activities",
+	"split.saved":           "Saved split:",
+	"split.draft":           "✂️ Split %s–%s (%s) between activities.\n",
+	"split.selected":        "Selected:\n",
+	"split.button_equal":    "✅ Equally",
+	"split.button_custom":   "✍️ Manual",
+	"split.error_count":     "expected %d numbers, got %d",
+	"split.error_positive":  "minutes must be positive whole numbers",
+	"split.error_sum":       "the minutes add up to %d instead of %d",
+
+	"common.already_in_command": "You're already executing some command",
+
+	// Импорт
+	"csv_import.parse_error": "Could not parse the CSV: %v\n\n" +
+		"Detailed reports from Toggl Track and Clockify are supported.",
+	"csv_import.error": "Failed to import entries: %v",
+	"csv_import.summary": "✅ Import from %s finished\n\nEntries imported: %d (%s)\n" +
+		"Skipped (empty or already recorded): %d\nNew activities created: %d\n",
+	"csv_import.by_project":    "\nBy project:\n",
+	"csv_import.more_projects": "• and %d more\n",
+	"csv_import.no_project":    "No project",
+	"ics_import.ask_rules": "Send a new list of rules, one per line:\n`regular expression => Area / Activity`\n\n" +
+		"For example:\n`(?i)standup|sync => Work / Meetings`\n\n" +
+		"Rules are applied in order, the first matching one wins. Send `-` to delete all rules.",
+	"ics_import.rules_error":  "Rules were not saved: %v",
+	"ics_import.rules_saved":  "✅ Rules saved: %d. Now send an .ics file to import.",
+	"ics_import.error_format": "line %d: expected `regular expression => path`",
+	"ics_import.error_regexp": "line %d: invalid regular expression: %v",
+	"ics_import.rules_title":  "📥 *Calendar import rules*\n\n",
+	"ics_import.no_rules":     "No rules yet.",
+	"ics_import.parse_error":  "Could not parse the calendar: %v",
+	"ics_import.no_rules_yet": "First set up rules that map events to activities: /import_rules",
+	"ics_import.error":        "Failed to import the calendar: %v",
+	"ics_import.summary": "✅ Calendar imported\n\nEvents matched: %d\nEvents without a matching rule: %d\n" +
+		"Slots recorded: %d (%s)\nAlready filled slots skipped: %d",
+
+	// /settings
+	"settings.ask_interval":      "Write the notification interval in minutes (from 1 to %d).",
+	"settings.ask_start":         "Write when (%s) notifications should start, for example 8:30.",
+	"settings.ask_finish":        "Write when (%s) notifications should stop, for example 22:15.",
+	"settings.save_error":        "Could not save: %s. Press the button again.",
+	"settings.incomplete":        "Set the interval, start and finish first",
+	"settings.error_interval":    "the interval must be a whole number of minutes from 1 to %d",
+	"settings.all_day":           " (around the clock)",
+	"settings.interval_unset":    "not set",
+	"settings.time_unset":        "not set",
+	"settings.notifications_off": "off",
+	"settings.notifications_on":  "on",
+	"settings.text": "⚙️ Settings\n\nNotification interval: %s\nStart: %s\nFinish: %s\nNotifications: %s\n" +
+		"Language: %s\n\nWeekday schedule — /schedule, days off — /day_off, pause — /dnd, adaptive interval — /adaptive, " +
+		"prompt text — /prompt.",
+	"settings.button_enable":   "🔔 Turn notifications on",
+	"settings.button_disable":  "🔕 Turn notifications off",
+	"settings.button_interval": "⏱ Interval",
+	"settings.button_start":    "🌅 Start",
+	"settings.button_finish":   "🌇 Finish",
+
+	// /schedule и выходные
+	"weekday.1":               "Mon",
+	"weekday.2":               "Tue",
+	"weekday.3":               "Wed",
+	"weekday.4":               "Thu",
+	"weekday.5":               "Fri",
+	"weekday.6":               "Sat",
+	"weekday.0":               "Sun",
+	"schedule.window_off":     "off",
+	"schedule.window_all_day": "around the clock from %s",
+	"schedule.error_window":   "expected two times separated by a dash",
+	"schedule.error_clock":    "cannot understand the time %q",
+	"schedule.not_configured": "Set up notifications with /start first.",
+	"schedule.ask_window": "Write the notification time (%s) for: %s.\n" +
+		"For example, 09:30-18:00 or 22:00-01:30. «off» — no notifications on these days, «default» — go back to the " +
+		"common schedule from /settings.",
+	"schedule.parse_error":     "Could not parse the time: %s. Choose the days again.",
+	"schedule.title":           "Notification schedule (%s):\n",
+	"schedule.default_note":    " (default)",
+	"schedule.hint":            "\nChoose days to change the time. Days off and vacations — /day_off.",
+	"schedule.button_weekdays": "Mon–Fri",
+	"schedule.button_weekend":  "Sat–Sun",
+	"schedule.button_all_days": "Every day",
+	"day_off.usage":            "Invalid format. Use: /day_off, /day_off 2025-01-31 or /day_off 2025-01-31 2025-02-09",
+	"day_off.save_error":       "Could not save the day off.",
+	"day_off.saved":            "🏖 Day off: %s. No notifications or daily summaries. List of days off — /days_off.",
+	"day_off.none":             "No days off planned. Add one — /day_off.",
+	"day_off.list":             "Planned days off (tap to delete):",
+
+	// Хронология
+	"timeline.day_usage":      "Invalid date format. Use: /day 2025-01-31",
+	"timeline.error":          "Could not load the day timeline.",
+	"timeline.empty":          "There are no answers or notifications for %s.",
+	"timeline.title":          "🗓 Timeline for %s (%s)\n\n",
+	"timeline.no_answer":      "⏳ no answer",
+	"timeline.totals":         "\nRecorded: %s, unanswered: %s",
+	"timeline.slot_not_found": "Slot not found",
+	"timeline.removed":        "%s: answer removed",
+	"timeline.button_fill":    "✏️ Fill in",
+	"timeline.saved":          "%s: saved \"%s\"",
+	"timeline.slot_question":  "What were you doing %s?",
+	"timeline.slot_current":   "\nCurrently recorded: %s",
+	"timeline.missed":         "⏳ Missed: %s",
+	"timeline.expired_prompt": "This notification has expired. Fill in the interval with the «Fill in» button or via " +
+		"/today.",
+
+	// Итоги дня
+	"day_stats.prompt":  "If you have filled in all of today's activities, PRESS THE BUTTON!",
+	"day_stats.button":  "Show",
+	"day_stats.caption": "Activity chart for today (made at %s for the interval [%s, %s]).",
+	"day_stats.refresh": "🔄 Refresh chart",
+
+	// /start
+	"start.welcome_back": "Welcome back! Notifications are on again with your previous schedule.",
+	"start.greeting": "Hi! You are using Andrew's time management bot.\n" +
+		"Firstly, tell me the time interval in which you want to receive question about your activity.",
+	"start.interval_set": "Nice, your interval is %s!\nNow tell me the hour (%s) to start sending you reminders.",
+	"start.start_set": "Wonderful, your start hour will be %[1]d:00 %[2]s!\n" +
+		"And now tell me the hour (%[2]s) to finish sending reminders and send day statistics.",
+	"start.finish_set": "Cool. You will get notifications every %[1]s, from %[2]d:00 %[4]s to %[3]d:00 %[4]s.\n" +
+		"Now click the button to enable notifications.",
+	"start.finish_set_enabled": "You will get notifications every %[1]s, from %[2]d:00 %[4]s to %[3]d:00 %[4]s.\n" +
+		"Notifications enabled! You can disable them by pressing button below.",
+	"start.summary":        "You will get notifications every %[1]s, from %[2]s %[4]s to %[3]s %[4]s.\n",
+	"start.enabled":        "Notifications enabled!",
+	"start.disabled":       "Notifications disabled!",
+	"start.button_enable":  "Enable notifications!",
+	"start.button_disable": "Disable notifications!",
+
+	"common.send_file_error": "Failed to send the file.",
+	"common.cancelled":       "Cancelled",
+
+	// Активности
+	"activities.export_error":   "Failed to export activities.",
+	"activities.export_caption": "Your activities exported as YAML",
+	"activities.import_prompt": "Send a YAML file with exported activities to import.\n\n" +
+		"⚠️ Note: the import adds new activities to the existing ones without replacing them.",
+	"activities.import_not_document": "Please send the YAML file as a document, not as text.",
+	"activities.import_unsupported": "Only YAML files (.yaml or .yml), calendars (.ics) and Toggl/Clockify CSV " +
+		"exports (.csv) are supported",
+	"activities.download_error":   "Failed to download the file.",
+	"activities.import_error":     "Failed to import activities: %v",
+	"activities.imported":         "✅ Activities imported!",
+	"activities.choose_delete":    "Choose an activity to delete:",
+	"activities.unknown":          "unknown activity",
+	"activities.delete_error":     "Failed to delete the activity",
+	"activities.deleted":          "✅ Activity '%s' and all of its subactivities were deleted.",
+	"activities.deleted_toast":    "Activity deleted",
+	"activities.delete_cancelled": "Activity deletion cancelled.",
+	"activities.list_refreshed":   "List refreshed",
+	"activities.ask_new":          "Write new activity",
+	"activities.add_error":        "Could not add the activity.",
+	"activities.added":            "New activity \"%s\" added!",
+	"mute.ask_unmute":             "What do you want to unmute?",
+	"mute.ask_mute":               "What do you want to mute?",
+	"mute.unmuted":                "Unmuted activity \"%s\"",
+	"mute.muted":                  "Muted activity \"%s\"",
+
+	// Календарь
+	"ics_export.usage":   "Invalid date format. Use: /export_ics 2025-01-01 2025-01-31",
+	"ics_export.error":   "Failed to export the calendar.",
+	"ics_export.empty":   "There are no recorded activities for the selected period.",
+	"ics_export.caption": "Activity calendar from %s to %s (%d events)",
+	"ics_feed.disabled":  "The ICS feed is not configured on this server.",
+	"ics_feed.link": "Your private ICS feed (last 90 days):\n%s/ics/%s.ics\n\n" +
+		"Do not share this link. To issue a new link, send /ics_feed reset",
+
+	// Текст уведомлений (/prompt)
+	"prompt.tone_playful":      "What are you up to?",
+	"prompt.tone_neutral":      "What have you been doing for the last {interval}?",
+	"prompt.tone_work":         "What did you work on from {from} to {to}?",
+	"prompt.since":             "\n\n🕐 From %s to %s %s",
+	"prompt.no_last":           "—",
+	"prompt.tone_name_playful": "😜 Playful",
+	"prompt.tone_name_neutral": "🙂 Neutral",
+	"prompt.tone_name_work":    "💼 Work",
+	"prompt.custom":            "custom template",
+	"prompt.since_on":          "shown",
+	"prompt.since_off":         "hidden",
+	"prompt.settings": "✉️ Prompt text\n\nTone: %s\nTime window in the prompt: %s\n\n" +
+		"This is how the prompt will look:\n%s\n\n" +
+		"Custom template: /prompt <text>. Placeholders: {from} and {to} — start and end of the window, {interval} — its " +
+		"length, {streak} — how many days in a row you have answered, {last} — the last recorded activity. Use the " +
+		"buttons below to go back to a preset tone.",
+	"prompt.button_since_on":   "🕐 Show time window",
+	"prompt.button_since_off":  "🕐 Hide time window",
+	"prompt.saved":             "Template saved.",
+	"prompt.parse_error":       "Could not save the template: %s",
+	"prompt.error_empty":       "the template is empty",
+	"prompt.error_too_long":    "the template is longer than %d characters",
+	"prompt.error_placeholder": "unknown placeholder %s",
+
+	// Распределение времени (/analytics)
+	"analytics.button_patterns": "🔥 When you do what",
+	"patterns.menu": "🔥 *Time patterns*\n\n" +
+		"The heatmap and the hour-of-day chart use answers from the last %d days, the calendar covers a year. Times are " +
+		"in %s.",
+	"patterns.menu_toast":       "Time patterns",
+	"patterns.button_heatmap":   "🗓 Weekdays × hours",
+	"patterns.button_calendar":  "🟩 Year calendar",
+	"patterns.button_hours":     "🕐 By hour of day",
+	"patterns.no_data":          "No data for this period",
+	"patterns.all_activities":   "All activities",
+	"patterns.other":            "Other",
+	"patterns.hours_unit":       "Hours",
+	"patterns.hours_per_day":    "Hours per day",
+	"patterns.minutes_per_day":  "Average minutes per day",
+	"patterns.heatmap_title":    "%s: weekdays × hours (%s), last %d days",
+	"patterns.heatmap_caption":  "🗓 %s — %s over the last %d days.",
+	"patterns.heatmap_subtree":  "\n\nThe buttons below open heatmaps of sub-activities.",
+	"patterns.calendar_title":   "Tracked time per day, %s — %s",
+	"patterns.calendar_caption": "🟩 %s tracked over the year, with answers on %d of %d days.",
+	"patterns.hours_title":      "Average time by hour of day (%s), last %d days",
+	"patterns.hours_caption": "🕐 Hour-of-day distribution over the last %d days. Most time is tracked at " +
+		"%02d:00–%02d:00 %s.",
+	"month.1":  "Jan",
+	"month.2":  "Feb",
+	"month.3":  "Mar",
+	"month.4":  "Apr",
+	"month.5":  "May",
+	"month.6":  "Jun",
+	"month.7":  "Jul",
+	"month.8":  "Aug",
+	"month.9":  "Sep",
+	"month.10": "Oct",
+	"month.11": "Nov",
+	"month.12": "Dec",
+
+	// Тренды (/analytics)
+	"analytics.button_trends": "📉 Trends",
+	"trends.menu": "📉 *Trends*\n\n" +
+		"Time per top-level activity over the last complete weeks or months: bars are activities, the line is a moving " +
+		"average. Choose a period:",
+	"trends.menu_toast":     "Trends",
+	"trends.button_weeks":   "📅 %d weeks",
+	"trends.button_months":  "📆 %d months",
+	"format.day_month":      "Jan 2",
+	"trends.month_label":    "%s %d",
+	"trends.title":          "Time per activity, %s — %s",
+	"trends.moving_average": "Moving average (%d)",
+	"trends.unit_weeks":     "wk",
+	"trends.unit_months":    "mo",
+	"trends.per_weeks":      "per week",
+	"trends.per_months":     "per month",
+	"trends.summary": "📉 Trends from %s to %s\n\nTotal now: %s %s, before — %s.\n" +
+		"\"Now\" is the average over the last %d %s, \"before\" over the same span preceding them.\n",
+	"trends.risers":     "\n📈 Rising:\n",
+	"trends.fallers":    "\n📉 Falling:\n",
+	"trends.change":     "• %s: %s → %s (%s%s %s)\n",
+	"trends.no_changes": "\nNo noticeable changes.",
+	"trends.short_history": "📉 Trends from %s to %s\n\n" +
+		"There are not enough answers to compare periods yet: at least two periods with answers are needed.",
+}
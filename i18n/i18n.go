@@ -0,0 +1,74 @@
+// Package i18n содержит каталоги сообщений бота и выбор языка пользователя.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang — код языка интерфейса (совпадает с кодом языка Telegram).
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// Default — язык пользователей, у которых язык не выбран и не определился.
+const Default = Russian
+
+// Supported — языки, для которых есть каталоги, в порядке показа пользователю.
+var Supported = []Lang{Russian, English}
+
+// catalog сопоставляет ключам сообщений шаблоны для fmt.Sprintf.
+type catalog map[string]string
+
+var catalogs = map[Lang]catalog{
+	Russian: ru,
+	English: en,
+}
+
+// Detect выбирает язык интерфейса по коду языка из Telegram (User.LanguageCode):
+// русский для русскоязычных и соседних локалей, английский для остальных.
+// Пустой код означает, что язык неизвестен, — тогда используется Default.
+func Detect(languageCode string) Lang {
+	code, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	switch code {
+	case "":
+		return Default
+	case "ru", "uk", "be", "kk":
+		return Russian
+	default:
+		return English
+	}
+}
+
+// Parse возвращает язык по сохранённому коду; неизвестный или пустой код даёт Default.
+func Parse(code string) Lang {
+	if _, ok := catalogs[Lang(code)]; ok {
+		return Lang(code)
+	}
+	return Default
+}
+
+// Name возвращает название языка на нём самом.
+func (l Lang) Name() string {
+	return T(l, "language.name")
+}
+
+// T возвращает сообщение key на языке lang, подставляя args как в fmt.Sprintf.
+// Если в каталоге языка сообщения нет, берётся сообщение из каталога Default,
+// а если нет и там — сам ключ, чтобы пропуск был заметен.
+func T(lang Lang, key string, args ...any) string {
+	format, ok := catalogs[Parse(string(lang))][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		code string
+		want Lang
+	}{
+		{code: "", want: Default},
+		{code: "ru", want: Russian},
+		{code: "RU", want: Russian},
+		{code: "uk", want: Russian},
+		{code: "be-BY", want: Russian},
+		{code: "kk", want: Russian},
+		{code: "en", want: English},
+		{code: "en-US", want: English},
+		{code: "de", want: English},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.code); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		code string
+		want Lang
+	}{
+		{code: "ru", want: Russian},
+		{code: "en", want: English},
+		{code: "", want: Default},
+		{code: "de", want: Default},
+		{code: "EN", want: Default},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.code); got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	// Каталог без ключа language.name: сообщение должно взяться из Default.
+	catalogs["test"] = catalog{"only.test": "тест"}
+	defer delete(catalogs, "test")
+
+	tests := []struct {
+		name string
+		lang Lang
+		key  string
+		args []any
+		want string
+	}{
+		{name: "russian", lang: Russian, key: "language.name", want: ru["language.name"]},
+		{name: "english", lang: English, key: "language.name", want: en["language.name"]},
+		{name: "with arguments", lang: English, key: "notify.saved", args: []any{"Work"}, want: `Saved activity "Work"`},
+		{name: "unknown language", lang: "de", key: "language.name", want: ru["language.name"]},
+		{name: "missing in the language", lang: "test", key: "language.name", want: ru["language.name"]},
+		{name: "missing everywhere", lang: English, key: "no.such.key", want: "no.such.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+// formatVerb находит глаголы fmt в шаблоне сообщения.
+var formatVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z%]`)
+
+// TestCatalogsMatch проверяет, что во всех каталогах одни и те же ключи с одинаковыми
+// аргументами: иначе пользователь увидит сообщение на другом языке или %!v(MISSING).
+func TestCatalogsMatch(t *testing.T) {
+	reference := catalogs[Default]
+	for _, lang := range Supported {
+		current, ok := catalogs[lang]
+		if !ok {
+			t.Errorf("no catalog for supported language %q", lang)
+			continue
+		}
+		for key, format := range reference {
+			translated, ok := current[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", lang, key)
+				continue
+			}
+			want, got := formatVerb.FindAllString(format, -1), formatVerb.FindAllString(translated, -1)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s: %q has verbs %v, want %v", lang, key, got, want)
+			}
+		}
+		for key := range current {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s: key %q is not in the %s catalog", lang, key, Default)
+			}
+		}
+	}
+}
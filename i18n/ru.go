@@ -0,0 +1,397 @@
+package i18n
+
+// ru — каталог сообщений на русском языке. Он же используется, когда сообщения
+// нет в каталоге языка пользователя.
+var ru = catalog{
+	"language.name": "Русский",
+
+	"duration.minutes":       "%d мин",
+	"duration.hours":         "%d ч",
+	"duration.hours_minutes": "%d ч %d мин",
+
+	// Описания команд бота.
+	"command.start":                 "Начать работу с ботом",
+	"command.settings":              "Настройки уведомлений",
+	"command.register_new_activity": "Зарегистрировать новую активность",
+	"command.start_notify":          "Начать присылать уведомления",
+	"command.stop_notify":           "Закончить присылать уведомления",
+	"command.mute_activity":         "Замьютить активность (чтобы не отображалась в регулярных опросах)",
+	"command.unmute_activity":       "Размьютить активность (чтобы снова появилась в регулярных опросах)",
+	"command.analytics":             "Аналитика и статистика активностей",
+	"command.today":                 "Хронология сегодняшнего дня",
+	"command.day":                   "Хронология дня: /day YYYY-MM-DD",
+	"command.unanswered":            "Что делать с уведомлениями без ответа",
+	"command.adaptive":              "Адаптивный интервал уведомлений",
+	"command.dnd":                   "Пауза уведомлений: /dnd 2h, /dnd off",
+	"command.schedule":              "Расписание уведомлений по дням недели",
+	"command.day_off":               "Выходной или отпуск: /day_off [YYYY-MM-DD [YYYY-MM-DD]]",
+	"command.days_off":              "Запланированные выходные",
+	"command.export_activities":     "Экспортировать дерево активностей в YAML файл",
+	"command.import_activities":     "Импортировать активности из YAML файла",
+	"command.delete_activity":       "Удалить активность и все её подактивности",
+	"command.export_ics":            "Экспортировать записанное время в календарь (.ics)",
+	"command.ics_feed":              "Получить ссылку на приватную ICS-ленту",
+	"command.import_rules":          "Правила импорта событий календаря (.ics) в активности",
+	"command.prompt":                "Текст уведомлений: тон, свой шаблон, интервал",
+
+	// Уведомления и ответы на них.
+	"notify.missed":        "\n\nСегодня без ответа: %d — заполнить?",
+	"notify.missed_button": "📝 Заполнить пропуски (%d)",
+	"notify.saved":         "Сохранено: «%s»",
+	"notify.removed":       "Удалено",
+	"button.add_activity":  "➕ Новая активность",
+	"button.refresh":       "🔄 Обновить",
+	"button.split":         "✂️ Разделить",
+	"button.change":        "✏️ Изменить",
+	"button.remove":        "🗑 Удалить",
+
+	// Пауза уведомлений (/dnd).
+	"format.datetime_short": "02.01 15:04",
+	"snooze.until":          "🔕 Пауза до %s",
+	"snooze.parse_error": "Не получилось разобрать длительность: %s. Используйте: /dnd 2h, /dnd 90, /dnd 3d или /dnd " +
+		"off",
+	"snooze.error_days":      "не понимаю число дней %q",
+	"snooze.error_duration":  "не понимаю длительность %q",
+	"snooze.error_positive":  "длительность должна быть положительной",
+	"snooze.error_too_long":  "пауза не может быть длиннее 30 дней",
+	"snooze.status_paused":   "🔕 Уведомления на паузе до %s, потом включатся сами.",
+	"snooze.status_active":   "🔔 Уведомления не на паузе. Поставить паузу — кнопками ниже или /dnd 2h.",
+	"snooze.button_tomorrow": "🌙 До завтра",
+	"snooze.button_resume":   "▶️ Возобновить",
+	"snooze.button_pause":    "⏸ Пауза %s",
+
+	// Меню аналитики.
+	"button.back":                     "⬅️ Назад",
+	"analytics.menu":                  "📊 *Аналитика активностей*\n\nВыберите тип отчета:",
+	"analytics.button_stats":          "📈 Статистика за период",
+	"analytics.button_compare":        "📊 Сравнить периоды",
+	"analytics.stats_menu":            "📈 *Статистика активностей*\n\nВыберите период для анализа:",
+	"analytics.button_today":          "📅 Сегодня",
+	"analytics.button_yesterday":      "📅 Вчера",
+	"analytics.button_this_week":      "📅 Эта неделя",
+	"analytics.button_last_week":      "📅 Прошлая неделя",
+	"analytics.choose_stats_period":   "Выберите период для статистики",
+	"analytics.compare_menu":          "📊 *Сравнение периодов*\n\nВыберите, какие периоды хотите сравнить:",
+	"analytics.button_weeks":          "📅 Эта неделя vs прошлая",
+	"analytics.button_months":         "📆 Этот месяц vs прошлый",
+	"analytics.button_custom":         "🔧 Настроить периоды",
+	"analytics.choose_compare_period": "Выберите период для сравнения",
+	"analytics.main_menu":             "Главное меню аналитики",
+	"analytics.compare_menu_toast":    "Меню сравнения периодов",
+	"analytics.this_week":             "Эта неделя",
+	"analytics.last_week":             "Прошлая неделя",
+	"analytics.this_month":            "Этот месяц",
+	"analytics.last_month":            "Прошлый месяц",
+	"analytics.data_error":            "Ошибка получения данных",
+	"analytics.compared":              "Сравнение выполнено",
+	"analytics.custom": "🔧 *Настраиваемое сравнение*\n\nЭта функция пока не реализована.\n" +
+		"В будущем здесь можно будет выбрать произвольные даты для сравнения.",
+	"analytics.in_progress":        "Функция в разработке",
+	"analytics.no_comparison_data": "📊 *Сравнение периодов*\n\nНет данных для сравнения.",
+	"analytics.comparison_title":   "📊 *Сравнение: %s vs %s*\n\n",
+	"analytics.total_time":         "⏱ *Общее время:*\n",
+	"analytics.change_up":          "📈 *Изменение:* +%.1f ч\n\n",
+	"analytics.change_down":        "📉 *Изменение:* %.1f ч\n\n",
+	"analytics.change_none":        "➖ *Изменение:* без изменений\n\n",
+	"analytics.main_changes":       "*Основные изменения:*\n",
+	"analytics.new_activity":       " (новая)",
+	"analytics.gone_activity":      " (исчезла)",
+	"analytics.activity_change":    "%s *%s*: %s%.1f ч%s\n",
+	"analytics.no_changes":         "Значительных изменений не обнаружено.",
+	"analytics.unknown_period":     "Неизвестный период",
+	"analytics.period_today":       "сегодня",
+	"analytics.period_yesterday":   "вчера",
+	"analytics.period_this_week":   "на этой неделе",
+	"analytics.period_last_week":   "на прошлой неделе",
+	"format.date":                  "02.01.2006",
+	"analytics.chart_caption":      "📊 Диаграмма активности %s\n(%s - %s)",
+	"analytics.back_to_periods":    "⬅️ Назад к выбору периода",
+	"analytics.chart_error":        "Ошибка создания графика",
+	"analytics.chart_done":         "График создан",
+
+	// /adaptive
+	"adaptive.ask_min":       "Напишите нижнюю границу интервала в минутах (от 1 до %d).",
+	"adaptive.ask_max":       "Напишите верхнюю границу интервала в минутах (от 1 до %d).",
+	"adaptive.error_minutes": "граница должна быть целым числом минут от 1 до %d",
+	"adaptive.error_bounds":  "минимум должен быть меньше максимума",
+	"adaptive.disabled": "Адаптивный интервал выключен, уведомления приходят каждые %s.\n" +
+		"Если включить, интервал будет расти, пока вы отвечаете одной и той же активностью, и сокращаться, когда ответы " +
+		"меняются.",
+	"adaptive.enabled": "Адаптивный интервал включён: от %s до %s, сейчас %s.\n" +
+		"Интервал растёт, пока вы отвечаете одной и той же активностью, и сокращается, когда ответы меняются.",
+	"adaptive.button_enable":  "✅ Включить",
+	"adaptive.button_disable": "⏸ Выключить",
+	"adaptive.button_min":     "⬇️ Минимум: %s",
+	"adaptive.button_max":     "⬆️ Максимум: %s",
+
+	// Пропущенные уведомления
+	"unanswered.auto_filled":          "Автоматически записана активность \"%s\"",
+	"unanswered.choose_fallback":      "Выберите активность, в которую записывать пропущенные уведомления:",
+	"unanswered.current_leave_empty":  "оставлять пустыми",
+	"unanswered.current_same_as_next": "записывать то же, что в следующем ответе",
+	"unanswered.deleted_activity":     "(удалённая активность)",
+	"unanswered.current_fallback":     "записывать в \"%s\"",
+	"unanswered.policy":               "Что делать с уведомлениями, на которые вы не ответили до следующего?\nСейчас: %s.",
+	"unanswered.button_leave_empty":   "Оставлять пустыми",
+	"unanswered.button_fallback":      "Записывать в активность…",
+	"unanswered.button_same_as_next":  "Как следующий ответ",
+
+	// Общие сообщения
+	"common.finish_command": "Сначала завершите текущую команду",
+	"button.cancel":         "❌ Отмена",
+
+	// Разделение интервала
+	"split.too_short":       "Интервал слишком короткий для разделения",
+	"split.expired_restart": "Разделение устарело, начните заново",
+	"split.expired":         "Разделение устарело",
+	"split.ask_minutes":     "Напишите через пробел, сколько минут заняла каждая активность (в сумме %d):\n%s",
+	"split.parse_error":     "Не получилось разделить: %s. Нажмите «Вручную» ещё раз.",
+	"split.pick_two":        "Выберите хотя бы две активности",
+	"split.saved":           "Разделение сохранено:",
+	"split.draft":           "✂️ Разделить %s–%s (%s) между активностями.\n",
+	"split.selected":        "Выбрано:\n",
+	"split.button_equal":    "✅ Поровну",
+	"split.button_custom":   "✍️ Вручную",
+	"split.error_count":     "нужно %d чисел, а получено %d",
+	"split.error_positive":  "минуты должны быть положительными целыми числами",
+	"split.error_sum":       "сумма минут %d, а должна быть %d",
+
+	"common.already_in_command": "Вы уже выполняете другую команду",
+
+	// Импорт
+	"csv_import.parse_error": "Не удалось разобрать CSV: %v\n\nПоддерживаются детальные отчёты Toggl Track и Clockify.",
+	"csv_import.error":       "Ошибка импорта записей: %v",
+	"csv_import.summary": "✅ Импорт из %s завершён\n\nИмпортировано записей: %d (%s)\n" +
+		"Пропущено (пустые или уже записанные): %d\nСоздано новых активностей: %d\n",
+	"csv_import.by_project":    "\nПо проектам:\n",
+	"csv_import.more_projects": "• и ещё %d\n",
+	"csv_import.no_project":    "Без проекта",
+	"ics_import.ask_rules": "Пришлите новый список правил, по одному на строку:\n" +
+		"`регулярное выражение => Область / Активность`\n\nНапример:\n`(?i)standup|синк => Работа / Митинги`\n\n" +
+		"Правила применяются по порядку, срабатывает первое подходящее. Отправьте `-`, чтобы удалить все правила.",
+	"ics_import.rules_error":  "Правила не сохранены: %v",
+	"ics_import.rules_saved":  "✅ Сохранено правил: %d. Теперь пришлите .ics файл для импорта.",
+	"ics_import.error_format": "строка %d: ожидается формат `регулярное выражение => путь`",
+	"ics_import.error_regexp": "строка %d: неверное регулярное выражение: %v",
+	"ics_import.rules_title":  "📥 *Правила импорта календаря*\n\n",
+	"ics_import.no_rules":     "Правил пока нет.",
+	"ics_import.parse_error":  "Не удалось разобрать календарь: %v",
+	"ics_import.no_rules_yet": "Сначала задайте правила сопоставления событий с активностями: /import_rules",
+	"ics_import.error":        "Ошибка импорта календаря: %v",
+	"ics_import.summary": "✅ Календарь импортирован\n\nСобытий сопоставлено: %d\n" +
+		"Событий без подходящего правила: %d\nЗаписано слотов: %d (%s)\nПропущено уже заполненных слотов: %d",
+
+	// /settings
+	"settings.ask_interval":      "Напишите интервал уведомлений в минутах (от 1 до %d).",
+	"settings.ask_start":         "Напишите, во сколько (%s) начинать присылать уведомления, например 8:30.",
+	"settings.ask_finish":        "Напишите, во сколько (%s) заканчивать присылать уведомления, например 22:15.",
+	"settings.save_error":        "Не получилось сохранить: %s. Нажмите кнопку ещё раз.",
+	"settings.incomplete":        "Сначала задайте интервал, начало и конец",
+	"settings.error_interval":    "интервал должен быть целым числом минут от 1 до %d",
+	"settings.all_day":           " (круглые сутки)",
+	"settings.interval_unset":    "не задан",
+	"settings.time_unset":        "не задано",
+	"settings.notifications_off": "выключены",
+	"settings.notifications_on":  "включены",
+	"settings.text": "⚙️ Настройки\n\nИнтервал уведомлений: %s\nНачало: %s\nКонец: %s\nУведомления: %s\nЯзык: %s\n\n" +
+		"Расписание по дням недели — /schedule, выходные — /day_off, пауза — /dnd, адаптивный интервал — /adaptive, " +
+		"текст уведомлений — /prompt.",
+	"settings.button_enable":   "🔔 Включить уведомления",
+	"settings.button_disable":  "🔕 Выключить уведомления",
+	"settings.button_interval": "⏱ Интервал",
+	"settings.button_start":    "🌅 Начало",
+	"settings.button_finish":   "🌇 Конец",
+
+	// /schedule и выходные
+	"weekday.1":               "Пн",
+	"weekday.2":               "Вт",
+	"weekday.3":               "Ср",
+	"weekday.4":               "Чт",
+	"weekday.5":               "Пт",
+	"weekday.6":               "Сб",
+	"weekday.0":               "Вс",
+	"schedule.window_off":     "выключено",
+	"schedule.window_all_day": "круглые сутки с %s",
+	"schedule.error_window":   "нужно два времени через дефис",
+	"schedule.error_clock":    "не понимаю время %q",
+	"schedule.not_configured": "Сначала настройте уведомления командой /start.",
+	"schedule.ask_window": "Напишите время уведомлений (%s) для: %s.\n" +
+		"Например, 09:30-18:00 или 22:00-01:30. «выкл» — не присылать уведомления в эти дни, «общее» — вернуть общее " +
+		"расписание из /settings.",
+	"schedule.parse_error":     "Не получилось разобрать время: %s. Выберите дни ещё раз.",
+	"schedule.title":           "Расписание уведомлений (%s):\n",
+	"schedule.default_note":    " (общее)",
+	"schedule.hint":            "\nВыберите дни, чтобы изменить время. Выходные и отпуск — /day_off.",
+	"schedule.button_weekdays": "Пн–Пт",
+	"schedule.button_weekend":  "Сб–Вс",
+	"schedule.button_all_days": "Все дни",
+	"day_off.usage": "Неверный формат. Используйте: /day_off, /day_off 2025-01-31 или /day_off 2025-01-31 " +
+		"2025-02-09",
+	"day_off.save_error": "Не удалось сохранить выходной.",
+	"day_off.saved":      "🏖 Выходной: %s. Уведомлений и итогов дня не будет. Список выходных — /days_off.",
+	"day_off.none":       "Выходных не запланировано. Добавить — /day_off.",
+	"day_off.list":       "Запланированные выходные (нажмите, чтобы удалить):",
+
+	// Хронология
+	"timeline.day_usage":      "Неверный формат даты. Используйте: /day 2025-01-31",
+	"timeline.error":          "Не удалось получить хронологию дня.",
+	"timeline.empty":          "За %s нет ни ответов, ни уведомлений.",
+	"timeline.title":          "🗓 Хронология за %s (%s)\n\n",
+	"timeline.no_answer":      "⏳ нет ответа",
+	"timeline.totals":         "\nЗаписано: %s, без ответа: %s",
+	"timeline.slot_not_found": "Слот не найден",
+	"timeline.removed":        "%s: ответ удалён",
+	"timeline.button_fill":    "✏️ Заполнить",
+	"timeline.saved":          "%s: сохранено \"%s\"",
+	"timeline.slot_question":  "Чем вы занимались %s?",
+	"timeline.slot_current":   "\nСейчас записано: %s",
+	"timeline.missed":         "⏳ Пропущено: %s",
+	"timeline.expired_prompt": "Это уведомление устарело. Заполните интервал кнопкой «Заполнить» или через /today.",
+
+	// Итоги дня
+	"day_stats.prompt":  "Если заполнил все активности за сегодня - ЖМИ НА КНОПКУ!",
+	"day_stats.button":  "Кнопка",
+	"day_stats.caption": "Диаграмма активности за сегодняшний день (сделал в %s за интервал [%s, %s]).",
+	"day_stats.refresh": "🔄 Обновить диаграмму",
+
+	// /start
+	"start.welcome_back": "С возвращением! Уведомления снова включены по прежнему расписанию.",
+	"start.greeting": "Привет! Это бот Андрея для учёта времени.\n" +
+		"Для начала выберите, как часто спрашивать вас о текущей активности.",
+	"start.interval_set": "Отлично, интервал — %s!\nТеперь выберите час (%s), с которого присылать напоминания.",
+	"start.start_set": "Замечательно, начало в %[1]d:00 %[2]s!\n" +
+		"А теперь выберите час (%[2]s), в который заканчивать напоминания и присылать итоги дня.",
+	"start.finish_set": "Готово. Уведомления будут приходить каждые %[1]s, с %[2]d:00 %[4]s до %[3]d:00 %[4]s.\n" +
+		"Теперь нажмите кнопку, чтобы включить уведомления.",
+	"start.finish_set_enabled": "Уведомления будут приходить каждые %[1]s, с %[2]d:00 %[4]s до %[3]d:00 %[4]s.\n" +
+		"Уведомления включены! Выключить их можно кнопкой ниже.",
+	"start.summary":        "Уведомления будут приходить каждые %[1]s, с %[2]s %[4]s до %[3]s %[4]s.\n",
+	"start.enabled":        "Уведомления включены!",
+	"start.disabled":       "Уведомления выключены!",
+	"start.button_enable":  "Включить уведомления!",
+	"start.button_disable": "Выключить уведомления!",
+
+	"common.send_file_error": "Произошла ошибка при отправке файла.",
+	"common.cancelled":       "Отменено",
+
+	// Активности
+	"activities.export_error":   "Произошла ошибка при экспорте активностей.",
+	"activities.export_caption": "Экспорт ваших активностей в формате YAML",
+	"activities.import_prompt": "Пришлите YAML файл с экспортированными активностями для импорта.\n\n" +
+		"⚠️ Внимание: импорт добавит новые активности к существующим, не заменяя их полностью.",
+	"activities.import_not_document": "Пожалуйста, отправьте YAML файл как документ, а не текст.",
+	"activities.import_unsupported": "Поддерживаются только YAML файлы (.yaml или .yml), календари (.ics) и " +
+		"CSV-экспорты Toggl/Clockify (.csv)",
+	"activities.download_error":   "Ошибка загрузки файла.",
+	"activities.import_error":     "Ошибка импорта активностей: %v",
+	"activities.imported":         "✅ Активности успешно импортированы!",
+	"activities.choose_delete":    "Выберите активность для удаления:",
+	"activities.unknown":          "неизвестная активность",
+	"activities.delete_error":     "Ошибка удаления активности",
+	"activities.deleted":          "✅ Активность '%s' и все её подактивности успешно удалены.",
+	"activities.deleted_toast":    "Активность удалена",
+	"activities.delete_cancelled": "Удаление активности отменено.",
+	"activities.list_refreshed":   "Список обновлен",
+	"activities.ask_new":          "Напишите новую активность",
+	"activities.add_error":        "Не удалось добавить активность.",
+	"activities.added":            "Новая активность \"%s\" добавлена!",
+	"mute.ask_unmute":             "Что хочешь размьютить?",
+	"mute.ask_mute":               "Что хочешь замьютить?",
+	"mute.unmuted":                "Активность \"%s\" снова в списке",
+	"mute.muted":                  "Активность \"%s\" скрыта",
+
+	// Календарь
+	"ics_export.usage":   "Неверный формат дат. Используйте: /export_ics 2025-01-01 2025-01-31",
+	"ics_export.error":   "Произошла ошибка при экспорте календаря.",
+	"ics_export.empty":   "За выбранный период нет записанных активностей.",
+	"ics_export.caption": "Календарь активностей с %s по %s (%d событий)",
+	"ics_feed.disabled":  "ICS-лента не настроена на этом сервере.",
+	"ics_feed.link": "Ваша приватная ICS-лента (последние 90 дней):\n%s/ics/%s.ics\n\n" +
+		"Не делитесь этой ссылкой. Чтобы выпустить новую ссылку, отправьте /ics_feed reset",
+
+	// Текст уведомлений (/prompt)
+	"prompt.tone_playful":      "Чё делаеш?))0)",
+	"prompt.tone_neutral":      "Чем вы занимались последние {interval}?",
+	"prompt.tone_work":         "Над чем работали с {from} до {to}?",
+	"prompt.since":             "\n\n🕐 С %s до %s %s",
+	"prompt.no_last":           "—",
+	"prompt.tone_name_playful": "😜 Шуточный",
+	"prompt.tone_name_neutral": "🙂 Нейтральный",
+	"prompt.tone_name_work":    "💼 Рабочий",
+	"prompt.custom":            "свой шаблон",
+	"prompt.since_on":          "показывается",
+	"prompt.since_off":         "не показывается",
+	"prompt.settings": "✉️ Текст уведомлений\n\nТон: %s\nИнтервал в уведомлении: %s\n\n" +
+		"Так будет выглядеть уведомление:\n%s\n\n" +
+		"Свой шаблон: /prompt <текст>. Плейсхолдеры: {from} и {to} — начало и конец интервала, {interval} — его " +
+		"длительность, {streak} — сколько дней подряд вы отвечаете, {last} — последняя записанная активность. Вернуться " +
+		"к готовому тону — кнопками ниже.",
+	"prompt.button_since_on":   "🕐 Показывать интервал",
+	"prompt.button_since_off":  "🕐 Не показывать интервал",
+	"prompt.saved":             "Шаблон сохранён.",
+	"prompt.parse_error":       "Не получилось сохранить шаблон: %s",
+	"prompt.error_empty":       "шаблон пустой",
+	"prompt.error_too_long":    "шаблон длиннее %d символов",
+	"prompt.error_placeholder": "неизвестный плейсхолдер %s",
+
+	// Распределение времени (/analytics)
+	"analytics.button_patterns": "🔥 Когда вы чем заняты",
+	"patterns.menu": "🔥 *Распределение времени*\n\n" +
+		"Тепловая карта и распределение по часам строятся по ответам за последние %d дней, календарь — за год. Время — " +
+		"%s.",
+	"patterns.menu_toast":       "Распределение времени",
+	"patterns.button_heatmap":   "🗓 Дни недели × часы",
+	"patterns.button_calendar":  "🟩 Календарь за год",
+	"patterns.button_hours":     "🕐 По часам суток",
+	"patterns.no_data":          "Нет данных за этот период",
+	"patterns.all_activities":   "Все активности",
+	"patterns.other":            "Другое",
+	"patterns.hours_unit":       "Часы",
+	"patterns.hours_per_day":    "Часов за день",
+	"patterns.minutes_per_day":  "Минут в день в среднем",
+	"patterns.heatmap_title":    "%s: дни недели × часы (%s), последние %d дней",
+	"patterns.heatmap_caption":  "🗓 %s — %s за последние %d дней.",
+	"patterns.heatmap_subtree":  "\n\nКнопки ниже открывают карты подактивностей.",
+	"patterns.calendar_title":   "Отслеженное время по дням, %s — %s",
+	"patterns.calendar_caption": "🟩 За год отслежено %s, ответы были в %d дней из %d.",
+	"patterns.hours_title":      "Среднее время по часам суток (%s), последние %d дней",
+	"patterns.hours_caption": "🕐 Распределение по часам суток за последние %d дней. Больше всего времени отслежено в " +
+		"%02d:00–%02d:00 %s.",
+	"month.1":  "янв",
+	"month.2":  "фев",
+	"month.3":  "мар",
+	"month.4":  "апр",
+	"month.5":  "май",
+	"month.6":  "июн",
+	"month.7":  "июл",
+	"month.8":  "авг",
+	"month.9":  "сен",
+	"month.10": "окт",
+	"month.11": "ноя",
+	"month.12": "дек",
+
+	// Тренды (/analytics)
+	"analytics.button_trends": "📉 Тренды",
+	"trends.menu": "📉 *Тренды*\n\n" +
+		"Время по корневым активностям за последние полные недели или месяцы: столбцы — активности, линия — скользящее " +
+		"среднее. Выберите период:",
+	"trends.menu_toast":     "Тренды",
+	"trends.button_weeks":   "📅 %d недель",
+	"trends.button_months":  "📆 %d месяцев",
+	"format.day_month":      "02.01",
+	"trends.month_label":    "%s %d",
+	"trends.title":          "Время по активностям, %s — %s",
+	"trends.moving_average": "Скользящее среднее (%d)",
+	"trends.unit_weeks":     "нед.",
+	"trends.unit_months":    "мес.",
+	"trends.per_weeks":      "в неделю",
+	"trends.per_months":     "в месяц",
+	"trends.summary": "📉 Тренды с %s по %s\n\nВсего сейчас: %s %s, раньше — %s.\n" +
+		"«Сейчас» — среднее за последние %d %s, «раньше» — за столько же перед ними.\n",
+	"trends.risers":     "\n📈 Растут:\n",
+	"trends.fallers":    "\n📉 Снижаются:\n",
+	"trends.change":     "• %s: %s → %s (%s%s %s)\n",
+	"trends.no_changes": "\nЗаметных изменений нет.",
+	"trends.short_history": "📉 Тренды с %s по %s\n\n" +
+		"Ответов пока слишком мало, чтобы сравнить периоды: нужно хотя бы два периода с ответами.",
+}
@@ -0,0 +1,217 @@
+package ics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateTimeLayout — формат даты-времени в UTC по RFC 5545.
+const dateTimeLayout = "20060102T150405Z"
+
+// maxLineOctets — максимальная длина строки календаря до переноса.
+const maxLineOctets = 75
+
+// Event — событие календаря (VEVENT).
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+// Encode сериализует события в документ VCALENDAR.
+func Encode(calendarName string, events []Event) []byte {
+	var buf bytes.Buffer
+	now := time.Now().UTC().Format(dateTimeLayout)
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//TimeCounterBot//Activity Log//RU")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "X-WR-CALNAME:"+escapeText(calendarName))
+
+	for _, event := range events {
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+escapeText(event.UID))
+		writeLine(&buf, "DTSTAMP:"+now)
+		writeLine(&buf, "DTSTART:"+event.Start.UTC().Format(dateTimeLayout))
+		writeLine(&buf, "DTEND:"+event.End.UTC().Format(dateTimeLayout))
+		writeLine(&buf, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// writeLine записывает строку календаря, перенося её по 75 октетов.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// Не разрезаем многобайтовые символы UTF-8.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Строка продолжения начинается с пробела, он тоже учитывается в длине.
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// escapeText экранирует спецсимволы в текстовых значениях.
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// EventUID формирует уникальный идентификатор события.
+func EventUID(userID int64, activityID int64, start time.Time) string {
+	return fmt.Sprintf("%d-%d-%d@time-counter-bot", userID, activityID, start.Unix())
+}
+
+// Parse разбирает документ VCALENDAR и возвращает события с заданным временем начала
+// и окончания. События на весь день (DTSTART;VALUE=DATE) пропускаются, правила
+// повторения (RRULE) не разворачиваются — берётся только первое вхождение.
+func Parse(data []byte) ([]Event, error) {
+	var events []Event
+	var current *Event
+	allDay := false
+	// Вложенные компоненты (например, VALARM) имеют свои DESCRIPTION и пропускаются.
+	nested := 0
+
+	for _, line := range unfoldLines(string(data)) {
+		name, params, value, ok := splitContentLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+			allDay = false
+			nested = 0
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, errors.New("unexpected END:VEVENT")
+			}
+			if !allDay && !current.Start.IsZero() {
+				if current.End.IsZero() {
+					current.End = current.Start
+				}
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "BEGIN":
+			nested++
+		case name == "END":
+			nested--
+		case nested > 0:
+			continue
+		case name == "UID":
+			current.UID = unescapeText(value)
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case name == "DTSTART" || name == "DTEND":
+			if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+				allDay = true
+				continue
+			}
+			ts, err := parseDateTime(value, params["TZID"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			if name == "DTSTART" {
+				current.Start = ts
+			} else {
+				current.End = ts
+			}
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// unfoldLines склеивает перенесённые строки календаря.
+func unfoldLines(data string) []string {
+	rawLines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	lines := make([]string, 0, len(rawLines))
+	for _, line := range rawLines {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitContentLine разбирает строку вида NAME;PARAM=VALUE:value.
+func splitContentLine(line string) (string, map[string]string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon == -1 {
+		return "", nil, "", false
+	}
+
+	head := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(head)-1)
+	for _, param := range head[1:] {
+		key, value, found := strings.Cut(param, "=")
+		if found {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(head[0]), params, line[colon+1:], true
+}
+
+// parseDateTime разбирает DATE-TIME в UTC, с TZID или «плавающее» локальное время.
+func parseDateTime(value, tzid string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayout, value)
+	}
+
+	location := time.UTC
+	if tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+	return time.ParseInLocation("20060102T150405", value, location)
+}
+
+// unescapeText снимает экранирование текстовых значений.
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,164 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEncode(t *testing.T) {
+	start := time.Date(2025, 3, 10, 12, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	tests := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name:  "times in UTC",
+			event: Event{UID: "1", Summary: "Работа", Start: start, End: start.Add(time.Hour)},
+			want:  []string{"DTSTART:20250310T093000Z", "DTEND:20250310T103000Z", "SUMMARY:Работа"},
+		},
+		{
+			name:  "escaped text",
+			event: Event{UID: "2", Summary: `a,b;c\d`, Description: "line1\nline2", Start: start, End: start},
+			want:  []string{`SUMMARY:a\,b\;c\\d`, `DESCRIPTION:line1\nline2`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Encode("Calendar", []Event{tt.event}))
+			for _, line := range tt.want {
+				if !strings.Contains(got, line+"\r\n") {
+					t.Errorf("Encode() has no line %q:\n%s", line, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+	}{
+		{name: "ascii", summary: strings.Repeat("a", 200)},
+		{name: "cyrillic", summary: strings.Repeat("я", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Encode("Calendar", []Event{{UID: "1", Summary: tt.summary}}))
+			for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line of %d octets: %q", len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line splits a UTF-8 character: %q", line)
+				}
+			}
+			if unfolded := strings.Join(unfoldLines(got), "\n"); !strings.Contains(unfolded, "SUMMARY:"+tt.summary) {
+				t.Errorf("unfolded calendar lost the summary:\n%s", unfolded)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	event := func(lines ...string) string {
+		return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    []Event
+		wantErr bool
+	}{
+		{
+			name: "utc times",
+			data: event("UID:1", "SUMMARY:Встреча", "DTSTART:20250310T090000Z", "DTEND:20250310T100000Z"),
+			want: []Event{{
+				UID: "1", Summary: "Встреча",
+				Start: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+				End:   time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name: "tzid",
+			data: event("SUMMARY:Созвон", "DTSTART;TZID=Europe/Moscow:20250310T120000",
+				"DTEND;TZID=Europe/Moscow:20250310T123000"),
+			want: []Event{{
+				Summary: "Созвон",
+				Start:   time.Date(2025, 3, 10, 12, 0, 0, 0, moscow),
+				End:     time.Date(2025, 3, 10, 12, 30, 0, 0, moscow),
+			}},
+		},
+		{
+			name: "folded and escaped summary",
+			data: event("SUMMARY:Планирование\\, ревью", "  и ретро", "DTSTART:20250310T090000Z"),
+			want: []Event{{
+				Summary: "Планирование, ревью и ретро",
+				Start:   time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+				End:     time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name: "all-day event skipped",
+			data: event("SUMMARY:Отпуск", "DTSTART;VALUE=DATE:20250310", "DTEND;VALUE=DATE:20250311"),
+		},
+		{
+			name: "alarm description ignored",
+			data: event("DESCRIPTION:событие", "DTSTART:20250310T090000Z", "BEGIN:VALARM",
+				"DESCRIPTION:напоминание", "END:VALARM"),
+			want: []Event{{
+				Description: "событие",
+				Start:       time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+				End:         time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+			}},
+		},
+		{name: "bad time", data: event("DTSTART:20251310T090000Z"), wantErr: true},
+		{name: "unterminated event", data: "BEGIN:VEVENT\r\nDTSTART:20250310T090000Z\r\n", wantErr: true},
+		{name: "unexpected end", data: "END:VEVENT\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].UID != tt.want[i].UID || got[i].Summary != tt.want[i].Summary ||
+					got[i].Description != tt.want[i].Description ||
+					!got[i].Start.Equal(tt.want[i].Start) || !got[i].End.Equal(tt.want[i].End) {
+					t.Errorf("Parse()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEncodeRoundTrip(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	events := []Event{
+		{UID: "1", Summary: "Работа / " + strings.Repeat("Код", 40), Start: start, End: start.Add(time.Hour)},
+		{UID: "2", Summary: "a;b,c", Description: "x\ny", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+	}
+	got, err := Parse(Encode("Calendar", events))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("Parse(Encode()) = %+v, want %+v", got, events)
+	}
+	for i := range events {
+		if got[i] != events[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], events[i])
+		}
+	}
+}
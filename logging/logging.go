@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+
+	"TimeCounterBot/common"
+)
+
+// Поддерживаемые форматы вывода логов.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// redactMessages — скрывать ли в логах содержимое сообщений пользователей.
+var redactMessages atomic.Bool
+
+func init() {
+	redactMessages.Store(true)
+}
+
+// Setup настраивает slog как логгер по умолчанию: уровень level ("debug", "info",
+// "warn", "error"), формат format (FormatText или FormatJSON) и скрытие текста
+// сообщений. Вывод стандартного пакета log тоже идёт через этот логгер.
+func Setup(w io.Writer, level, format string, redact bool) error {
+	var slogLevel slog.Level
+	if err := slogLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
+	options := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return fmt.Errorf("unsupported log format %q (expected %q or %q)", format, FormatText, FormatJSON)
+	}
+
+	redactMessages.Store(redact)
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+	return nil
+}
+
+// Text возвращает текст сообщения пользователя для записи в лог: сам текст,
+// если скрытие выключено, иначе только его длину.
+func Text(text string) string {
+	if !redactMessages.Load() || text == "" {
+		return text
+	}
+	return fmt.Sprintf("[redacted, %d chars]", len([]rune(text)))
+}
+
+// contextKey — ключ полей запроса в context.Context.
+type contextKey struct{}
+
+// fields — поля, которые добавляются ко всем записям лога в рамках одного обновления.
+type fields struct {
+	requestID string
+	userID    common.UserID
+	component string
+}
+
+func fromContext(ctx context.Context) fields {
+	if ctx == nil {
+		return fields{}
+	}
+	f, _ := ctx.Value(contextKey{}).(fields)
+	return f
+}
+
+// WithRequestID возвращает контекст, все записи лога в котором получают request_id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	f := fromContext(ctx)
+	f.requestID = requestID
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// WithUserID возвращает контекст, все записи лога в котором получают user_id.
+func WithUserID(ctx context.Context, userID common.UserID) context.Context {
+	f := fromContext(ctx)
+	f.userID = userID
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// WithComponent возвращает контекст фоновой задачи (рассылка, HTTP-сервер и т.п.).
+func WithComponent(ctx context.Context, component string) context.Context {
+	f := fromContext(ctx)
+	f.component = component
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// errorCountKey — ключ счётчика записей уровня Error в context.Context.
+type errorCountKey struct{}
+
+// WithErrorCount возвращает контекст, в котором считаются записи лога уровня Error,
+// и функцию, возвращающую их число. Обработчики сообщают об ошибках записью в лог,
+// поэтому так роутер узнаёт, что обработка обновления завершилась ошибкой.
+// Считаются записи, прошедшие через логгер из Setup.
+func WithErrorCount(ctx context.Context) (context.Context, func() int64) {
+	count := new(atomic.Int64)
+	return context.WithValue(ctx, errorCountKey{}, count), count.Load
+}
+
+// NewRequestID генерирует короткий случайный идентификатор обновления.
+func NewRequestID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+// contextHandler дописывает к записям лога поля запроса из контекста.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil && record.Level >= slog.LevelError {
+		if count, ok := ctx.Value(errorCountKey{}).(*atomic.Int64); ok {
+			count.Add(1)
+		}
+	}
+
+	f := fromContext(ctx)
+	if f.component != "" {
+		record.AddAttrs(slog.String("component", f.component))
+	}
+	if f.requestID != "" {
+		record.AddAttrs(slog.String("request_id", f.requestID))
+	}
+	if f.userID != 0 {
+		record.AddAttrs(slog.Int64("user_id", int64(f.userID)))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
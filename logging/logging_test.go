@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// restoreDefaults возвращает глобальный логгер и скрытие сообщений после теста.
+func restoreDefaults(t *testing.T) {
+	t.Helper()
+
+	logger := slog.Default()
+	redact := redactMessages.Load()
+	t.Cleanup(func() {
+		slog.SetDefault(logger)
+		redactMessages.Store(redact)
+	})
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		wantErr bool
+	}{
+		{name: "text info", level: "info", format: FormatText},
+		{name: "json debug", level: "debug", format: FormatJSON},
+		{name: "upper case level", level: "WARN", format: FormatText},
+		{name: "unknown level", level: "verbose", format: FormatText, wantErr: true},
+		{name: "unknown format", level: "info", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreDefaults(t)
+
+			err := Setup(&bytes.Buffer{}, tt.level, tt.format, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Setup(%q, %q) error = %v, wantErr %v", tt.level, tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetupLevel(t *testing.T) {
+	restoreDefaults(t)
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, "warn", FormatText, true); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	slog.Info("скрытое")
+	slog.Warn("видимое")
+
+	if strings.Contains(buf.String(), "скрытое") {
+		t.Errorf("info record written at warn level: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "видимое") {
+		t.Errorf("warn record missing: %q", buf.String())
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name   string
+		redact bool
+		text   string
+		want   string
+	}{
+		{name: "redacted", redact: true, text: "секрет", want: "[redacted, 6 chars]"},
+		{name: "redacted empty", redact: true, text: "", want: ""},
+		{name: "plain", redact: false, text: "секрет", want: "секрет"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreDefaults(t)
+			redactMessages.Store(tt.redact)
+
+			if got := Text(tt.text); got != tt.want {
+				t.Errorf("Text(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want map[string]any
+	}{
+		{
+			name: "no fields",
+			ctx:  context.Background(),
+			want: map[string]any{},
+		},
+		{
+			name: "request and user",
+			ctx:  WithUserID(WithRequestID(context.Background(), "abc"), 42),
+			want: map[string]any{"request_id": "abc", "user_id": float64(42)},
+		},
+		{
+			name: "component",
+			ctx:  WithComponent(context.Background(), "notifier"),
+			want: map[string]any{"component": "notifier"},
+		},
+		{
+			name: "later value wins",
+			ctx:  WithRequestID(WithRequestID(context.Background(), "old"), "new"),
+			want: map[string]any{"request_id": "new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(contextHandler{Handler: slog.NewJSONHandler(&buf, nil)})
+			logger.InfoContext(tt.ctx, "запись")
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("decode record %q: %v", buf.String(), err)
+			}
+			for _, key := range []string{"component", "request_id", "user_id"} {
+				want, ok := tt.want[key]
+				got, present := record[key]
+				if present != ok || got != want {
+					t.Errorf("%s = %v (present %v), want %v (present %v)", key, got, present, want, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestWithErrorCount(t *testing.T) {
+	logger := slog.New(contextHandler{Handler: slog.NewTextHandler(io.Discard, nil)})
+	ctx, errorCount := WithErrorCount(WithRequestID(context.Background(), "abc"))
+
+	logger.InfoContext(ctx, "запись")
+	logger.WarnContext(ctx, "предупреждение")
+	if got := errorCount(); got != 0 {
+		t.Errorf("errors after info and warn records = %d, want 0", got)
+	}
+
+	logger.ErrorContext(ctx, "ошибка")
+	logger.ErrorContext(WithUserID(ctx, 42), "ошибка с пользователем")
+	logger.ErrorContext(context.Background(), "ошибка другого обновления")
+	if got := errorCount(); got != 2 {
+		t.Errorf("errors = %d, want 2", got)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	format := regexp.MustCompile(`^[0-9a-f]{12}$`)
+	first, second := NewRequestID(), NewRequestID()
+	if !format.MatchString(first) {
+		t.Errorf("NewRequestID() = %q, want 12 hex digits", first)
+	}
+	if first == second {
+		t.Errorf("NewRequestID() returned %q twice", first)
+	}
+}
@@ -1,65 +1,67 @@
 package main
 
 import (
-	"context"
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"TimeCounterBot/db"
-	"TimeCounterBot/routes"
-	"TimeCounterBot/tg/bot"
-	"TimeCounterBot/tg/router"
-
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"TimeCounterBot/config"
+	"TimeCounterBot/logging"
 )
 
-func main() {
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	db.InitDB()
+const usage = `usage: bot [-config FILE] <command> [args]
 
-	var err error
+commands:
+  serve                           run the bot (default)
+  migrate up | down [N] | status  manage database schema migrations
+  export-user USER_ID [FILE]      export all data of a user to YAML (stdout by default)
+  import-user FILE                import user data exported by export-user
+  send-test-notification USER_ID  send an activity prompt to a user right now
+`
 
-	token := os.Getenv("TELEGRAM_TOKEN")
-	if token == "" {
-		log.Fatal("Telegram token was not found")
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"),
+		"path to YAML config file (default "+config.DefaultPath+")")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	bot.Bot, err = tgbotapi.NewBotAPI(token)
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		// Abort if something is wrong
-		log.Panic(err)
+		fmt.Fprintf(os.Stderr, "Ошибка загрузки конфигурации: %v\n", err)
+		os.Exit(1)
+	}
+	err = logging.Setup(os.Stderr, cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.RedactMessages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка настройки логирования: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Set this to true to log all interactions with telegram servers
-	bot.Bot.Debug = false
-
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
-
-	// Создаем контекст с возможностью отмены.
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel() // гарантируем вызов cancel при завершении main
-
-	// Настраиваем обработку сигналов для корректного завершения работы.
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		sig := <-sigCh
-		log.Printf("Получен сигнал %s, завершаем работу...", sig)
-		cancel()
-	}()
-
-	updates := bot.Bot.GetUpdatesChan(updateConfig)
-
-	go router.SetCommands()
-	go router.ReceiveUpdates(ctx, updates)
-	go routes.DispatchNotifications()
-
-	log.Println("Start listening for updates. Press enter to stop")
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	// Блокируем выполнение до получения cancel.
-	<-ctx.Done()
-	log.Println("Shutting down...")
+	switch command {
+	case "serve":
+		err = runServeCommand(cfg)
+	case "migrate":
+		err = runMigrateCommand(cfg, args)
+	case "export-user":
+		err = runExportUserCommand(cfg, args)
+	case "import-user":
+		err = runImportUserCommand(cfg, args)
+	case "send-test-notification":
+		err = runSendTestNotificationCommand(cfg, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("Команда завершилась с ошибкой", "command", command, "err", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"TimeCounterBot/common"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "timecounter"
+
+var (
+	// UpdatesTotal — обработанные обновления Telegram по типу и команде/callback-у.
+	UpdatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "updates_total",
+		Help:      "Telegram updates handled, by update type and command or callback prefix.",
+	}, []string{"type", "route"})
+
+	// HandlerDuration — время обработки одного обновления.
+	HandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent handling a Telegram update.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"type", "route"})
+
+	// HandlerErrors — обновления, при обработке которых обработчик записал в лог ошибку
+	// или упал с паникой.
+	HandlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handler_errors_total",
+		Help:      "Telegram updates whose handler logged an error or panicked.",
+	}, []string{"type", "route"})
+
+	// NotificationsTotal — отправленные и неотправленные уведомления с выбором активности.
+	NotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Activity prompts sent to users, by result (sent or failed).",
+	}, []string{"result"})
+
+	// AutoFilledPrompts — пропущенные уведомления, заполненные по правилу пользователя.
+	AutoFilledPrompts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auto_filled_prompts_total",
+		Help:      "Unanswered activity prompts filled automatically, by user policy (fallback or next).",
+	}, []string{"policy"})
+
+	// DispatcherLag — насколько позже запланированного начался последний проход рассылки.
+	DispatcherLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dispatcher_lag_seconds",
+		Help:      "Delay between the scheduled and the actual start of the last dispatcher pass.",
+	})
+
+	// DispatcherLastRun — время последнего прохода рассылки (для алертов на зависание).
+	DispatcherLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "dispatcher_last_run_timestamp_seconds",
+		Help:      "Unix time of the last dispatcher pass.",
+	})
+
+	// DBQueryDuration — длительность запросов к базе по типу операции GORM.
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query duration, by GORM operation.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation"})
+
+	// DBQueryErrors — запросы к базе, завершившиеся ошибкой (кроме «запись не найдена»).
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Database queries that failed, by GORM operation.",
+	}, []string{"operation"})
+
+	// OutboundQueueLength — запросы к Telegram, ожидающие своей очереди из-за ограничений частоты.
+	OutboundQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbound_queue_length",
+		Help:      "Telegram API requests waiting for a rate limit slot.",
+	})
+
+	// OutboundRequests — запросы к Telegram по методу API и результату (sent или failed).
+	OutboundRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_requests_total",
+		Help:      "Telegram API requests, by method and result (sent or failed).",
+	}, []string{"method", "result"})
+
+	// OutboundRetries — повторные попытки запросов к Telegram по причине
+	// (rate_limited — ответ 429, transient — сетевая ошибка или ошибка сервера).
+	OutboundRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_retries_total",
+		Help:      "Retried Telegram API requests, by reason (rate_limited or transient).",
+	}, []string{"reason"})
+
+	// OutboundDeadLetters — запросы, которые не удалось отправить и после всех повторов.
+	OutboundDeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_dead_letters_total",
+		Help:      "Telegram API requests dropped after exhausting retries, by method.",
+	}, []string{"method"})
+)
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_conversations",
+		Help:      "Users the bot is currently waiting for a reply from.",
+	}, func() float64 {
+		return float64(common.CountActiveConversations())
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"TimeCounterBot/config"
+	"TimeCounterBot/db"
+)
+
+const migrateUsage = "usage: bot migrate up | down [N] | status"
+
+// runMigrateCommand выполняет подкоманду `migrate`: применяет, откатывает
+// или показывает состояние миграций схемы.
+func runMigrateCommand(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := db.OpenMigrator(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+
+	case "status":
+		current, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current version: %d\n", current)
+		for _, migration := range pending {
+			fmt.Printf("pending %04d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
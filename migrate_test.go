@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"TimeCounterBot/config"
+)
+
+func TestRunMigrateCommand(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.URL = filepath.Join(t.TempDir(), "bot.db")
+
+	// Шаги выполняются по порядку над одной базой.
+	steps := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: nil, wantErr: true},
+		{args: []string{"status"}},
+		{args: []string{"up"}},
+		{args: []string{"up"}},
+		{args: []string{"down", "2"}},
+		{args: []string{"down"}},
+		{args: []string{"down", "0"}, wantErr: true},
+		{args: []string{"down", "two"}, wantErr: true},
+		{args: []string{"sideways"}, wantErr: true},
+		{args: []string{"up"}},
+	}
+	for _, step := range steps {
+		if err := runMigrateCommand(cfg, step.args); (err != nil) != step.wantErr {
+			t.Errorf("migrate %q: error = %v, wantErr %v", step.args, err, step.wantErr)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+#!/usr/bin/env python
+"""
+Генерация календарной тепловой карты (как график вкладов на GitHub):
+столбец — неделя, строка — день недели, цвет — отслеженные за день часы.
+
+На вход подается JSON:
+- title: заголовок диаграммы,
+- colorbar: подпись шкалы цвета,
+- start: первый день карты (понедельник) в формате YYYY-MM-DD,
+- hours: часы активности по дням подряд, начиная со start,
+- weekdays: подписи дней недели, начиная с понедельника,
+- months: короткие названия месяцев, начиная с января.
+
+Диаграмма сохраняется в PNG-файл по указанному пути.
+"""
+
+import json
+from datetime import date, timedelta
+
+import matplotlib.pyplot as plt
+import numpy as np
+
+
+def generate_calendar_heatmap(data, output_file):
+    start = date.fromisoformat(data["start"])
+    hours = data["hours"]
+    weeks = (len(hours) + 6) // 7
+
+    # Дни после последнего (будущие дни текущей недели) не закрашиваются.
+    grid = np.full((7, weeks), np.nan)
+    for i, value in enumerate(hours):
+        grid[i % 7, i // 7] = value
+
+    fig, ax = plt.subplots(figsize=(max(6, weeks * 0.25 + 2), 2.8))
+    cmap = plt.get_cmap("Greens").copy()
+    cmap.set_bad("white")
+    # Дни без ответов — светло-серые, как пустые клетки на GitHub.
+    cmap.set_under("#ebedf0")
+    image = ax.imshow(grid, cmap=cmap, vmin=0.01,
+                      vmax=max(1, np.nanmax(grid)), aspect="equal")
+
+    # Разделяем клетки белыми линиями.
+    ax.set_xticks(np.arange(-0.5, weeks, 1), minor=True)
+    ax.set_yticks(np.arange(-0.5, 7, 1), minor=True)
+    ax.grid(which="minor", color="white", linewidth=1.5)
+    ax.tick_params(which="minor", length=0)
+
+    # Подписи месяцев над первой неделей каждого месяца.
+    month_ticks, month_labels = [], []
+    for week in range(weeks):
+        day = start + timedelta(weeks=week)
+        if week == 0 or day.month != (day - timedelta(weeks=1)).month:
+            month_ticks.append(week)
+            month_labels.append(data["months"][day.month - 1])
+    ax.set_xticks(month_ticks)
+    ax.set_xticklabels(month_labels, fontsize=8)
+    ax.xaxis.tick_top()
+    ax.set_yticks(range(7))
+    ax.set_yticklabels(data["weekdays"], fontsize=8)
+    ax.tick_params(which="major", length=0)
+
+    for side in ("top", "right", "bottom", "left"):
+        ax.spines[side].set_visible(False)
+
+    colorbar = fig.colorbar(image, ax=ax, shrink=0.8, pad=0.01)
+    colorbar.set_label(data["colorbar"], fontsize=8)
+    ax.set_title(data["title"], pad=20)
+
+    plt.savefig(output_file, dpi=200, bbox_inches="tight")
+    plt.close()
+
+
+if __name__ == "__main__":
+    import sys
+    if len(sys.argv) < 3:
+        print("Использование: python script.py '<json_data>' output.png")
+        sys.exit(1)
+    input_json = sys.argv[1]
+    output_filename = sys.argv[2]
+    try:
+        data = json.loads(input_json)
+        generate_calendar_heatmap(data, output_filename)
+        print(f"✅ Диаграмма успешно сохранена в {output_filename}")
+    except Exception as e:
+        print(f"❌ Ошибка генерации диаграммы: {e}")
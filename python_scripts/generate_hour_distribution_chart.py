@@ -0,0 +1,60 @@
+#!/usr/bin/env python
+"""
+Генерация распределения времени по часам суток: столбец — час,
+высота — среднее число минут в день, цвета — активности.
+
+На вход подается JSON:
+- title: заголовок диаграммы,
+- ylabel: подпись оси значений,
+- series: список активностей, где каждая имеет:
+  - name: название активности,
+  - minutes: 24 значения — среднее число минут в день по часам.
+
+Диаграмма сохраняется в PNG-файл по указанному пути.
+"""
+
+import json
+import matplotlib.pyplot as plt
+import seaborn as sns
+
+
+def generate_hour_distribution_chart(data, output_file):
+    series = data["series"]
+    palette = sns.color_palette("pastel", max(len(series), 1))
+
+    fig, ax = plt.subplots(figsize=(12, 5))
+    bottom = [0.0] * 24
+    for i, item in enumerate(series):
+        ax.bar(range(24), item["minutes"], bottom=bottom, width=0.8,
+               color=palette[i], edgecolor="white", label=item["name"])
+        bottom = [b + m for b, m in zip(bottom, item["minutes"])]
+
+    ax.set_xticks(range(24))
+    ax.set_xticklabels([f"{hour:02d}" for hour in range(24)], fontsize=8)
+    ax.set_xlim(-0.6, 23.6)
+    ax.set_ylabel(data["ylabel"])
+    ax.grid(axis="y", linestyle=":", alpha=0.6)
+    ax.set_axisbelow(True)
+    ax.set_title(data["title"])
+    ax.legend(loc="center left", bbox_to_anchor=(1, 0.5), fontsize=9)
+
+    for side in ("top", "right"):
+        ax.spines[side].set_visible(False)
+
+    plt.savefig(output_file, dpi=200, bbox_inches="tight")
+    plt.close()
+
+
+if __name__ == "__main__":
+    import sys
+    if len(sys.argv) < 3:
+        print("Использование: python script.py '<json_data>' output.png")
+        sys.exit(1)
+    input_json = sys.argv[1]
+    output_filename = sys.argv[2]
+    try:
+        data = json.loads(input_json)
+        generate_hour_distribution_chart(data, output_filename)
+        print(f"✅ Диаграмма успешно сохранена в {output_filename}")
+    except Exception as e:
+        print(f"❌ Ошибка генерации диаграммы: {e}")
@@ -0,0 +1,90 @@
+#!/usr/bin/env python
+"""
+Генерация диаграммы Ганта для хронологии одного дня.
+
+На вход подается JSON:
+- date: дата в формате YYYY-MM-DD,
+- zone: часовой пояс, в котором отсчитываются минуты слотов,
+- slots: список слотов, где каждый слот имеет:
+  - start, end: границы слота в минутах от начала дня,
+  - name: полное название активности (пустое для пропусков),
+  - answered: false, если на уведомление не ответили.
+
+Каждая активность рисуется на своей строке, пропуски — серым на отдельной строке.
+Диаграмма сохраняется в PNG-файл по указанному пути.
+"""
+
+import json
+import matplotlib.pyplot as plt
+import seaborn as sns
+
+MISSED_LABEL = "Нет ответа"
+MISSED_COLOR = "#d0d0d0"
+
+
+def generate_timeline_chart(data, output_file):
+    slots = data["slots"]
+
+    # Строки диаграммы в порядке первого появления активности за день.
+    labels = []
+    for slot in slots:
+        label = slot["name"] if slot["answered"] else MISSED_LABEL
+        if not label:
+            label = "?"
+        if label not in labels:
+            labels.append(label)
+
+    answered_labels = [label for label in labels if label != MISSED_LABEL]
+    palette = sns.color_palette("pastel", max(len(answered_labels), 1))
+    colors = {label: palette[i] for i, label in enumerate(answered_labels)}
+    colors[MISSED_LABEL] = MISSED_COLOR
+
+    fig_height = max(2.5, 0.45 * len(labels) + 1.2)
+    fig, ax = plt.subplots(figsize=(12, fig_height))
+
+    for slot in slots:
+        label = slot["name"] if slot["answered"] else MISSED_LABEL
+        if not label:
+            label = "?"
+        row = labels.index(label)
+        start_hour = slot["start"] / 60
+        width = (slot["end"] - slot["start"]) / 60
+        ax.barh(row, width, left=start_hour, height=0.6,
+                color=colors[label], edgecolor="white")
+
+    ax.set_yticks(range(len(labels)))
+    ax.set_yticklabels(labels, fontsize=9)
+    ax.invert_yaxis()
+
+    # Ось времени: весь день, если слоты выходят за его пределы — расширяем.
+    min_hour = min([0] + [slot["start"] / 60 for slot in slots])
+    max_hour = max([24] + [slot["end"] / 60 for slot in slots])
+    ax.set_xlim(min_hour, max_hour)
+    ax.set_xticks(range(int(min_hour), int(max_hour) + 1, 2))
+    ax.set_xticklabels([f"{h % 24:02d}:00" for h in
+                        range(int(min_hour), int(max_hour) + 1, 2)],
+                       fontsize=8)
+    ax.grid(axis="x", linestyle=":", alpha=0.6)
+    ax.set_axisbelow(True)
+    ax.set_title(f"Хронология за {data['date']} ({data['zone']})")
+
+    for side in ("top", "right"):
+        ax.spines[side].set_visible(False)
+
+    plt.savefig(output_file, dpi=200, bbox_inches="tight")
+    plt.close()
+
+
+if __name__ == "__main__":
+    import sys
+    if len(sys.argv) < 3:
+        print("Использование: python script.py '<json_data>' output.png")
+        sys.exit(1)
+    input_json = sys.argv[1]
+    output_filename = sys.argv[2]
+    try:
+        data = json.loads(input_json)
+        generate_timeline_chart(data, output_filename)
+        print(f"✅ Диаграмма успешно сохранена в {output_filename}")
+    except Exception as e:
+        print(f"❌ Ошибка генерации диаграммы: {e}")
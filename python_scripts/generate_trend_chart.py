@@ -0,0 +1,75 @@
+#!/usr/bin/env python
+"""
+Генерация графика трендов: время по активностям за несколько периодов
+(недель или месяцев) столбцами с накоплением и скользящее среднее линией.
+
+На вход подается JSON:
+- title: заголовок диаграммы,
+- ylabel: подпись оси значений,
+- periods: подписи периодов по порядку,
+- series: список активностей, где каждая имеет:
+  - name: название активности,
+  - hours: часы активности по периодам,
+- moving_average: скользящее среднее суммы часов по периодам
+  (null, пока окно не заполнено),
+- moving_average_label: подпись линии скользящего среднего.
+
+Диаграмма сохраняется в PNG-файл по указанному пути.
+"""
+
+import json
+import matplotlib.pyplot as plt
+import seaborn as sns
+
+
+def generate_trend_chart(data, output_file):
+    periods = data["periods"]
+    series = data["series"]
+    palette = sns.color_palette("pastel", max(len(series), 1))
+    positions = range(len(periods))
+
+    fig, ax = plt.subplots(figsize=(max(8, 0.5 * len(periods) + 4), 5))
+    bottom = [0.0] * len(periods)
+    for i, item in enumerate(series):
+        ax.bar(positions, item["hours"], bottom=bottom, width=0.7,
+               color=palette[i], edgecolor="white", label=item["name"])
+        bottom = [b + h for b, h in zip(bottom, item["hours"])]
+
+    # Скользящее среднее рисуется только там, где окно заполнено.
+    average = [(i, value) for i, value in enumerate(data["moving_average"])
+               if value is not None]
+    if average:
+        ax.plot([i for i, _ in average], [value for _, value in average],
+                color="#404040", linewidth=2, marker="o", markersize=4,
+                label=data["moving_average_label"])
+
+    ax.set_xticks(list(positions))
+    ax.set_xticklabels(periods, fontsize=8,
+                       rotation=45 if len(periods) > 12 else 0,
+                       ha="right" if len(periods) > 12 else "center")
+    ax.set_ylabel(data["ylabel"])
+    ax.grid(axis="y", linestyle=":", alpha=0.6)
+    ax.set_axisbelow(True)
+    ax.set_title(data["title"])
+    ax.legend(loc="center left", bbox_to_anchor=(1, 0.5), fontsize=9)
+
+    for side in ("top", "right"):
+        ax.spines[side].set_visible(False)
+
+    plt.savefig(output_file, dpi=200, bbox_inches="tight")
+    plt.close()
+
+
+if __name__ == "__main__":
+    import sys
+    if len(sys.argv) < 3:
+        print("Использование: python script.py '<json_data>' output.png")
+        sys.exit(1)
+    input_json = sys.argv[1]
+    output_filename = sys.argv[2]
+    try:
+        data = json.loads(input_json)
+        generate_trend_chart(data, output_filename)
+        print(f"✅ Диаграмма успешно сохранена в {output_filename}")
+    except Exception as e:
+        print(f"❌ Ошибка генерации диаграммы: {e}")
@@ -0,0 +1,49 @@
+#!/usr/bin/env python
+"""
+Генерация тепловой карты "дни недели × часы" для поддерева активностей.
+
+На вход подается JSON:
+- title: заголовок диаграммы,
+- colorbar: подпись шкалы цвета,
+- weekdays: подписи дней недели, начиная с понедельника,
+- hours: матрица 7 × 24 — часы активности по дням недели и часам суток.
+
+Диаграмма сохраняется в PNG-файл по указанному пути.
+"""
+
+import json
+import matplotlib.pyplot as plt
+import seaborn as sns
+
+
+def generate_weekday_hour_heatmap(data, output_file):
+    hours = data["hours"]
+
+    fig, ax = plt.subplots(figsize=(14, 4.5))
+    sns.heatmap(hours, ax=ax, cmap="YlGnBu", linewidths=0.5,
+                linecolor="white", square=True,
+                cbar_kws={"label": data["colorbar"], "shrink": 0.8})
+
+    ax.set_xticks([hour + 0.5 for hour in range(24)])
+    ax.set_xticklabels([f"{hour:02d}" for hour in range(24)], fontsize=8)
+    ax.set_yticks([day + 0.5 for day in range(7)])
+    ax.set_yticklabels(data["weekdays"], rotation=0, fontsize=9)
+    ax.set_title(data["title"])
+
+    plt.savefig(output_file, dpi=200, bbox_inches="tight")
+    plt.close()
+
+
+if __name__ == "__main__":
+    import sys
+    if len(sys.argv) < 3:
+        print("Использование: python script.py '<json_data>' output.png")
+        sys.exit(1)
+    input_json = sys.argv[1]
+    output_filename = sys.argv[2]
+    try:
+        data = json.loads(input_json)
+        generate_weekday_hour_heatmap(data, output_filename)
+        print(f"✅ Диаграмма успешно сохранена в {output_filename}")
+    except Exception as e:
+        print(f"❌ Ошибка генерации диаграммы: {e}")
@@ -2,21 +2,21 @@ package routes
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"TimeCounterBot/common"
-	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // ExportActivitiesCommand обрабатывает команду экспорта активностей.
-func ExportActivitiesCommand(message *tgbotapi.Message) {
+func (h *Handlers) ExportActivitiesCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -24,18 +24,19 @@ func ExportActivitiesCommand(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
 		return
 	}
 
+	lang := userLang(*user)
 	// Экспортируем активности в YAML
-	yamlData, err := db.ExportActivitiesToYAML(userID)
+	yamlData, err := h.activities.ExportActivitiesToYAML(userID)
 	if err != nil {
-		log.Printf("Ошибка экспорта активностей: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID), "Произошла ошибка при экспорте активностей.")
-		bot.Bot.Send(msgConf)
+		slog.ErrorContext(ctx, "Ошибка экспорта активностей", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.export_error"))
+		h.sender.Send(msgConf)
 		return
 	}
 
@@ -44,27 +45,27 @@ func ExportActivitiesCommand(message *tgbotapi.Message) {
 		Name:  fmt.Sprintf("activities_export_%d.yaml", userID),
 		Bytes: yamlData,
 	})
-	document.Caption = "Экспорт ваших активностей в формате YAML"
+	document.Caption = i18n.T(lang, "activities.export_caption")
 
-	_, err = bot.Bot.Send(document)
+	_, err = h.sender.Send(document)
 	if err != nil {
-		log.Printf("Ошибка отправки файла: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID), "Произошла ошибка при отправке файла.")
-		bot.Bot.Send(msgConf)
+		slog.ErrorContext(ctx, "Ошибка отправки файла", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "common.send_file_error"))
+		h.sender.Send(msgConf)
 		return
 	}
 
 	// Удаляем исходное сообщение команды
-	_, err = bot.Bot.Request(
+	_, err = h.sender.Request(
 		tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID),
 	)
 	if err != nil {
-		log.Printf("Ошибка удаления сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 }
 
 // ImportActivitiesCommand обрабатывает команду импорта активностей.
-func ImportActivitiesCommand(message *tgbotapi.Message) {
+func (h *Handlers) ImportActivitiesCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -72,53 +73,53 @@ func ImportActivitiesCommand(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
 		return
 	}
 
 	// Устанавливаем состояние ожидания файла
-	state := common.UserStates[userID]
+	state := common.GetUserState(userID)
 	if state.WaitingChannel == nil {
 		waitingChan := make(chan string, 1)
-		common.UserStates[userID] = common.UserState{
+		state = common.UserState{
 			WaitingChannel: &waitingChan,
 		}
+		common.SetUserState(userID, state)
 	}
 
-	msgConf := tgbotapi.NewMessage(int64(user.ChatID),
-		"Пришлите YAML файл с экспортированными активностями для импорта.\n\n"+
-			"⚠️ Внимание: импорт добавит новые активности к существующим, не заменяя их полностью.")
+	lang := userLang(*user)
+	msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.import_prompt"))
 
-	_, err = bot.Bot.Send(msgConf)
+	_, err = h.sender.Send(msgConf)
 	if err != nil {
-		log.Printf("Ошибка отправки сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
 		return
 	}
 
 	// Ждем файл от пользователя
-	go func() {
-		select {
-		case <-*common.UserStates[userID].WaitingChannel:
-			// Пользователь отправил что-то, но нам нужен именно документ
-			msgConf := tgbotapi.NewMessage(int64(user.ChatID),
-				"Пожалуйста, отправьте YAML файл как документ, а не текст.")
-			bot.Bot.Send(msgConf)
+	h.goBackground(func() {
+		if _, ok := h.waitForReply(ctx, *state.WaitingChannel); !ok {
+			return
 		}
-	}()
+		// Пользователь отправил что-то, но нам нужен именно документ
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.import_not_document"))
+		h.sender.Send(msgConf)
+	})
 
 	// Удаляем исходное сообщение команды
-	_, err = bot.Bot.Request(
+	_, err = h.sender.Request(
 		tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID),
 	)
 	if err != nil {
-		log.Printf("Ошибка удаления сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 }
 
-// ProcessImportFile обрабатывает загруженный YAML файл для импорта.
-func ProcessImportFile(message *tgbotapi.Message) {
+// ProcessImportFile обрабатывает загруженный файл для импорта:
+// YAML с деревом активностей, календарь .ics или CSV-экспорт Toggl/Clockify.
+func (h *Handlers) ProcessImportFile(ctx context.Context, message *tgbotapi.Message) {
 	if message.Document == nil {
 		return
 	}
@@ -130,68 +131,84 @@ func ProcessImportFile(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
 		return
 	}
 
+	lang := userLang(*user)
 	// Проверяем расширение файла
-	if !strings.HasSuffix(strings.ToLower(message.Document.FileName), ".yaml") &&
-		!strings.HasSuffix(strings.ToLower(message.Document.FileName), ".yml") {
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID),
-			"Поддерживаются только YAML файлы (.yaml или .yml)")
-		bot.Bot.Send(msgConf)
+	fileName := strings.ToLower(message.Document.FileName)
+	isYAML := strings.HasSuffix(fileName, ".yaml") || strings.HasSuffix(fileName, ".yml")
+	isICS := strings.HasSuffix(fileName, ".ics")
+	isCSV := strings.HasSuffix(fileName, ".csv")
+	if !isYAML && !isICS && !isCSV {
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.import_unsupported"))
+		h.sender.Send(msgConf)
 		return
 	}
 
-	// Получаем файл
-	fileConfig := tgbotapi.FileConfig{FileID: message.Document.FileID}
-	file, err := bot.Bot.GetFile(fileConfig)
+	data, err := h.downloadDocument(ctx, message.Document)
 	if err != nil {
-		log.Printf("Ошибка получения файла: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID), "Ошибка загрузки файла.")
-		bot.Bot.Send(msgConf)
+		slog.ErrorContext(ctx, "Ошибка загрузки файла", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.download_error"))
+		h.sender.Send(msgConf)
 		return
 	}
 
-	// Скачиваем содержимое файла
-	resp, err := http.Get(file.Link(bot.Bot.Token))
-	if err != nil {
-		log.Printf("Ошибка скачивания файла: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID), "Ошибка скачивания файла.")
-		bot.Bot.Send(msgConf)
+	if isICS {
+		h.processCalendarImport(ctx, *user, data)
 		return
 	}
-	defer resp.Body.Close()
-
-	// Читаем содержимое
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(resp.Body)
-	if err != nil {
-		log.Printf("Ошибка чтения файла: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID), "Ошибка чтения файла.")
-		bot.Bot.Send(msgConf)
+	if isCSV {
+		h.processTimeEntriesImport(ctx, *user, data)
 		return
 	}
 
 	// Импортируем активности
-	err = db.ImportActivitiesFromYAML(buf.Bytes(), userID)
+	err = h.activities.ImportActivitiesFromYAML(data, userID)
 	if err != nil {
-		log.Printf("Ошибка импорта активностей: %v", err)
-		msgConf := tgbotapi.NewMessage(int64(user.ChatID),
-			fmt.Sprintf("Ошибка импорта активностей: %v", err))
-		bot.Bot.Send(msgConf)
+		slog.ErrorContext(ctx, "Ошибка импорта активностей", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.import_error", err))
+		h.sender.Send(msgConf)
 		return
 	}
 
-	msgConf := tgbotapi.NewMessage(int64(user.ChatID),
-		"✅ Активности успешно импортированы!")
-	bot.Bot.Send(msgConf)
+	msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.imported"))
+	h.sender.Send(msgConf)
+}
+
+// downloadDocument скачивает содержимое документа, присланного пользователем.
+func (h *Handlers) downloadDocument(ctx context.Context, document *tgbotapi.Document) ([]byte, error) {
+	// Получаем ссылку на файл
+	fileURL, err := h.sender.GetFileDirectURL(document.FileID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Скачиваем содержимое файла
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Читаем содержимое
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // DeleteActivityCommand обрабатывает команду удаления активности.
-func DeleteActivityCommand(message *tgbotapi.Message) {
+func (h *Handlers) DeleteActivityCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -199,32 +216,33 @@ func DeleteActivityCommand(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	msgText := "Выберите активность для удаления:"
-
-	msgconf := tgbotapi.NewMessage(int64(user.ChatID), msgText)
-	msgconf.ReplyMarkup = buildActivitiesKeyboardMarkupForUser(
-		*user, -1, nil, nil, "delete_activity__delete", getDeleteActivitiesLastRow())
+	lang := userLang(*user)
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.choose_delete"))
+	msgconf.ReplyMarkup = h.buildActivitiesKeyboardMarkupForUser(
+		ctx, *user, -1, nil, nil, "delete_activity__delete", getDeleteActivitiesLastRow(lang))
 
-	_, err = bot.Bot.Send(msgconf)
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 
-	_, err = bot.Bot.Request(
+	_, err = h.sender.Request(
 		tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID),
 	)
 	if err != nil {
-		log.Printf("Ошибка удаления сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 }
 
 // DeleteActivityCallback обрабатывает callback для удаления активности.
-func DeleteActivityCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) DeleteActivityCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	data := strings.Split(callback.Data, " ")
 	if len(data) < 2 {
 		return
@@ -232,85 +250,86 @@ func DeleteActivityCallback(callback *tgbotapi.CallbackQuery) {
 
 	activityID, err := strconv.ParseInt(data[1], 10, 64)
 	if err != nil {
-		log.Printf("Ошибка парсинга ID активности: %v", err)
+		slog.ErrorContext(ctx, "Ошибка парсинга ID активности", "err", err)
 		return
 	}
 
 	userID := common.UserID(callback.From.ID)
+	lang := h.langOf(userID)
 
 	// Получаем название активности перед удалением
-	activityName, err := db.GetFullActivityNameByID(activityID, userID)
+	activityName, err := h.activities.GetFullActivityNameByID(activityID, userID)
 	if err != nil {
-		log.Printf("Ошибка получения названия активности: %v", err)
-		activityName = "неизвестная активность"
+		slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+		activityName = i18n.T(lang, "activities.unknown")
 	}
 
 	// Удаляем активность
-	err = db.DeleteActivityRecursive(activityID, userID)
+	err = h.activities.DeleteActivityRecursive(activityID, userID)
 	if err != nil {
-		log.Printf("Ошибка удаления активности: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления активности", "err", err)
 
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Ошибка удаления активности")
-		bot.Bot.Request(answerConfig)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "activities.delete_error"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
 	// Отправляем подтверждение
-	msgText := fmt.Sprintf("✅ Активность '%s' и все её подактивности успешно удалены.", activityName)
+	msgText := i18n.T(lang, "activities.deleted", activityName)
 
 	editConfig := tgbotapi.NewEditMessageText(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
 		msgText,
 	)
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Активность удалена")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "activities.deleted_toast"))
+	h.sender.Request(answerConfig)
 }
 
 // DeleteActivityCancelCallback отменяет удаление активности.
-func DeleteActivityCancelCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) DeleteActivityCancelCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
 	editConfig := tgbotapi.NewEditMessageText(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		"Удаление активности отменено.",
+		i18n.T(lang, "activities.delete_cancelled"),
 	)
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Отменено")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "common.cancelled"))
+	h.sender.Request(answerConfig)
 }
 
 // DeleteActivityRefreshCallback обновляет список активностей для удаления.
-func DeleteActivityRefreshCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) DeleteActivityRefreshCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	userID := common.UserID(callback.From.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
 		return
 	}
 
-	msgText := "Выберите активность для удаления:"
-
+	lang := userLang(*user)
 	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		msgText,
-		buildActivitiesKeyboardMarkupForUser(
-			*user, -1, nil, nil, "delete_activity__delete", getDeleteActivitiesLastRow()),
+		i18n.T(lang, "activities.choose_delete"),
+		h.buildActivitiesKeyboardMarkupForUser(
+			ctx, *user, -1, nil, nil, "delete_activity__delete", getDeleteActivitiesLastRow(lang)),
 	)
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Список обновлен")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "activities.list_refreshed"))
+	h.sender.Request(answerConfig)
 }
 
 // getDeleteActivitiesLastRow возвращает последний ряд кнопок для удаления активности.
-func getDeleteActivitiesLastRow() []tgbotapi.InlineKeyboardButton {
+func getDeleteActivitiesLastRow(lang i18n.Lang) []tgbotapi.InlineKeyboardButton {
 	return []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔄 Обновить", "delete_activity__refresh"),
-		tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "delete_activity__cancel"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.refresh"), "delete_activity__refresh"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.cancel"), "delete_activity__cancel"),
 	}
 }
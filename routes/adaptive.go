@@ -0,0 +1,292 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Границы адаптивного интервала по умолчанию.
+const (
+	defaultAdaptiveMinMinutes = 10
+	defaultAdaptiveMaxMinutes = 90
+)
+
+// Границы адаптивного интервала, которые можно изменить в /adaptive.
+const (
+	adaptiveBoundMin = "min"
+	adaptiveBoundMax = "max"
+)
+
+// notifyIntervalMinutes возвращает текущий интервал уведомлений пользователя.
+func notifyIntervalMinutes(user db.User) int64 {
+	if user.AdaptiveInterval && user.AdaptiveCurrentMinutes.Valid {
+		return user.AdaptiveCurrentMinutes.Int64
+	}
+	return user.TimerMinutes.Int64
+}
+
+// promptIntervalMinutes возвращает, сколько минут покрывает новое уведомление. В адаптивном
+// режиме интервал меняется между уведомлениями, поэтому записывается фактически прошедшее
+// с предыдущего уведомления время. Слишком большой разрыв (ночь, выключенные уведомления)
+// не считается — тогда берётся текущий интервал.
+func promptIntervalMinutes(user db.User, previous sql.NullTime, now time.Time) int64 {
+	interval := notifyIntervalMinutes(user)
+	if !user.AdaptiveInterval || !previous.Valid {
+		return interval
+	}
+
+	elapsed := int64(math.Round(now.Sub(previous.Time).Minutes()))
+	if elapsed <= 0 || elapsed > 2*max(interval, user.AdaptiveMaxMinutes.Int64) {
+		return interval
+	}
+	return elapsed
+}
+
+// adaptInterval пересчитывает адаптивный интервал после нового ответа activityID
+// на уведомление в timestamp: интервал растёт в полтора раза, если ответ совпал с
+// предыдущим, и сокращается на треть, если нет. activityID = 0 означает разделённый
+// интервал — это всегда смена активности.
+func (h *Handlers) adaptInterval(ctx context.Context, userID common.UserID, activityID int64, timestamp time.Time) {
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+	if !user.AdaptiveInterval {
+		return
+	}
+
+	logs, err := h.logs.GetActivityLogs(userID, timestamp.Add(-Day), timestamp.Add(-time.Second))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения логов активностей", "err", err)
+		return
+	}
+	if len(logs) == 0 {
+		return
+	}
+
+	current := notifyIntervalMinutes(*user)
+	next := current * 2 / 3
+	if logs[len(logs)-1].ActivityID == activityID {
+		next = current * 3 / 2
+	}
+	next = min(max(next, user.AdaptiveMinMinutes.Int64), user.AdaptiveMaxMinutes.Int64)
+	if next == current && user.AdaptiveCurrentMinutes.Valid {
+		return
+	}
+
+	user.AdaptiveCurrentMinutes = sql.NullInt64{Int64: next, Valid: true}
+	err = h.users.UpdateUserColumns(userID, map[string]any{"adaptive_current_minutes": user.AdaptiveCurrentMinutes})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+	slog.DebugContext(ctx, "Адаптивный интервал изменён", "from", current, "to", next)
+}
+
+// AdaptiveCommand обрабатывает команду /adaptive: настройки адаптивного интервала.
+func (h *Handlers) AdaptiveCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), adaptiveSettingsText(*user))
+	msgconf.ReplyMarkup = getAdaptiveSettingsKeyboardMarkup(*user)
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// AdaptiveToggleCallback включает и выключает адаптивный интервал.
+func (h *Handlers) AdaptiveToggleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	h.updateAdaptiveSettings(ctx, callback, func(user *db.User) {
+		user.AdaptiveInterval = !user.AdaptiveInterval
+		if !user.AdaptiveMinMinutes.Valid {
+			user.AdaptiveMinMinutes = sql.NullInt64{Int64: defaultAdaptiveMinMinutes, Valid: true}
+		}
+		if !user.AdaptiveMaxMinutes.Valid {
+			user.AdaptiveMaxMinutes = sql.NullInt64{Int64: defaultAdaptiveMaxMinutes, Valid: true}
+		}
+		// Каждое включение начинается со стартового интервала из /start.
+		user.AdaptiveCurrentMinutes = sql.NullInt64{}
+		if user.AdaptiveInterval {
+			user.AdaptiveCurrentMinutes = sql.NullInt64{
+				Int64: min(max(user.TimerMinutes.Int64, user.AdaptiveMinMinutes.Int64), user.AdaptiveMaxMinutes.Int64),
+				Valid: true,
+			}
+		}
+	})
+}
+
+// AdaptiveBoundCallback спрашивает новую нижнюю или верхнюю границу адаптивного
+// интервала и сохраняет ответ. Callback data: "adaptive__edit min" или "adaptive__edit max".
+func (h *Handlers) AdaptiveBoundCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	userID := common.UserID(callback.From.ID)
+	lang := h.langOf(userID)
+	bound := strings.TrimPrefix(callback.Data, "adaptive__edit ")
+	if bound != adaptiveBoundMin && bound != adaptiveBoundMax {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "data", callback.Data)
+		return
+	}
+
+	if common.GetUserState(userID).State == common.InCommand {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "common.finish_command")))
+		return
+	}
+
+	waitChan := make(chan string, 1)
+	common.SetUserState(userID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(userID, common.UserState{State: common.Idle, WaitingChannel: nil})
+
+	reply := tgbotapi.NewMessage(callback.Message.Chat.ID, i18n.T(lang, "adaptive.ask_"+bound, maxTimerMinutes))
+	reply.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	_, err := h.sender.Send(reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+
+	// Пользователя читаем после ответа: пока ждали, настройки могли измениться.
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	if err := applyAdaptiveBound(lang, user, bound, ans); err != nil {
+		_, err = h.sender.Send(tgbotapi.NewMessage(callback.Message.Chat.ID,
+			i18n.T(lang, "settings.save_error", err.Error())))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+	if err := h.users.UpdateUserColumns(userID, adaptiveColumns(*user)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	h.editAdaptiveSettingsMessage(ctx, callback, *user)
+}
+
+// applyAdaptiveBound разбирает ответ text с новой границей bound адаптивного интервала
+// и записывает её в user. Минимум должен оставаться меньше максимума; текущий интервал
+// сдвигается в новые границы. Тексты ошибок — на языке lang.
+func applyAdaptiveBound(lang i18n.Lang, user *db.User, bound, text string) error {
+	minutes, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil || minutes < 1 || minutes > maxTimerMinutes {
+		return errors.New(i18n.T(lang, "adaptive.error_minutes", maxTimerMinutes))
+	}
+
+	lower, upper := user.AdaptiveMinMinutes.Int64, user.AdaptiveMaxMinutes.Int64
+	if bound == adaptiveBoundMin {
+		lower = minutes
+	} else {
+		upper = minutes
+	}
+	if lower >= upper {
+		return errors.New(i18n.T(lang, "adaptive.error_bounds"))
+	}
+
+	user.AdaptiveMinMinutes = sql.NullInt64{Int64: lower, Valid: true}
+	user.AdaptiveMaxMinutes = sql.NullInt64{Int64: upper, Valid: true}
+	if user.AdaptiveCurrentMinutes.Valid {
+		user.AdaptiveCurrentMinutes.Int64 = min(max(user.AdaptiveCurrentMinutes.Int64, lower), upper)
+	}
+	return nil
+}
+
+// updateAdaptiveSettings применяет change к настройкам пользователя и обновляет сообщение
+// с настройками.
+func (h *Handlers) updateAdaptiveSettings(
+	ctx context.Context, callback *tgbotapi.CallbackQuery, change func(user *db.User),
+) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	change(user)
+	if err := h.users.UpdateUserColumns(user.ID, adaptiveColumns(*user)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	h.editAdaptiveSettingsMessage(ctx, callback, *user)
+}
+
+// editAdaptiveSettingsMessage показывает в сообщении с настройками из callback
+// актуальные настройки адаптивного интервала пользователя.
+func (h *Handlers) editAdaptiveSettingsMessage(ctx context.Context, callback *tgbotapi.CallbackQuery, user db.User) {
+	_, err := h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		adaptiveSettingsText(user), getAdaptiveSettingsKeyboardMarkup(user)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// adaptiveColumns возвращает столбцы настроек адаптивного интервала пользователя.
+func adaptiveColumns(user db.User) map[string]any {
+	return map[string]any{
+		"adaptive_interval":        user.AdaptiveInterval,
+		"adaptive_min_minutes":     user.AdaptiveMinMinutes,
+		"adaptive_max_minutes":     user.AdaptiveMaxMinutes,
+		"adaptive_current_minutes": user.AdaptiveCurrentMinutes,
+	}
+}
+
+func adaptiveSettingsText(user db.User) string {
+	lang := userLang(user)
+	if !user.AdaptiveInterval {
+		return i18n.T(lang, "adaptive.disabled", formatMinutes(lang, user.TimerMinutes.Int64))
+	}
+	return i18n.T(lang, "adaptive.enabled",
+		formatMinutes(lang, user.AdaptiveMinMinutes.Int64), formatMinutes(lang, user.AdaptiveMaxMinutes.Int64),
+		formatMinutes(lang, notifyIntervalMinutes(user)))
+}
+
+func getAdaptiveSettingsKeyboardMarkup(user db.User) tgbotapi.InlineKeyboardMarkup {
+	lang := userLang(user)
+	toggle := i18n.T(lang, "adaptive.button_enable")
+	if user.AdaptiveInterval {
+		toggle = i18n.T(lang, "adaptive.button_disable")
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(toggle, "adaptive__toggle")),
+	}
+	if !user.AdaptiveInterval {
+		return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+	}
+
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "adaptive.button_min", formatMinutes(lang, user.AdaptiveMinMinutes.Int64)),
+			"adaptive__edit "+adaptiveBoundMin),
+		tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "adaptive.button_max", formatMinutes(lang, user.AdaptiveMaxMinutes.Int64)),
+			"adaptive__edit "+adaptiveBoundMax),
+	))
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
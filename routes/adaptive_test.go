@@ -0,0 +1,236 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+)
+
+// adaptiveUser — пользователь с адаптивным интервалом current в границах [10, 90].
+func adaptiveUser(current int64) db.User {
+	return db.User{
+		TimerMinutes:           sql.NullInt64{Int64: 30, Valid: true},
+		AdaptiveInterval:       true,
+		AdaptiveMinMinutes:     sql.NullInt64{Int64: 10, Valid: true},
+		AdaptiveMaxMinutes:     sql.NullInt64{Int64: 90, Valid: true},
+		AdaptiveCurrentMinutes: sql.NullInt64{Int64: current, Valid: current != 0},
+	}
+}
+
+func TestPromptIntervalMinutes(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	fixed := adaptiveUser(45)
+	fixed.AdaptiveInterval = false
+
+	tests := []struct {
+		name     string
+		user     db.User
+		previous sql.NullTime
+		want     int64
+	}{
+		{
+			name:     "fixed interval",
+			user:     fixed,
+			previous: sql.NullTime{Time: now.Add(-50 * time.Minute), Valid: true},
+			want:     30,
+		},
+		{name: "first prompt", user: adaptiveUser(45), want: 45},
+		{name: "adaptive without current", user: adaptiveUser(0), want: 30},
+		{
+			name:     "elapsed since previous",
+			user:     adaptiveUser(45),
+			previous: sql.NullTime{Time: now.Add(-50 * time.Minute), Valid: true},
+			want:     50,
+		},
+		{
+			name:     "rounded to minutes",
+			user:     adaptiveUser(45),
+			previous: sql.NullTime{Time: now.Add(-50*time.Minute - 40*time.Second), Valid: true},
+			want:     51,
+		},
+		{
+			name:     "gap too long",
+			user:     adaptiveUser(45),
+			previous: sql.NullTime{Time: now.Add(-8 * time.Hour), Valid: true},
+			want:     45,
+		},
+		{
+			name:     "previous in the future",
+			user:     adaptiveUser(45),
+			previous: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+			want:     45,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promptIntervalMinutes(tt.user, tt.previous, now); got != tt.want {
+				t.Errorf("promptIntervalMinutes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyAdaptiveBound(t *testing.T) {
+	lang := i18n.Russian
+
+	tests := []struct {
+		name        string
+		current     int64
+		bound       string
+		text        string
+		wantMin     int64
+		wantMax     int64
+		wantCurrent int64
+		wantErr     string
+	}{
+		{name: "lower min", current: 45, bound: adaptiveBoundMin, text: "5", wantMin: 5, wantMax: 90, wantCurrent: 45},
+		{
+			name: "raise min above current", current: 15, bound: adaptiveBoundMin, text: " 20 ",
+			wantMin: 20, wantMax: 90, wantCurrent: 20,
+		},
+		{
+			name: "lower max below current", current: 80, bound: adaptiveBoundMax, text: "60",
+			wantMin: 10, wantMax: 60, wantCurrent: 60,
+		},
+		{name: "max limit", current: 45, bound: adaptiveBoundMax, text: "720", wantMin: 10, wantMax: 720, wantCurrent: 45},
+		{
+			name: "not a number", current: 45, bound: adaptiveBoundMin, text: "десять",
+			wantErr: i18n.T(lang, "adaptive.error_minutes", maxTimerMinutes),
+		},
+		{
+			name: "zero", current: 45, bound: adaptiveBoundMin, text: "0",
+			wantErr: i18n.T(lang, "adaptive.error_minutes", maxTimerMinutes),
+		},
+		{
+			name: "above limit", current: 45, bound: adaptiveBoundMax, text: "721",
+			wantErr: i18n.T(lang, "adaptive.error_minutes", maxTimerMinutes),
+		},
+		{
+			name: "min equals max", current: 45, bound: adaptiveBoundMin, text: "90",
+			wantErr: i18n.T(lang, "adaptive.error_bounds"),
+		},
+		{
+			name: "max below min", current: 45, bound: adaptiveBoundMax, text: "5",
+			wantErr: i18n.T(lang, "adaptive.error_bounds"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := adaptiveUser(tt.current)
+			err := applyAdaptiveBound(lang, &user, tt.bound, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("applyAdaptiveBound(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				if user != adaptiveUser(tt.current) {
+					t.Errorf("user changed on error: %+v", user)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyAdaptiveBound(%q): %v", tt.text, err)
+			}
+			got := [3]int64{user.AdaptiveMinMinutes.Int64, user.AdaptiveMaxMinutes.Int64, user.AdaptiveCurrentMinutes.Int64}
+			if want := [3]int64{tt.wantMin, tt.wantMax, tt.wantCurrent}; got != want {
+				t.Errorf("min, max, current = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAdaptInterval(t *testing.T) {
+	const userID common.UserID = 11
+	timestamp := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		user db.User
+		// previous — активность предыдущего ответа; 0 — ответов за сутки не было.
+		previous string
+		answer   string
+		want     sql.NullInt64
+	}{
+		{
+			name: "same activity grows", user: adaptiveUser(40), previous: "Работа / Код", answer: "Работа / Код",
+			want: sql.NullInt64{Int64: 60, Valid: true},
+		},
+		{
+			name: "other activity shrinks", user: adaptiveUser(45), previous: "Работа / Код", answer: "Дом / Обед",
+			want: sql.NullInt64{Int64: 30, Valid: true},
+		},
+		{
+			name: "split shrinks", user: adaptiveUser(45), previous: "Работа / Код",
+			want: sql.NullInt64{Int64: 30, Valid: true},
+		},
+		{
+			name: "clamped to max", user: adaptiveUser(80), previous: "Работа / Код", answer: "Работа / Код",
+			want: sql.NullInt64{Int64: 90, Valid: true},
+		},
+		{
+			name: "clamped to min", user: adaptiveUser(12), previous: "Работа / Код", answer: "Дом / Обед",
+			want: sql.NullInt64{Int64: 10, Valid: true},
+		},
+		{
+			name: "starts from the timer", user: adaptiveUser(0), previous: "Работа / Код", answer: "Работа / Код",
+			want: sql.NullInt64{Int64: 45, Valid: true},
+		},
+		{
+			name: "no previous answer", user: adaptiveUser(45), answer: "Работа / Код",
+			want: sql.NullInt64{Int64: 45, Valid: true},
+		},
+		{
+			name: "adaptive mode off",
+			user: func() db.User {
+				user := adaptiveUser(0)
+				user.AdaptiveInterval = false
+				return user
+			}(),
+			previous: "Работа / Код", answer: "Работа / Код",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo, _ := newTestHandlers(t)
+			user := tt.user
+			user.ID, user.ChatID = userID, 11
+			if err := repo.AddUser(user); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+			activityIDs := make(map[string]int64)
+			for _, path := range []string{"Работа / Код", "Дом / Обед"} {
+				id, err := repo.FindOrAddActivityPath(userID, path)
+				if err != nil {
+					t.Fatalf("FindOrAddActivityPath(%q): %v", path, err)
+				}
+				activityIDs[path] = id
+			}
+			if tt.previous != "" {
+				err := repo.AddActivityLog(db.ActivityLog{
+					MessageID: 1, UserID: int64(userID), ActivityID: activityIDs[tt.previous],
+					Timestamp: timestamp.Add(-time.Hour), IntervalMinutes: 30,
+				})
+				if err != nil {
+					t.Fatalf("AddActivityLog: %v", err)
+				}
+			}
+
+			h.adaptInterval(context.Background(), userID, activityIDs[tt.answer], timestamp)
+
+			stored, err := repo.GetUserByID(userID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if stored.AdaptiveCurrentMinutes != tt.want {
+				t.Errorf("adaptive_current_minutes = %+v, want %+v", stored.AdaptiveCurrentMinutes, tt.want)
+			}
+		})
+	}
+}
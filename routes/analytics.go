@@ -2,11 +2,13 @@ package routes
 
 import (
 	"TimeCounterBot/common"
-	tg "TimeCounterBot/tg/bot"
+	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -30,18 +32,18 @@ type ActivityData struct {
 
 // getUserActivityDataForInterval собирает данные активности
 // для пользователя user за интервал [start, end].
-func getUserActivityDataForInterval(user db.User, start, end time.Time) ActivityData {
+func (h *Handlers) getUserActivityDataForInterval(user db.User, start, end time.Time) (ActivityData, error) {
 	var data ActivityData
 
 	// Получаем все активности пользователя.
-	activities, err := db.GetSimpleActivities(user.ID, nil, nil)
+	activities, err := h.activities.GetSimpleActivities(user.ID, nil, nil)
 	if err != nil {
-		log.Fatal(err)
+		return data, fmt.Errorf("get activities: %w", err)
 	}
 
-	logDurations, err := db.GetLogDurations(user.ID, start, end)
+	logDurations, err := h.logs.GetLogDurations(user.ID, start, end)
 	if err != nil {
-		log.Fatal(err)
+		return data, fmt.Errorf("get log durations: %w", err)
 	}
 
 	// Преобразуем полученные активности в ActivityNode.
@@ -69,23 +71,30 @@ func getUserActivityDataForInterval(user db.User, start, end time.Time) Activity
 		}
 		data.Nodes = append(data.Nodes, node)
 	}
-	return data
+	return data, nil
 }
 
-func generateActivityChart(data ActivityData, outputFile string) {
+// generateActivityChart строит sunburst-диаграмму data в файл outputFile.
+func (h *Handlers) generateActivityChart(ctx context.Context, data ActivityData, outputFile string) error {
+	return h.runChartScript(ctx, "generate_sunburst_chart.py", data, outputFile)
+}
+
+// runChartScript передаёт data в JSON Python-скрипту script из ChartScriptsDir,
+// который рисует график в файл outputFile. При отмене ctx скрипт завершается.
+func (h *Handlers) runChartScript(ctx context.Context, script string, data any, outputFile string) error {
 	// Кодируем данные в JSON
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Fatalf("Ошибка кодирования JSON: %v", err)
+		return fmt.Errorf("encode chart data: %w", err)
 	}
 
 	// Путь к Python-скрипту и файлу вывода
-	scriptPath := "python_scripts/generate_sunburst_chart.py"
+	scriptPath := filepath.Join(h.settings.ChartScriptsDir, script)
 
 	// Создаём команду для запуска Python-скрипта с виртуальной средой
 	// #nosec G204
 
-	cmd := exec.Command("python3", scriptPath, string(jsonData), outputFile)
+	cmd := exec.CommandContext(ctx, h.settings.PythonBin, scriptPath, string(jsonData), outputFile)
 
 	// Перенаправляем stderr, чтобы увидеть ошибки при выполнении
 	cmd.Stderr = os.Stderr
@@ -93,42 +102,54 @@ func generateActivityChart(data ActivityData, outputFile string) {
 	// Запускаем команду и проверяем ошибки
 	err = cmd.Run()
 	if err != nil {
-		log.Fatalf("❌ Ошибка выполнения скрипта: %v", err)
+		return fmt.Errorf("run %s: %w", scriptPath, err)
 	}
 
 	// Проверяем, создался ли файл
-	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		log.Fatalf("❌ Файл с графиком не найден: %s", outputFile)
+	if _, err := os.Stat(outputFile); err != nil {
+		return fmt.Errorf("chart file was not created: %w", err)
 	}
+	return nil
 }
 
 // GetDayStatisticsCommand вызывается, когда пользователь запрашивает статистику
-func GetDayStatisticsCommand(message *tgbotapi.Message) {
-	user, err := db.GetUserByID(common.UserID(message.From.ID))
+func (h *Handlers) GetDayStatisticsCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
 	spl := strings.Split(message.Text, " ")
 	start, err := time.Parse(time.DateOnly, spl[1])
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора даты", "err", err)
+		return
 	}
 	end, err := time.Parse(time.DateOnly, spl[2])
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора даты", "err", err)
+		return
 	}
 	end = end.Add(Day)
 
-	data := getUserActivityDataForInterval(*user, start, end)
+	data, err := h.getUserActivityDataForInterval(*user, start, end)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения данных для диаграммы", "err", err)
+		return
+	}
 	outputFile := "pie_chart.png"
-	generateActivityChart(data, outputFile)
+	if err := h.generateActivityChart(ctx, data, outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы", "err", err)
+		return
+	}
 
 	// Отправляем картинку в Telegram
 	msgconf := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FilePath(outputFile))
-	_, err = tg.Bot.Send(msgconf)
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Fatalf("❌ Ошибка отправки изображения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка отправки изображения", "err", err)
+		return
 	}
 }
 
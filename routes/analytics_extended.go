@@ -1,22 +1,22 @@
 package routes
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math"
-	"os"
 	"sort"
 	"time"
 
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // AnalyticsMenuCommand показывает главное меню аналитики.
-func AnalyticsMenuCommand(message *tgbotapi.Message) {
+func (h *Handlers) AnalyticsMenuCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -24,137 +24,101 @@ func AnalyticsMenuCommand(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
 		return
 	}
 
-	msgText := "📊 *Аналитика активностей*\n\nВыберите тип отчета:"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📈 Статистика за период", "analytics__day_stats"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Сравнить периоды", "analytics__compare_periods"),
-		),
-	)
-
-	msgConf := tgbotapi.NewMessage(int64(user.ChatID), msgText)
+	lang := userLang(*user)
+	msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "analytics.menu"))
 	msgConf.ParseMode = "Markdown"
-	msgConf.ReplyMarkup = keyboard
+	msgConf.ReplyMarkup = getAnalyticsMenuKeyboardMarkup(lang)
 
-	_, err = bot.Bot.Send(msgConf)
+	_, err = h.sender.Send(msgConf)
 	if err != nil {
-		log.Printf("Ошибка отправки сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
 	}
 
 	// Удаляем исходное сообщение команды
-	_, err = bot.Bot.Request(
+	_, err = h.sender.Request(
 		tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID),
 	)
 	if err != nil {
-		log.Printf("Ошибка удаления сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 }
 
 // AnalyticsGetDayStatsCallback показывает меню выбора периода для статистики.
-func AnalyticsGetDayStatsCallback(callback *tgbotapi.CallbackQuery) {
-	msgText := "📈 *Статистика активностей*\n\nВыберите период для анализа:"
-
+func (h *Handlers) AnalyticsGetDayStatsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Сегодня", "day_stats__today"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_today"), "day_stats__today"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Вчера", "day_stats__yesterday"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_yesterday"), "day_stats__yesterday"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Эта неделя", "day_stats__this_week"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_this_week"), "day_stats__this_week"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Прошлая неделя", "day_stats__last_week"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_last_week"), "day_stats__last_week"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "analytics__back"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "analytics__back"),
 		),
 	)
 
 	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		msgText,
+		i18n.T(lang, "analytics.stats_menu"),
 		keyboard,
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Выберите период для статистики")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.choose_stats_period"))
+	h.sender.Request(answerConfig)
 }
 
 // AnalyticsComperiodsCallback показывает меню выбора периодов для сравнения.
-func AnalyticsComperiodsCallback(callback *tgbotapi.CallbackQuery) {
-	msgText := "📊 *Сравнение периодов*\n\nВыберите, какие периоды хотите сравнить:"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Эта неделя vs прошлая", "compare_periods__this_vs_last_week"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📆 Этот месяц vs прошлый", "compare_periods__this_vs_last_month"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔧 Настроить периоды", "compare_periods__custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "analytics__back"),
-		),
-	)
-
+func (h *Handlers) AnalyticsComperiodsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
 	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		msgText,
-		keyboard,
+		i18n.T(lang, "analytics.compare_menu"),
+		getComparePeriodsKeyboardMarkup(lang),
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Выберите период для сравнения")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.choose_compare_period"))
+	h.sender.Request(answerConfig)
 }
 
 // AnalyticsBackCallback возвращает к главному меню аналитики.
-func AnalyticsBackCallback(callback *tgbotapi.CallbackQuery) {
-	msgText := "📊 *Аналитика активностей*\n\nВыберите тип отчета:"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📈 Статистика за период", "analytics__day_stats"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Сравнить периоды", "analytics__compare_periods"),
-		),
-	)
-
+func (h *Handlers) AnalyticsBackCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
 	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		msgText,
-		keyboard,
+		i18n.T(lang, "analytics.menu"),
+		getAnalyticsMenuKeyboardMarkup(lang),
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Главное меню аналитики")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.main_menu"))
+	h.sender.Request(answerConfig)
 }
 
 // ComparePeriods_ThisVsLastWeekCallback сравнивает текущую и прошлую неделю.
-func ComparePeriods_ThisVsLastWeekCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) ComparePeriods_ThisVsLastWeekCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	userID := common.UserID(callback.From.ID)
+	lang := h.langOf(userID)
 
 	now := time.Now()
 
@@ -170,26 +134,26 @@ func ComparePeriods_ThisVsLastWeekCallback(callback *tgbotapi.CallbackQuery) {
 	lastWeekStart := thisWeekStart.AddDate(0, 0, -7)
 	lastWeekEnd := thisWeekEnd.AddDate(0, 0, -7)
 
-	comparison, err := db.CompareActivityPeriods(
+	comparison, err := h.logs.CompareActivityPeriods(
 		userID,
 		thisWeekStart, thisWeekEnd,
 		lastWeekStart, lastWeekEnd,
-		"Эта неделя",
-		"Прошлая неделя",
+		i18n.T(lang, "analytics.this_week"),
+		i18n.T(lang, "analytics.last_week"),
 	)
 
 	if err != nil {
-		log.Printf("Ошибка сравнения периодов: %v", err)
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Ошибка получения данных")
-		bot.Bot.Request(answerConfig)
+		slog.ErrorContext(ctx, "Ошибка сравнения периодов", "err", err)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.data_error"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
-	msgText := formatComparisonResult(comparison)
+	msgText := formatComparisonResult(lang, comparison)
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "compare_periods__back"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "compare_periods__back"),
 		),
 	)
 
@@ -200,15 +164,16 @@ func ComparePeriods_ThisVsLastWeekCallback(callback *tgbotapi.CallbackQuery) {
 		keyboard,
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Сравнение выполнено")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.compared"))
+	h.sender.Request(answerConfig)
 }
 
 // ComparePeriods_ThisVsLastMonthCallback сравнивает текущий и прошлый месяц.
-func ComparePeriods_ThisVsLastMonthCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) ComparePeriods_ThisVsLastMonthCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	userID := common.UserID(callback.From.ID)
+	lang := h.langOf(userID)
 
 	now := time.Now()
 
@@ -220,26 +185,26 @@ func ComparePeriods_ThisVsLastMonthCallback(callback *tgbotapi.CallbackQuery) {
 	lastMonthStart := thisMonthStart.AddDate(0, -1, 0)
 	lastMonthEnd := thisMonthStart.Add(-time.Second)
 
-	comparison, err := db.CompareActivityPeriods(
+	comparison, err := h.logs.CompareActivityPeriods(
 		userID,
 		thisMonthStart, thisMonthEnd,
 		lastMonthStart, lastMonthEnd,
-		"Этот месяц",
-		"Прошлый месяц",
+		i18n.T(lang, "analytics.this_month"),
+		i18n.T(lang, "analytics.last_month"),
 	)
 
 	if err != nil {
-		log.Printf("Ошибка сравнения периодов: %v", err)
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Ошибка получения данных")
-		bot.Bot.Request(answerConfig)
+		slog.ErrorContext(ctx, "Ошибка сравнения периодов", "err", err)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.data_error"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
-	msgText := formatComparisonResult(comparison)
+	msgText := formatComparisonResult(lang, comparison)
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "compare_periods__back"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "compare_periods__back"),
 		),
 	)
 
@@ -250,21 +215,20 @@ func ComparePeriods_ThisVsLastMonthCallback(callback *tgbotapi.CallbackQuery) {
 		keyboard,
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Сравнение выполнено")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.compared"))
+	h.sender.Request(answerConfig)
 }
 
 // ComparePeriods_CustomCallback показывает инструкции для настройки периодов.
-func ComparePeriods_CustomCallback(callback *tgbotapi.CallbackQuery) {
-	msgText := "🔧 *Настраиваемое сравнение*\n\n" +
-		"Эта функция пока не реализована.\n" +
-		"В будущем здесь можно будет выбрать произвольные даты для сравнения."
+func (h *Handlers) ComparePeriods_CustomCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
+	msgText := i18n.T(lang, "analytics.custom")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "compare_periods__back"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "compare_periods__back"),
 		),
 	)
 
@@ -275,48 +239,32 @@ func ComparePeriods_CustomCallback(callback *tgbotapi.CallbackQuery) {
 		keyboard,
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Функция в разработке")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.in_progress"))
+	h.sender.Request(answerConfig)
 }
 
 // ComparePeriods_BackCallback возвращает к меню сравнения периодов.
-func ComparePeriods_BackCallback(callback *tgbotapi.CallbackQuery) {
-	msgText := "📊 *Сравнение периодов*\n\nВыберите, какие периоды хотите сравнить:"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Эта неделя vs прошлая", "compare_periods__this_vs_last_week"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📆 Этот месяц vs прошлый", "compare_periods__this_vs_last_month"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔧 Настроить периоды", "compare_periods__custom"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад", "analytics__back"),
-		),
-	)
-
+func (h *Handlers) ComparePeriods_BackCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
 	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
 		callback.Message.Chat.ID,
 		callback.Message.MessageID,
-		msgText,
-		keyboard,
+		i18n.T(lang, "analytics.compare_menu"),
+		getComparePeriodsKeyboardMarkup(lang),
 	)
 	editConfig.ParseMode = "Markdown"
-	bot.Bot.Send(editConfig)
+	h.sender.Send(editConfig)
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "Меню сравнения периодов")
-	bot.Bot.Request(answerConfig)
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.compare_menu_toast"))
+	h.sender.Request(answerConfig)
 }
 
 // formatComparisonResult форматирует результат сравнения в красивый текст.
-func formatComparisonResult(comparison *db.PeriodComparisonResult) string {
+func formatComparisonResult(lang i18n.Lang, comparison *db.PeriodComparisonResult) string {
 	if len(comparison.Comparisons) == 0 {
-		return "📊 *Сравнение периодов*\n\nНет данных для сравнения."
+		return i18n.T(lang, "analytics.no_comparison_data")
 	}
 
 	// Сортируем по убыванию разности во времени
@@ -324,26 +272,25 @@ func formatComparisonResult(comparison *db.PeriodComparisonResult) string {
 		return math.Abs(float64(comparison.Comparisons[i].DifferenceMin)) > math.Abs(float64(comparison.Comparisons[j].DifferenceMin))
 	})
 
-	result := fmt.Sprintf("📊 *Сравнение: %s vs %s*\n\n",
-		comparison.Period1Name, comparison.Period2Name)
+	result := i18n.T(lang, "analytics.comparison_title", comparison.Period1Name, comparison.Period2Name)
 
 	// Общая статистика
 	totalDiff := comparison.Period1Total - comparison.Period2Total
 	totalDiffHours := float64(totalDiff) / 60.0
-	result += fmt.Sprintf("⏱ *Общее время:*\n")
-	result += fmt.Sprintf("• %s: %s\n", comparison.Period1Name, formatMinutes(comparison.Period1Total))
-	result += fmt.Sprintf("• %s: %s\n", comparison.Period2Name, formatMinutes(comparison.Period2Total))
+	result += i18n.T(lang, "analytics.total_time")
+	result += fmt.Sprintf("• %s: %s\n", comparison.Period1Name, formatMinutes(lang, comparison.Period1Total))
+	result += fmt.Sprintf("• %s: %s\n", comparison.Period2Name, formatMinutes(lang, comparison.Period2Total))
 
 	if totalDiff > 0 {
-		result += fmt.Sprintf("📈 *Изменение:* +%.1f ч\n\n", totalDiffHours)
+		result += i18n.T(lang, "analytics.change_up", totalDiffHours)
 	} else if totalDiff < 0 {
-		result += fmt.Sprintf("📉 *Изменение:* %.1f ч\n\n", totalDiffHours)
+		result += i18n.T(lang, "analytics.change_down", totalDiffHours)
 	} else {
-		result += fmt.Sprintf("➖ *Изменение:* без изменений\n\n")
+		result += i18n.T(lang, "analytics.change_none")
 	}
 
 	// Топ изменений (максимум 5 активностей)
-	result += "*Основные изменения:*\n"
+	result += i18n.T(lang, "analytics.main_changes")
 
 	count := 0
 	for _, comp := range comparison.Comparisons {
@@ -370,57 +317,54 @@ func formatComparisonResult(comparison *db.PeriodComparisonResult) string {
 		percentStr := ""
 		if comp.PercentChange != 0 {
 			if comp.PercentChange == 100 {
-				percentStr = " (новая)"
+				percentStr = i18n.T(lang, "analytics.new_activity")
 			} else if comp.PercentChange == -100 {
-				percentStr = " (исчезла)"
+				percentStr = i18n.T(lang, "analytics.gone_activity")
 			} else {
 				percentStr = fmt.Sprintf(" (%s%.0f%%)", sign, math.Abs(comp.PercentChange))
 			}
 		}
 
-		result += fmt.Sprintf("%s *%s*: %s%.1f ч%s\n",
+		result += i18n.T(lang, "analytics.activity_change",
 			icon, comp.ActivityName, sign, math.Abs(diffHours), percentStr)
 
 		count++
 	}
 
 	if count == 0 {
-		result += "Значительных изменений не обнаружено."
+		result += i18n.T(lang, "analytics.no_changes")
 	}
 
 	return result
 }
 
-// formatMinutes форматирует минуты в часы и минуты.
-func formatMinutes(minutes int64) string {
-	if minutes == 0 {
-		return "0 мин"
-	}
-
+// formatMinutes форматирует минуты в часы и минуты на языке lang.
+func formatMinutes(lang i18n.Lang, minutes int64) string {
 	hours := minutes / 60
 	mins := minutes % 60
 
 	if hours == 0 {
-		return fmt.Sprintf("%d мин", mins)
+		return i18n.T(lang, "duration.minutes", mins)
 	} else if mins == 0 {
-		return fmt.Sprintf("%d ч", hours)
+		return i18n.T(lang, "duration.hours", hours)
 	} else {
-		return fmt.Sprintf("%d ч %d мин", hours, mins)
+		return i18n.T(lang, "duration.hours_minutes", hours, mins)
 	}
 }
 
 // DayStatsCallback обрабатывает выбор периода для статистики и генерирует график.
-func DayStatsCallback(callback *tgbotapi.CallbackQuery, periodType string) {
+func (h *Handlers) DayStatsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, periodType string) {
 	userID := common.UserID(callback.From.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользователя: %v", err)
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Ошибка получения данных")
-		bot.Bot.Request(answerConfig)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(i18n.Default, "analytics.data_error"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
+	lang := userLang(*user)
 	now := time.Now()
 	var start, end time.Time
 	var periodName string
@@ -429,11 +373,11 @@ func DayStatsCallback(callback *tgbotapi.CallbackQuery, periodType string) {
 	case "today":
 		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		end = start.AddDate(0, 0, 1)
-		periodName = "сегодня"
+		periodName = i18n.T(lang, "analytics.period_today")
 	case "yesterday":
 		start = time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location())
 		end = start.AddDate(0, 0, 1)
-		periodName = "вчера"
+		periodName = i18n.T(lang, "analytics.period_yesterday")
 	case "this_week":
 		weekday := int(now.Weekday())
 		if weekday == 0 { // Воскресенье
@@ -441,7 +385,7 @@ func DayStatsCallback(callback *tgbotapi.CallbackQuery, periodType string) {
 		}
 		start = now.AddDate(0, 0, -(weekday - 1)).Truncate(24 * time.Hour)
 		end = start.AddDate(0, 0, 7)
-		periodName = "на этой неделе"
+		periodName = i18n.T(lang, "analytics.period_this_week")
 	case "last_week":
 		weekday := int(now.Weekday())
 		if weekday == 0 { // Воскресенье
@@ -450,53 +394,89 @@ func DayStatsCallback(callback *tgbotapi.CallbackQuery, periodType string) {
 		thisWeekStart := now.AddDate(0, 0, -(weekday - 1)).Truncate(24 * time.Hour)
 		start = thisWeekStart.AddDate(0, 0, -7)
 		end = start.AddDate(0, 0, 7)
-		periodName = "на прошлой неделе"
+		periodName = i18n.T(lang, "analytics.period_last_week")
 	default:
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Неизвестный период")
-		bot.Bot.Request(answerConfig)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.unknown_period"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
-	data := getUserActivityDataForInterval(*user, start, end)
+	data, err := h.getUserActivityDataForInterval(*user, start, end)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения данных для диаграммы", "err", err)
+		return
+	}
 	outputFile := fmt.Sprintf("analytics_chart_%d_%d.png", user.ID, callback.Message.MessageID)
 
 	// Используем существующую функцию генерации графика
-	generateActivityChart(data, outputFile)
+	if err := h.generateActivityChart(ctx, data, outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы", "err", err)
+		return
+	}
+	defer removeChartFile(ctx, outputFile)
 
 	// Отправляем картинку в Telegram
 	msgconf := tgbotapi.NewPhoto(int64(user.ChatID), tgbotapi.FilePath(outputFile))
-	msgconf.Caption = fmt.Sprintf("📊 Диаграмма активности %s\n(%s - %s)",
+	msgconf.Caption = i18n.T(lang, "analytics.chart_caption",
 		periodName,
-		start.Format("02.01.2006"),
-		end.AddDate(0, 0, -1).Format("02.01.2006"))
+		start.Format(i18n.T(lang, "format.date")),
+		end.AddDate(0, 0, -1).Format(i18n.T(lang, "format.date")))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("⬅️ Назад к выбору периода", "analytics__day_stats"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.back_to_periods"), "analytics__day_stats"),
 		),
 	)
 	msgconf.ReplyMarkup = keyboard
 
-	_, err = bot.Bot.Send(msgconf)
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Printf("❌ Ошибка отправки изображения: %v", err)
-		answerConfig := tgbotapi.NewCallback(callback.ID, "Ошибка создания графика")
-		bot.Bot.Request(answerConfig)
+		slog.ErrorContext(ctx, "Ошибка отправки изображения", "err", err)
+		answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.chart_error"))
+		h.sender.Request(answerConfig)
 		return
 	}
 
 	// Удаляем предыдущее сообщение
-	_, err = bot.Bot.Send(tgbotapi.NewDeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID))
+	_, err = h.sender.Send(tgbotapi.NewDeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID))
 	if err != nil {
-		log.Printf("Ошибка удаления сообщения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 
-	// Удаляем временный файл
-	err = os.Remove(outputFile)
-	if err != nil {
-		log.Printf("Не удалось удалить временный файл: %v", err)
-	}
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.chart_done"))
+	h.sender.Request(answerConfig)
+}
 
-	answerConfig := tgbotapi.NewCallback(callback.ID, "График создан")
-	bot.Bot.Request(answerConfig)
+func getAnalyticsMenuKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_stats"), "analytics__day_stats"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_compare"), "analytics__compare_periods"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_patterns"), "analytics__patterns"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_trends"), "analytics__trends"),
+		),
+	)
+}
+
+func getComparePeriodsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_weeks"), "compare_periods__this_vs_last_week"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_months"), "compare_periods__this_vs_last_month"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "analytics.button_custom"), "compare_periods__custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "analytics__back"),
+		),
+	)
 }
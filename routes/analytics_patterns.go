@@ -0,0 +1,481 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// patternsDays — за сколько последних дней строятся тепловая карта и распределение по часам.
+const patternsDays = 90
+
+// calendarWeeks — сколько недель, включая текущую, показывает календарная тепловая карта.
+const calendarWeeks = 53
+
+// maxHourSeries — сколько активностей показывать отдельно в распределении по часам;
+// остальные объединяются в одну.
+const maxHourSeries = 7
+
+// WeekdayHourHeatmapData — данные для скрипта generate_weekday_hour_heatmap.py.
+type WeekdayHourHeatmapData struct {
+	Title    string `json:"title"`
+	Colorbar string `json:"colorbar"`
+	// Weekdays — подписи дней недели, начиная с понедельника.
+	Weekdays []string `json:"weekdays"`
+	// Hours — часы активности: строка — день недели (0 — понедельник), столбец — час суток
+	// по часовому поясу бота.
+	Hours [7][24]float64 `json:"hours"`
+}
+
+// CalendarHeatmapData — данные для скрипта generate_calendar_heatmap.py.
+type CalendarHeatmapData struct {
+	Title    string `json:"title"`
+	Colorbar string `json:"colorbar"`
+	// Start — первый день карты (понедельник) в формате YYYY-MM-DD.
+	Start string `json:"start"`
+	// Hours — часы активности по дням подряд, начиная со Start.
+	Hours    []float64 `json:"hours"`
+	Weekdays []string  `json:"weekdays"`
+	// Months — короткие названия месяцев с января.
+	Months []string `json:"months"`
+}
+
+// HourDistributionData — данные для скрипта generate_hour_distribution_chart.py.
+type HourDistributionData struct {
+	Title  string           `json:"title"`
+	YLabel string           `json:"ylabel"`
+	Series []HourSeriesData `json:"series"`
+}
+
+// HourSeriesData — среднее число минут в день активности Name по часам суток.
+type HourSeriesData struct {
+	Name    string      `json:"name"`
+	Minutes [24]float64 `json:"minutes"`
+}
+
+// spreadByHour раскладывает лог по часам суток в часовом поясе loc: лог покрывает
+// IntervalMinutes минут, заканчивающихся в Timestamp. add вызывается с началом часа
+// (в loc) и минутами в нём.
+func spreadByHour(activityLog db.ActivityLog, loc *time.Location, add func(hour time.Time, minutes float64)) {
+	end := activityLog.Timestamp.In(loc)
+	start := end.Add(-time.Duration(activityLog.IntervalMinutes) * time.Minute)
+	for start.Before(end) {
+		// Truncate отсчитывает часы от UTC, а у поясов вроде +05:30 границы часов другие.
+		hour := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc)
+		next := hour.Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+		add(hour, next.Sub(start).Minutes())
+		start = next
+	}
+}
+
+// daysBetween возвращает число календарных дней от полуночи start до дня, в который
+// попадает ts (в часовом поясе start). Сутки при переходе на летнее время короче или
+// длиннее 24 часов, поэтому разница округляется.
+func daysBetween(start, ts time.Time) int {
+	return int(math.Round(startOfDay(ts, start.Location()).Sub(start).Hours() / 24))
+}
+
+// activityBranches сопоставляет каждой активности поддерева rootID её ветку — предка,
+// чей родитель rootID (rootID = -1 — всё дерево, ветки — корневые активности).
+// Сама rootID относится к своей ветке; активности вне поддерева в результат не попадают.
+func activityBranches(activities []db.Activity, rootID int64) map[int64]int64 {
+	parents := make(map[int64]int64, len(activities))
+	for _, activity := range activities {
+		parents[activity.ID] = activity.ParentActivityID
+	}
+
+	branches := make(map[int64]int64)
+	for _, activity := range activities {
+		if activity.ID == rootID {
+			branches[activity.ID] = rootID
+			continue
+		}
+		current := activity.ID
+		// Глубина ограничена числом активностей на случай испорченного дерева.
+		for range activities {
+			parent, ok := parents[current]
+			if !ok {
+				break
+			}
+			if parent == rootID {
+				branches[activity.ID] = current
+				break
+			}
+			current = parent
+		}
+	}
+	return branches
+}
+
+// activityPath возвращает полное название активности ("Работа / Созвоны"), в том
+// числе не листовой.
+func activityPath(activities []db.Activity, activityID int64) string {
+	byID := make(map[int64]db.Activity, len(activities))
+	for _, activity := range activities {
+		byID[activity.ID] = activity
+	}
+
+	var parts []string
+	for current := activityID; len(parts) < len(activities); {
+		activity, ok := byID[current]
+		if !ok {
+			break
+		}
+		parts = append([]string{activity.Name}, parts...)
+		current = activity.ParentActivityID
+	}
+	return strings.Join(parts, " / ")
+}
+
+// weekdayLabels возвращает короткие названия дней недели, начиная с понедельника.
+func weekdayLabels(lang i18n.Lang) []string {
+	labels := make([]string, 0, 7)
+	for i := range 7 {
+		labels = append(labels, weekdayName(lang, time.Weekday((i+1)%7)))
+	}
+	return labels
+}
+
+// buildWeekdayHourHeatmap суммирует часы логов из branches по дням недели и часам суток в loc.
+func buildWeekdayHourHeatmap(logs []db.ActivityLog, branches map[int64]int64, loc *time.Location) [7][24]float64 {
+	var hours [7][24]float64
+	for _, activityLog := range logs {
+		if _, ok := branches[activityLog.ActivityID]; !ok {
+			continue
+		}
+		spreadByHour(activityLog, loc, func(hour time.Time, minutes float64) {
+			hours[(int(hour.Weekday())+6)%7][hour.Hour()] += minutes / 60
+		})
+	}
+	return hours
+}
+
+// buildCalendarHours суммирует часы логов по дням, начиная со start — полуночи в
+// часовом поясе, по которому считаются дни.
+func buildCalendarHours(logs []db.ActivityLog, start time.Time, days int) []float64 {
+	hours := make([]float64, days)
+	for _, activityLog := range logs {
+		spreadByHour(activityLog, start.Location(), func(hour time.Time, minutes float64) {
+			day := daysBetween(start, hour)
+			if day >= 0 && day < days {
+				hours[day] += minutes / 60
+			}
+		})
+	}
+	return hours
+}
+
+// observedDays возвращает, за сколько дней (не больше limit) есть данные: от начала
+// самого раннего лога из logs до now, с округлением вверх. Без этого у нового
+// пользователя средние за день делились бы на весь период окна.
+func observedDays(logs []db.ActivityLog, now time.Time, limit int) int {
+	if len(logs) == 0 {
+		return limit
+	}
+	first := now
+	for _, activityLog := range logs {
+		start := activityLog.Timestamp.Add(-time.Duration(activityLog.IntervalMinutes) * time.Minute)
+		if start.Before(first) {
+			first = start
+		}
+	}
+	days := int(math.Ceil(float64(now.Sub(first)) / float64(Day)))
+	return max(1, min(days, limit))
+}
+
+// buildHourDistribution считает среднее число минут в день по часам суток в loc для
+// каждой ветки branches. Ветки упорядочены по убыванию времени; после maxHourSeries
+// остальные объединяются в ветку 0.
+func buildHourDistribution(
+	logs []db.ActivityLog, branches map[int64]int64, days int, loc *time.Location,
+) (order []int64, minutes map[int64]*[24]float64) {
+	minutes = make(map[int64]*[24]float64)
+	totals := make(map[int64]float64)
+	for _, activityLog := range logs {
+		branch, ok := branches[activityLog.ActivityID]
+		if !ok {
+			continue
+		}
+		if minutes[branch] == nil {
+			minutes[branch] = &[24]float64{}
+			order = append(order, branch)
+		}
+		spreadByHour(activityLog, loc, func(hour time.Time, value float64) {
+			minutes[branch][hour.Hour()] += value / float64(days)
+			totals[branch] += value
+		})
+	}
+	sort.SliceStable(order, func(i, j int) bool { return totals[order[i]] > totals[order[j]] })
+
+	if len(order) > maxHourSeries {
+		other := &[24]float64{}
+		for _, branch := range order[maxHourSeries:] {
+			for hour, value := range minutes[branch] {
+				other[hour] += value
+			}
+			delete(minutes, branch)
+		}
+		order = append(order[:maxHourSeries], 0)
+		minutes[0] = other
+	}
+	return order, minutes
+}
+
+// AnalyticsPatternsCallback показывает меню отчётов о распределении времени.
+func (h *Handlers) AnalyticsPatternsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
+	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID,
+		callback.Message.MessageID,
+		i18n.T(lang, "patterns.menu", patternsDays, h.zoneName()),
+		getPatternsKeyboardMarkup(lang),
+	)
+	editConfig.ParseMode = "Markdown"
+	h.sender.Send(editConfig)
+
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "patterns.menu_toast"))
+	h.sender.Request(answerConfig)
+}
+
+// PatternsHeatmapCallback присылает тепловую карту "дни недели × часы" для поддерева
+// активности. Под картой — кнопки подактивностей для перехода в их поддеревья.
+// Callback data: "patterns__heatmap <activity_id>", -1 — все активности.
+func (h *Handlers) PatternsHeatmapCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var rootID int64
+	_, err := fmt.Sscanf(callback.Data, "patterns__heatmap %d", &rootID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	user, activities, logs, ok := h.loadPatternsData(ctx, callback, time.Now().Add(-patternsDays*Day))
+	if !ok {
+		return
+	}
+	lang := userLang(*user)
+
+	name := i18n.T(lang, "patterns.all_activities")
+	if rootID != -1 {
+		name = activityPath(activities, rootID)
+	}
+
+	hours := buildWeekdayHourHeatmap(logs, activityBranches(activities, rootID), h.location())
+	var total float64
+	for _, day := range hours {
+		for _, value := range day {
+			total += value
+		}
+	}
+	if total == 0 {
+		h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(lang, "patterns.no_data")))
+		return
+	}
+
+	data := WeekdayHourHeatmapData{
+		Title:    i18n.T(lang, "patterns.heatmap_title", name, h.zoneName(), patternsDays),
+		Colorbar: i18n.T(lang, "patterns.hours_unit"),
+		Weekdays: weekdayLabels(lang),
+		Hours:    hours,
+	}
+	caption := i18n.T(lang, "patterns.heatmap_caption", name, formatMinutes(lang, int64(total*60)), patternsDays)
+	keyboard := getPatternsSubtreeKeyboardMarkup(activities, rootID)
+	if keyboard != nil {
+		caption += i18n.T(lang, "patterns.heatmap_subtree")
+	}
+	h.sendPatternsChart(ctx, callback, *user, "generate_weekday_hour_heatmap.py", data, caption, keyboard)
+}
+
+// PatternsCalendarCallback присылает календарную тепловую карту отслеженного времени по дням за год.
+func (h *Handlers) PatternsCalendarCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	today := startOfDay(time.Now(), h.location())
+	// Карта начинается с понедельника, чтобы недели шли столбцами.
+	start := today.AddDate(0, 0, -(int(today.Weekday())+6)%7-7*(calendarWeeks-1))
+	days := daysBetween(start, today) + 1
+
+	user, _, logs, ok := h.loadPatternsData(ctx, callback, start)
+	if !ok {
+		return
+	}
+	lang := userLang(*user)
+
+	hours := buildCalendarHours(logs, start, days)
+	var total float64
+	activeDays := 0
+	for _, value := range hours {
+		total += value
+		if value > 0 {
+			activeDays++
+		}
+	}
+	if activeDays == 0 {
+		h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(lang, "patterns.no_data")))
+		return
+	}
+
+	months := make([]string, 0, 12)
+	for month := time.January; month <= time.December; month++ {
+		months = append(months, i18n.T(lang, fmt.Sprintf("month.%d", month)))
+	}
+	data := CalendarHeatmapData{
+		Title: i18n.T(lang, "patterns.calendar_title",
+			start.Format(i18n.T(lang, "format.date")), today.Format(i18n.T(lang, "format.date"))),
+		Colorbar: i18n.T(lang, "patterns.hours_per_day"),
+		Start:    start.Format(time.DateOnly),
+		Hours:    hours,
+		Weekdays: weekdayLabels(lang),
+		Months:   months,
+	}
+	caption := i18n.T(lang, "patterns.calendar_caption", formatMinutes(lang, int64(total*60)), activeDays, days)
+	h.sendPatternsChart(ctx, callback, *user, "generate_calendar_heatmap.py", data, caption, nil)
+}
+
+// PatternsHoursCallback присылает распределение времени по часам суток по корневым активностям.
+func (h *Handlers) PatternsHoursCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	now := time.Now()
+	user, activities, logs, ok := h.loadPatternsData(ctx, callback, now.Add(-patternsDays*Day))
+	if !ok {
+		return
+	}
+	lang := userLang(*user)
+
+	days := observedDays(logs, now, patternsDays)
+	order, minutes := buildHourDistribution(logs, activityBranches(activities, -1), days, h.location())
+	if len(order) == 0 {
+		h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(lang, "patterns.no_data")))
+		return
+	}
+
+	names := make(map[int64]string, len(activities))
+	for _, activity := range activities {
+		names[activity.ID] = activity.Name
+	}
+	names[0] = i18n.T(lang, "patterns.other")
+
+	var total [24]float64
+	series := make([]HourSeriesData, 0, len(order))
+	for _, branch := range order {
+		series = append(series, HourSeriesData{Name: names[branch], Minutes: *minutes[branch]})
+		for hour, value := range minutes[branch] {
+			total[hour] += value
+		}
+	}
+	busiest := 0
+	for hour, value := range total {
+		if value > total[busiest] {
+			busiest = hour
+		}
+	}
+
+	data := HourDistributionData{
+		Title:  i18n.T(lang, "patterns.hours_title", h.zoneName(), days),
+		YLabel: i18n.T(lang, "patterns.minutes_per_day"),
+		Series: series,
+	}
+	caption := i18n.T(lang, "patterns.hours_caption", days, busiest, (busiest+1)%24, h.zoneName())
+	h.sendPatternsChart(ctx, callback, *user, "generate_hour_distribution_chart.py", data, caption, nil)
+}
+
+// loadPatternsData получает пользователя, его активности и логи с since до текущего
+// момента. При ошибке отвечает на callback и возвращает ok = false.
+func (h *Handlers) loadPatternsData(
+	ctx context.Context, callback *tgbotapi.CallbackQuery, since time.Time,
+) (user *db.User, activities []db.Activity, logs []db.ActivityLog, ok bool) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(i18n.Default, "analytics.data_error")))
+		return nil, nil, nil, false
+	}
+
+	activities, err = h.activities.GetSimpleActivities(user.ID, nil, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "analytics.data_error")))
+		return nil, nil, nil, false
+	}
+
+	logs, err = h.logs.GetActivityLogs(user.ID, since, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения логов активностей", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "analytics.data_error")))
+		return nil, nil, nil, false
+	}
+	return user, activities, logs, true
+}
+
+// sendPatternsChart строит график скриптом script по data и присылает его с подписью caption.
+func (h *Handlers) sendPatternsChart(
+	ctx context.Context, callback *tgbotapi.CallbackQuery, user db.User,
+	script string, data any, caption string, keyboard *tgbotapi.InlineKeyboardMarkup,
+) {
+	lang := userLang(user)
+	outputFile := fmt.Sprintf("patterns_chart_%d_%s.png", user.ID, callback.ID)
+	if err := h.runChartScript(ctx, script, data, outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы", "script", script, "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.chart_error")))
+		return
+	}
+	defer removeChartFile(ctx, outputFile)
+
+	msgconf := tgbotapi.NewPhoto(int64(user.ChatID), tgbotapi.FilePath(outputFile))
+	msgconf.Caption = caption
+	if keyboard != nil {
+		msgconf.ReplyMarkup = *keyboard
+	}
+	_, err := h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки изображения", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.chart_error")))
+		return
+	}
+
+	h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "analytics.chart_done")))
+}
+
+func getPatternsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "patterns.button_heatmap"), "patterns__heatmap -1"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "patterns.button_calendar"), "patterns__calendar"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "patterns.button_hours"), "patterns__hours"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "analytics__back"),
+		),
+	)
+}
+
+// getPatternsSubtreeKeyboardMarkup возвращает кнопки тепловых карт для подактивностей
+// rootID или nil, если подактивностей нет.
+func getPatternsSubtreeKeyboardMarkup(activities []db.Activity, rootID int64) *tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
+	for _, activity := range activities {
+		if activity.ParentActivityID != rootID {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			"🗓 "+activity.Name, "patterns__heatmap "+strconv.FormatInt(activity.ID, 10))))
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
@@ -0,0 +1,294 @@
+package routes
+
+import (
+	"math"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"TimeCounterBot/db"
+)
+
+// hourShare — минуты лога, попавшие в час, начинающийся в hour.
+type hourShare struct {
+	hour    time.Time
+	minutes float64
+}
+
+func TestSpreadByHour(t *testing.T) {
+	india := time.FixedZone("IST", 5*60*60+30*60)
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name string
+		log  db.ActivityLog
+		loc  *time.Location
+		want []hourShare
+	}{
+		{
+			name: "inside one hour",
+			log:  db.ActivityLog{Timestamp: time.Date(2024, 5, 10, 9, 50, 0, 0, time.UTC), IntervalMinutes: 30},
+			loc:  time.UTC,
+			want: []hourShare{{time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC), 30}},
+		},
+		{
+			name: "across an hour boundary",
+			log:  db.ActivityLog{Timestamp: time.Date(2024, 5, 10, 10, 15, 0, 0, time.UTC), IntervalMinutes: 90},
+			loc:  time.UTC,
+			want: []hourShare{
+				{time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC), 15},
+				{time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC), 60},
+				{time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC), 15},
+			},
+		},
+		{
+			name: "half-hour zone",
+			log:  db.ActivityLog{Timestamp: time.Date(2024, 5, 10, 4, 0, 0, 0, time.UTC), IntervalMinutes: 60},
+			loc:  india,
+			want: []hourShare{
+				{time.Date(2024, 5, 10, 8, 0, 0, 0, india), 30},
+				{time.Date(2024, 5, 10, 9, 0, 0, 0, india), 30},
+			},
+		},
+		{
+			name: "across midnight in the bot zone",
+			log:  db.ActivityLog{Timestamp: time.Date(2024, 5, 9, 21, 30, 0, 0, time.UTC), IntervalMinutes: 60},
+			loc:  moscow,
+			want: []hourShare{
+				{time.Date(2024, 5, 9, 23, 0, 0, 0, moscow), 30},
+				{time.Date(2024, 5, 10, 0, 0, 0, 0, moscow), 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []hourShare
+			spreadByHour(tt.log, tt.loc, func(hour time.Time, minutes float64) {
+				got = append(got, hourShare{hour, minutes})
+			})
+			if len(got) != len(tt.want) {
+				t.Fatalf("spreadByHour() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].hour.Equal(tt.want[i].hour) || got[i].minutes != tt.want[i].minutes {
+					t.Errorf("share %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	start := time.Date(2024, 3, 25, 0, 0, 0, 0, berlin)
+	// Старт до перехода на летнее время 31 марта: одни сутки в периоде длятся 23 часа.
+	before := time.Date(2024, 3, 20, 0, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		ts    time.Time
+		want  int
+	}{
+		{name: "same day", start: start, ts: start.Add(23 * time.Hour), want: 0},
+		{name: "next day", start: start, ts: time.Date(2024, 3, 26, 0, 30, 0, 0, berlin), want: 1},
+		{name: "ts in another zone", start: start, ts: time.Date(2024, 3, 25, 23, 30, 0, 0, time.UTC), want: 1},
+		{name: "across the clock change", start: before, ts: time.Date(2024, 4, 2, 12, 0, 0, 0, berlin), want: 13},
+		{name: "before start", start: start, ts: time.Date(2024, 3, 24, 12, 0, 0, 0, berlin), want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := daysBetween(tt.start, tt.ts); got != tt.want {
+				t.Errorf("daysBetween(%v, %v) = %d, want %d", tt.start, tt.ts, got, tt.want)
+			}
+		})
+	}
+}
+
+// testActivities — дерево: 1 Работа (2 Код, 3 Созвоны (4 Планёрка)), 5 Дом (6 Обед).
+var testActivities = []db.Activity{
+	{ID: 1, Name: "Работа", ParentActivityID: -1},
+	{ID: 2, Name: "Код", ParentActivityID: 1, IsLeaf: true},
+	{ID: 3, Name: "Созвоны", ParentActivityID: 1},
+	{ID: 4, Name: "Планёрка", ParentActivityID: 3, IsLeaf: true},
+	{ID: 5, Name: "Дом", ParentActivityID: -1},
+	{ID: 6, Name: "Обед", ParentActivityID: 5, IsLeaf: true},
+}
+
+func TestActivityBranches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rootID int64
+		want   map[int64]int64
+	}{
+		{name: "whole tree", rootID: -1, want: map[int64]int64{1: 1, 2: 1, 3: 1, 4: 1, 5: 5, 6: 5}},
+		{name: "subtree", rootID: 1, want: map[int64]int64{1: 1, 2: 2, 3: 3, 4: 3}},
+		{name: "leaf", rootID: 6, want: map[int64]int64{6: 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activityBranches(testActivities, tt.rootID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("activityBranches(%d) = %v, want %v", tt.rootID, got, tt.want)
+			}
+			for id, branch := range tt.want {
+				if got[id] != branch {
+					t.Errorf("activityBranches(%d)[%d] = %d, want %d", tt.rootID, id, got[id], branch)
+				}
+			}
+		})
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		broken := []db.Activity{{ID: 1, ParentActivityID: 2}, {ID: 2, ParentActivityID: 1}}
+		if got := activityBranches(broken, -1); len(got) != 0 {
+			t.Errorf("activityBranches() on a cycle = %v, want empty", got)
+		}
+	})
+}
+
+func TestActivityPath(t *testing.T) {
+	tests := []struct {
+		id   int64
+		want string
+	}{
+		{id: 1, want: "Работа"},
+		{id: 3, want: "Работа / Созвоны"},
+		{id: 4, want: "Работа / Созвоны / Планёрка"},
+		{id: 42, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := activityPath(testActivities, tt.id); got != tt.want {
+			t.Errorf("activityPath(%d) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestBuildWeekdayHourHeatmap(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	logs := []db.ActivityLog{
+		// Воскресенье 22:30–23:30 UTC — понедельник 01:30–02:30 по Москве.
+		{ActivityID: 2, Timestamp: time.Date(2024, 5, 12, 23, 30, 0, 0, time.UTC), IntervalMinutes: 60},
+		// Не входит в выбранные ветки.
+		{ActivityID: 6, Timestamp: time.Date(2024, 5, 12, 23, 30, 0, 0, time.UTC), IntervalMinutes: 60},
+	}
+
+	hours := buildWeekdayHourHeatmap(logs, activityBranches(testActivities, 1), moscow)
+
+	var want [7][24]float64
+	want[0][1], want[0][2] = 0.5, 0.5
+	if hours != want {
+		t.Errorf("buildWeekdayHourHeatmap() = %v, want Monday 01:00 and 02:00 half an hour each", hours)
+	}
+}
+
+func TestBuildCalendarHours(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, moscow)
+	logs := []db.ActivityLog{
+		// 30 апреля 23:30 – 1 мая 00:30 по Москве: в окно попадает половина.
+		{Timestamp: time.Date(2024, 4, 30, 21, 30, 0, 0, time.UTC), IntervalMinutes: 60},
+		{Timestamp: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC), IntervalMinutes: 120},
+		// После окна.
+		{Timestamp: time.Date(2024, 5, 3, 21, 30, 0, 0, time.UTC), IntervalMinutes: 30},
+	}
+
+	got := buildCalendarHours(logs, start, 3)
+	want := []float64{0.5, 2, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("buildCalendarHours() = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestObservedDays(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	logAt := func(ago time.Duration, minutes int64) db.ActivityLog {
+		return db.ActivityLog{Timestamp: now.Add(-ago), IntervalMinutes: minutes}
+	}
+
+	tests := []struct {
+		name string
+		logs []db.ActivityLog
+		want int
+	}{
+		{name: "no logs", want: 28},
+		{name: "started an hour ago", logs: []db.ActivityLog{logAt(0, 60)}, want: 1},
+		{name: "partial days round up", logs: []db.ActivityLog{logAt(0, 30), logAt(2*Day, 60)}, want: 3},
+		{name: "interval counts as observed", logs: []db.ActivityLog{logAt(Day-time.Hour, 120)}, want: 2},
+		{name: "capped by the window", logs: []db.ActivityLog{logAt(40*Day, 30)}, want: 28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := observedDays(tt.logs, now, 28); got != tt.want {
+				t.Errorf("observedDays() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildHourDistribution(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	t.Run("average per day by branch", func(t *testing.T) {
+		logs := []db.ActivityLog{
+			{ActivityID: 2, Timestamp: time.Date(2024, 5, 10, 7, 0, 0, 0, time.UTC), IntervalMinutes: 60},
+			{ActivityID: 4, Timestamp: time.Date(2024, 5, 11, 7, 0, 0, 0, time.UTC), IntervalMinutes: 60},
+			{ActivityID: 6, Timestamp: time.Date(2024, 5, 11, 10, 0, 0, 0, time.UTC), IntervalMinutes: 30},
+		}
+
+		order, minutes := buildHourDistribution(logs, activityBranches(testActivities, -1), 2, moscow)
+
+		if len(order) != 2 || order[0] != 1 || order[1] != 5 {
+			t.Fatalf("order = %v, want [1 5]", order)
+		}
+		// Два часа работы за два дня с 09:00 до 10:00 по Москве — 60 минут в день.
+		if got := minutes[1][9]; got != 60 {
+			t.Errorf("Работа at 09:00 = %v, want 60", got)
+		}
+		if got := minutes[5][12]; got != 15 {
+			t.Errorf("Дом at 12:00 = %v, want 15", got)
+		}
+	})
+
+	t.Run("small branches are merged", func(t *testing.T) {
+		var activities []db.Activity
+		var logs []db.ActivityLog
+		for i := range maxHourSeries + 2 {
+			id := int64(i + 1)
+			activities = append(activities, db.Activity{ID: id, ParentActivityID: -1, IsLeaf: true})
+			logs = append(logs, db.ActivityLog{
+				ActivityID:      id,
+				Timestamp:       time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC),
+				IntervalMinutes: int64(60 - i),
+			})
+		}
+
+		order, minutes := buildHourDistribution(logs, activityBranches(activities, -1), 1, time.UTC)
+
+		if len(order) != maxHourSeries+1 || order[maxHourSeries] != 0 {
+			t.Fatalf("order = %v, want %d branches and 0 for the rest", order, maxHourSeries)
+		}
+		var other float64
+		for _, value := range minutes[0] {
+			other += value
+		}
+		// Объединяются две самые маленькие ветки: 60-7 и 60-8 минут.
+		if want := float64(53 + 52); math.Abs(other-want) > 1e-9 {
+			t.Errorf("other branches total = %v, want %v", other, want)
+		}
+		if len(minutes) != maxHourSeries+1 {
+			t.Errorf("got %d series, want %d", len(minutes), maxHourSeries+1)
+		}
+	})
+}
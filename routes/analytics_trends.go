@@ -0,0 +1,339 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Единицы периодов трендов (callback data "trends__<единица> <число>").
+const (
+	trendWeeks  = "weeks"
+	trendMonths = "months"
+)
+
+// Варианты числа периодов в меню трендов.
+var (
+	trendWeekOptions  = []int{8, 12, 26}
+	trendMonthOptions = []int{6, 12}
+)
+
+// trendMovingAverageWindow — окно скользящего среднего в периодах. Сравнение для
+// растущих и снижающихся активностей идёт между двумя последними такими окнами.
+var trendMovingAverageWindow = map[string]int{trendWeeks: 4, trendMonths: 3}
+
+// maxTrendSeries — сколько корневых активностей показывать отдельно; остальные объединяются.
+const maxTrendSeries = 7
+
+// maxTrendChanges — сколько растущих и снижающихся активностей показывать в итогах.
+const maxTrendChanges = 3
+
+// minTrendChangeMinutes — изменение среднего за период меньше этого не считается заметным.
+const minTrendChangeMinutes = 30
+
+// TrendChartData — данные для скрипта generate_trend_chart.py.
+type TrendChartData struct {
+	Title  string `json:"title"`
+	YLabel string `json:"ylabel"`
+	// Periods — подписи периодов по порядку.
+	Periods []string          `json:"periods"`
+	Series  []TrendSeriesData `json:"series"`
+	// MovingAverage — скользящее среднее суммы часов; null, пока окно не заполнено.
+	MovingAverage      []*float64 `json:"moving_average"`
+	MovingAverageLabel string     `json:"moving_average_label"`
+}
+
+// TrendSeriesData — часы активности Name по периодам.
+type TrendSeriesData struct {
+	Name  string    `json:"name"`
+	Hours []float64 `json:"hours"`
+}
+
+// trendBounds возвращает границы count последних полных недель (с понедельника) или
+// месяцев до now по часовому поясу loc: count+1 моментов, период i — [bounds[i], bounds[i+1]).
+func trendBounds(unit string, count int, now time.Time, loc *time.Location) []time.Time {
+	today := startOfDay(now, loc)
+	end := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	if unit == trendMonths {
+		end = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+	}
+
+	bounds := make([]time.Time, count+1)
+	for i := range bounds {
+		if unit == trendMonths {
+			bounds[i] = end.AddDate(0, i-count, 0)
+		} else {
+			bounds[i] = end.AddDate(0, 0, 7*(i-count))
+		}
+	}
+	return bounds
+}
+
+// buildTrendSeries суммирует минуты логов по веткам branches и периодам bounds.
+// Ветки упорядочены по убыванию времени; после maxTrendSeries остальные объединяются
+// в ветку 0.
+func buildTrendSeries(
+	logs []db.ActivityLog, branches map[int64]int64, bounds []time.Time,
+) (order []int64, minutes map[int64][]float64) {
+	periods := len(bounds) - 1
+	minutes = make(map[int64][]float64)
+	totals := make(map[int64]float64)
+	for _, activityLog := range logs {
+		branch, ok := branches[activityLog.ActivityID]
+		if !ok {
+			continue
+		}
+		spreadByHour(activityLog, bounds[0].Location(), func(hour time.Time, value float64) {
+			period := sort.Search(len(bounds), func(i int) bool { return bounds[i].After(hour) }) - 1
+			if period < 0 || period >= periods {
+				return
+			}
+			if minutes[branch] == nil {
+				minutes[branch] = make([]float64, periods)
+				order = append(order, branch)
+			}
+			minutes[branch][period] += value
+			totals[branch] += value
+		})
+	}
+	sort.SliceStable(order, func(i, j int) bool { return totals[order[i]] > totals[order[j]] })
+
+	if len(order) > maxTrendSeries {
+		other := make([]float64, periods)
+		for _, branch := range order[maxTrendSeries:] {
+			for period, value := range minutes[branch] {
+				other[period] += value
+			}
+			delete(minutes, branch)
+		}
+		order = append(order[:maxTrendSeries], 0)
+		minutes[0] = other
+	}
+	return order, minutes
+}
+
+// movingAverage возвращает скользящее среднее values по окну window; первые window-1
+// значений не заданы.
+func movingAverage(values []float64, window int) []*float64 {
+	result := make([]*float64, len(values))
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= window {
+			sum -= values[i-window]
+		}
+		if i >= window-1 {
+			average := sum / float64(window)
+			result[i] = &average
+		}
+	}
+	return result
+}
+
+// trendChange — среднее за период в предыдущем и последнем окне сравнения.
+type trendChange struct {
+	name             string
+	previous, recent float64
+}
+
+// compareTrendWindows возвращает средние values за последние window периодов и за
+// window периодов перед ними.
+func compareTrendWindows(values []float64, window int) (previous, recent float64) {
+	n := len(values)
+	for i := n - window; i < n; i++ {
+		recent += values[i]
+	}
+	for i := n - 2*window; i < n-window; i++ {
+		previous += values[i]
+	}
+	return previous / float64(window), recent / float64(window)
+}
+
+// AnalyticsTrendsCallback показывает меню выбора периода для трендов.
+func (h *Handlers) AnalyticsTrendsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := h.langOf(common.UserID(callback.From.ID))
+	editConfig := tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID,
+		callback.Message.MessageID,
+		i18n.T(lang, "trends.menu"),
+		getTrendsKeyboardMarkup(lang),
+	)
+	editConfig.ParseMode = "Markdown"
+	h.sender.Send(editConfig)
+
+	answerConfig := tgbotapi.NewCallback(callback.ID, i18n.T(lang, "trends.menu_toast"))
+	h.sender.Request(answerConfig)
+}
+
+// TrendsCallback присылает график времени по корневым активностям за последние полные
+// недели или месяцы со скользящим средним и итогами: какие активности растут и снижаются.
+// Callback data: "trends__weeks <число>" или "trends__months <число>".
+func (h *Handlers) TrendsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var unit string
+	var count int
+	_, err := fmt.Sscanf(callback.Data, "trends__%s %d", &unit, &count)
+	if err != nil || !(unit == trendWeeks && slices.Contains(trendWeekOptions, count) ||
+		unit == trendMonths && slices.Contains(trendMonthOptions, count)) {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	bounds := trendBounds(unit, count, time.Now(), h.location())
+	user, activities, logs, ok := h.loadPatternsData(ctx, callback, bounds[0])
+	if !ok {
+		return
+	}
+	lang := userLang(*user)
+
+	order, minutes := buildTrendSeries(logs, activityBranches(activities, -1), bounds)
+	if len(order) == 0 {
+		h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(lang, "patterns.no_data")))
+		return
+	}
+
+	names := make(map[int64]string, len(activities))
+	for _, activity := range activities {
+		names[activity.ID] = activity.Name
+	}
+	names[0] = i18n.T(lang, "patterns.other")
+
+	totals := make([]float64, count)
+	series := make([]TrendSeriesData, 0, len(order))
+	for _, branch := range order {
+		hours := make([]float64, count)
+		for period, value := range minutes[branch] {
+			hours[period] = value / 60
+			totals[period] += value
+		}
+		series = append(series, TrendSeriesData{Name: names[branch], Hours: hours})
+	}
+
+	// Периоды до первого ответа не участвуют в сравнении, иначе всё выглядело бы растущим с нуля.
+	history := count - slices.IndexFunc(totals, func(value float64) bool { return value > 0 })
+	window := min(trendMovingAverageWindow[unit], history/2)
+	changes := make([]trendChange, 0, len(order))
+	if window > 0 {
+		for _, branch := range order {
+			previous, recent := compareTrendWindows(minutes[branch], window)
+			changes = append(changes, trendChange{name: names[branch], previous: previous, recent: recent})
+		}
+	}
+
+	totalHours := make([]float64, count)
+	for period, value := range totals {
+		totalHours[period] = value / 60
+	}
+	periodLabels := make([]string, count)
+	for period := range periodLabels {
+		periodLabels[period] = trendPeriodLabel(lang, unit, bounds[period])
+	}
+
+	first := bounds[0].Format(i18n.T(lang, "format.date"))
+	last := bounds[count].AddDate(0, 0, -1).Format(i18n.T(lang, "format.date"))
+	data := TrendChartData{
+		Title:              i18n.T(lang, "trends.title", first, last),
+		YLabel:             i18n.T(lang, "patterns.hours_unit"),
+		Periods:            periodLabels,
+		Series:             series,
+		MovingAverage:      movingAverage(totalHours, trendMovingAverageWindow[unit]),
+		MovingAverageLabel: i18n.T(lang, "trends.moving_average", trendMovingAverageWindow[unit]),
+	}
+
+	caption := i18n.T(lang, "trends.short_history", first, last)
+	if window > 0 {
+		var total trendChange
+		total.previous, total.recent = compareTrendWindows(totals, window)
+		caption = formatTrendSummary(lang, unit, first, last, window, total, changes)
+	}
+	h.sendPatternsChart(ctx, callback, *user, "generate_trend_chart.py", data, caption, nil)
+}
+
+// trendPeriodLabel возвращает подпись периода, начинающегося в start: дату начала
+// недели или месяц с годом.
+func trendPeriodLabel(lang i18n.Lang, unit string, start time.Time) string {
+	if unit == trendMonths {
+		return i18n.T(lang, "trends.month_label", i18n.T(lang, fmt.Sprintf("month.%d", start.Month())), start.Year())
+	}
+	return start.Format(i18n.T(lang, "format.day_month"))
+}
+
+// formatTrendSummary описывает итоги трендов: общее время и активности, среднее
+// время которых за последнее окно заметнее всего выросло или снизилось.
+func formatTrendSummary(
+	lang i18n.Lang, unit, first, last string, window int, total trendChange, changes []trendChange,
+) string {
+	per := i18n.T(lang, "trends.per_"+unit)
+	result := i18n.T(lang, "trends.summary", first, last,
+		formatMinutes(lang, int64(math.Round(total.recent))), per,
+		formatMinutes(lang, int64(math.Round(total.previous))), window, i18n.T(lang, "trends.unit_"+unit))
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].recent-changes[i].previous > changes[j].recent-changes[j].previous
+	})
+	var risers, fallers string
+	for i := 0; i < min(len(changes), maxTrendChanges); i++ {
+		if changes[i].recent-changes[i].previous >= minTrendChangeMinutes {
+			risers += formatTrendChange(lang, changes[i], per)
+		}
+	}
+	for i := len(changes) - 1; i >= max(len(changes)-maxTrendChanges, 0); i-- {
+		if changes[i].previous-changes[i].recent >= minTrendChangeMinutes {
+			fallers += formatTrendChange(lang, changes[i], per)
+		}
+	}
+
+	if risers == "" && fallers == "" {
+		return result + i18n.T(lang, "trends.no_changes")
+	}
+	if risers != "" {
+		result += i18n.T(lang, "trends.risers") + risers
+	}
+	if fallers != "" {
+		result += i18n.T(lang, "trends.fallers") + fallers
+	}
+	return result
+}
+
+func formatTrendChange(lang i18n.Lang, change trendChange, per string) string {
+	difference := int64(math.Round(change.recent - change.previous))
+	sign := "+"
+	if difference < 0 {
+		sign = "−"
+		difference = -difference
+	}
+	return i18n.T(lang, "trends.change",
+		change.name,
+		formatMinutes(lang, int64(math.Round(change.previous))),
+		formatMinutes(lang, int64(math.Round(change.recent))),
+		sign, formatMinutes(lang, difference), per)
+}
+
+func getTrendsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	weeks := make([]tgbotapi.InlineKeyboardButton, 0, len(trendWeekOptions))
+	for _, count := range trendWeekOptions {
+		weeks = append(weeks, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "trends.button_weeks", count), fmt.Sprintf("trends__%s %d", trendWeeks, count)))
+	}
+	months := make([]tgbotapi.InlineKeyboardButton, 0, len(trendMonthOptions))
+	for _, count := range trendMonthOptions {
+		months = append(months, tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "trends.button_months", count), fmt.Sprintf("trends__%s %d", trendMonths, count)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		weeks,
+		months,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back"), "analytics__back"),
+		),
+	)
+}
@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"TimeCounterBot/db"
+)
+
+func TestTrendBounds(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, moscow)
+	}
+
+	tests := []struct {
+		name  string
+		unit  string
+		count int
+		now   time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "weeks from wednesday",
+			unit:  trendWeeks,
+			count: 2,
+			now:   time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+			want:  []time.Time{date(2024, 4, 29), date(2024, 5, 6), date(2024, 5, 13)},
+		},
+		{
+			name:  "weeks from sunday",
+			unit:  trendWeeks,
+			count: 1,
+			now:   time.Date(2024, 5, 19, 20, 0, 0, 0, time.UTC),
+			want:  []time.Time{date(2024, 5, 6), date(2024, 5, 13)},
+		},
+		{
+			// Воскресенье 22:00 UTC — уже понедельник по Москве, текущая неделя не включается.
+			name:  "monday in the bot zone",
+			unit:  trendWeeks,
+			count: 1,
+			now:   time.Date(2024, 5, 19, 22, 0, 0, 0, time.UTC),
+			want:  []time.Time{date(2024, 5, 13), date(2024, 5, 20)},
+		},
+		{
+			name:  "months across a year",
+			unit:  trendMonths,
+			count: 3,
+			now:   time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC),
+			want:  []time.Time{date(2023, 11, 1), date(2023, 12, 1), date(2024, 1, 1), date(2024, 2, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trendBounds(tt.unit, tt.count, tt.now, moscow)
+			if len(got) != len(tt.want) {
+				t.Fatalf("trendBounds() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("bounds[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTrendSeries(t *testing.T) {
+	bounds := []time.Time{
+		time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC),
+	}
+	logs := []db.ActivityLog{
+		{ActivityID: 2, Timestamp: time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC), IntervalMinutes: 60},
+		// Половина лога приходится на вторую неделю, половина — на первую.
+		{ActivityID: 4, Timestamp: time.Date(2024, 5, 13, 0, 30, 0, 0, time.UTC), IntervalMinutes: 60},
+		{ActivityID: 6, Timestamp: time.Date(2024, 5, 14, 12, 0, 0, 0, time.UTC), IntervalMinutes: 30},
+		// До первой недели.
+		{ActivityID: 6, Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), IntervalMinutes: 600},
+	}
+
+	order, minutes := buildTrendSeries(logs, activityBranches(testActivities, -1), bounds)
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 5 {
+		t.Fatalf("order = %v, want [1 5]", order)
+	}
+	if got := minutes[1]; len(got) != 2 || got[0] != 90 || got[1] != 30 {
+		t.Errorf("Работа = %v, want [90 30]", got)
+	}
+	if got := minutes[5]; len(got) != 2 || got[0] != 0 || got[1] != 30 {
+		t.Errorf("Дом = %v, want [0 30]", got)
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		want   []float64 // -1 — значение не задано
+	}{
+		{name: "window of one", values: []float64{1, 2, 3}, window: 1, want: []float64{1, 2, 3}},
+		{name: "window of three", values: []float64{3, 6, 9, 0, 3}, window: 3, want: []float64{-1, -1, 6, 5, 4}},
+		{name: "shorter than window", values: []float64{1, 2}, window: 4, want: []float64{-1, -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := movingAverage(tt.values, tt.window)
+			if len(got) != len(tt.want) {
+				t.Fatalf("movingAverage() returned %d values, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				switch {
+				case want < 0 && got[i] != nil:
+					t.Errorf("value %d = %v, want unset", i, *got[i])
+				case want >= 0 && (got[i] == nil || *got[i] != want):
+					t.Errorf("value %d = %v, want %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareTrendWindows(t *testing.T) {
+	tests := []struct {
+		name             string
+		values           []float64
+		window           int
+		previous, recent float64
+	}{
+		{name: "growth", values: []float64{60, 120}, window: 1, previous: 60, recent: 120},
+		{name: "older periods ignored", values: []float64{999, 10, 20, 30, 50}, window: 2, previous: 15, recent: 40},
+		{name: "decline", values: []float64{90, 30, 0, 0}, window: 2, previous: 60, recent: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous, recent := compareTrendWindows(tt.values, tt.window)
+			if previous != tt.previous || recent != tt.recent {
+				t.Errorf("compareTrendWindows() = %v, %v, want %v, %v", previous, recent, tt.previous, tt.recent)
+			}
+		})
+	}
+}
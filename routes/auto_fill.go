@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// autoFillWindow — насколько старые пропуски ещё заполняются автоматически.
+const autoFillWindow = 2 * Day
+
+// AutoFillMissedPrompts раз в AutoFillInterval заполняет пропущенные уведомления
+// (на которые не ответили до следующего) по правилу каждого пользователя.
+// Возвращается после отмены ctx.
+func (h *Handlers) AutoFillMissedPrompts(ctx context.Context) {
+	ticker := time.NewTicker(h.settings.AutoFillInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		users, err := h.users.GetUsers()
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка получения списка пользователей", "err", err)
+			continue
+		}
+		now := time.Now()
+		for _, user := range users {
+			h.autoFillUser(logging.WithUserID(ctx, user.ID), user, now)
+		}
+	}
+}
+
+// autoFillUser заполняет пропущенные уведомления пользователя и показывает
+// под каждым из них, что было записано.
+func (h *Handlers) autoFillUser(ctx context.Context, user db.User, now time.Time) {
+	if user.UnansweredPolicy != db.UnansweredFallback && user.UnansweredPolicy != db.UnansweredSameAsNext {
+		return
+	}
+	if user.UnansweredPolicy == db.UnansweredFallback && !user.FallbackActivityID.Valid {
+		return
+	}
+
+	prompts, err := h.logs.GetMissedPrompts(user.ID, now.Add(-autoFillWindow))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пропущенных уведомлений", "err", err)
+		return
+	}
+
+	for _, prompt := range prompts {
+		activityID := user.FallbackActivityID.Int64
+		if user.UnansweredPolicy == db.UnansweredSameAsNext {
+			var ok bool
+			activityID, ok = h.nextAnsweredActivity(ctx, user.ID, prompt.SentAt, now)
+			if !ok {
+				// Следующего ответа ещё нет — для более поздних пропусков тоже.
+				return
+			}
+		}
+
+		activityName, err := h.activities.GetFullActivityNameByID(activityID, user.ID)
+		if err != nil {
+			slog.WarnContext(ctx, "Активность для заполнения пропусков не найдена",
+				"activity_id", activityID, "err", err)
+			return
+		}
+
+		if err := h.logs.AutoFillPrompt(prompt, activityID, now); err != nil {
+			slog.ErrorContext(ctx, "Ошибка заполнения пропущенного уведомления", "err", err)
+			return
+		}
+		metrics.AutoFilledPrompts.WithLabelValues(user.UnansweredPolicy).Inc()
+
+		_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+			int64(prompt.ChatID), int(prompt.MessageID),
+			i18n.T(userLang(user), "unanswered.auto_filled", activityName),
+			getSavedActivityKeyboardMarkup(userLang(user)),
+		))
+		if err != nil {
+			slog.WarnContext(ctx, "Не удалось обновить пропущенное уведомление", "err", err)
+		}
+	}
+}
+
+// nextAnsweredActivity возвращает активность первого ответа, записанного после sentAt.
+func (h *Handlers) nextAnsweredActivity(
+	ctx context.Context, userID common.UserID, sentAt, now time.Time,
+) (int64, bool) {
+	logs, err := h.logs.GetActivityLogs(userID, sentAt, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения логов активностей", "err", err)
+		return 0, false
+	}
+	idx := slices.IndexFunc(logs, func(l db.ActivityLog) bool { return l.Timestamp.After(sentAt) })
+	if idx == -1 {
+		return 0, false
+	}
+	return logs[idx].ActivityID, true
+}
+
+// UnansweredCommand обрабатывает команду /unanswered: выбор правила для пропущенных уведомлений.
+func (h *Handlers) UnansweredCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), h.unansweredPolicyText(*user))
+	msgconf.ReplyMarkup = getUnansweredPolicyKeyboardMarkup(userLang(*user))
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// UnansweredSetCallback сохраняет правило, для которого не нужна активность.
+func (h *Handlers) UnansweredSetCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var policy string
+	_, err := fmt.Sscanf(callback.Data, "unanswered__set %s", &policy)
+	if err != nil || (policy != db.UnansweredLeaveEmpty && policy != db.UnansweredSameAsNext) {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	user.UnansweredPolicy = policy
+	if err := h.users.UpdateUserColumns(user.ID, unansweredColumns(*user)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		h.unansweredPolicyText(*user), getUnansweredPolicyKeyboardMarkup(userLang(*user))))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// UnansweredFallbackCallback показывает дерево активностей и сохраняет выбранный лист
+// активностью для заполнения пропусков. Callback data: "unanswered__fallback <node_id> <timer>".
+func (h *Handlers) UnansweredFallbackCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	nodeID := int64(-1)
+	var timerMinutes int64
+	if callback.Data != "unanswered__fallback" {
+		_, err := fmt.Sscanf(callback.Data, "unanswered__fallback %d %d", &nodeID, &timerMinutes)
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+			return
+		}
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	isLeaf := false
+	if nodeID != -1 {
+		activities, err := h.activities.GetSimpleActivities(user.ID, nil, nil)
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+			return
+		}
+		idx := slices.IndexFunc(activities, func(a db.Activity) bool { return a.ID == nodeID })
+		if idx == -1 {
+			slog.ErrorContext(ctx, "Активность не найдена среди активностей пользователя", "activity_id", nodeID)
+			return
+		}
+		isLeaf = activities[idx].IsLeaf
+	}
+
+	if !isLeaf {
+		keyboard := h.buildActivitiesKeyboardMarkupForUser(
+			ctx, *user, nodeID, nil, nil, "unanswered__fallback",
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				i18n.T(userLang(*user), "button.back"), "unanswered__back")))
+		_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+			callback.Message.Chat.ID, callback.Message.MessageID,
+			i18n.T(userLang(*user), "unanswered.choose_fallback"), keyboard))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+		}
+		return
+	}
+
+	user.UnansweredPolicy = db.UnansweredFallback
+	user.FallbackActivityID = sql.NullInt64{Int64: nodeID, Valid: true}
+	if err := h.users.UpdateUserColumns(user.ID, unansweredColumns(*user)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		h.unansweredPolicyText(*user), getUnansweredPolicyKeyboardMarkup(userLang(*user))))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// unansweredColumns возвращает столбцы правила для пропущенных уведомлений. Пишем
+// только их: строку пользователя одновременно меняют рассылка и автозаполнение.
+func unansweredColumns(user db.User) map[string]any {
+	return map[string]any{
+		"unanswered_policy":    user.UnansweredPolicy,
+		"fallback_activity_id": user.FallbackActivityID,
+	}
+}
+
+// UnansweredBackCallback возвращает выбор правила из дерева активностей.
+func (h *Handlers) UnansweredBackCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		h.unansweredPolicyText(*user), getUnansweredPolicyKeyboardMarkup(userLang(*user))))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// unansweredPolicyText описывает текущее правило пользователя для пропущенных уведомлений.
+func (h *Handlers) unansweredPolicyText(user db.User) string {
+	lang := userLang(user)
+	current := i18n.T(lang, "unanswered.current_leave_empty")
+	switch user.UnansweredPolicy {
+	case db.UnansweredSameAsNext:
+		current = i18n.T(lang, "unanswered.current_same_as_next")
+	case db.UnansweredFallback:
+		name, err := h.activities.GetFullActivityNameByID(user.FallbackActivityID.Int64, user.ID)
+		if err != nil {
+			name = i18n.T(lang, "unanswered.deleted_activity")
+		}
+		current = i18n.T(lang, "unanswered.current_fallback", name)
+	}
+	return i18n.T(lang, "unanswered.policy", current)
+}
+
+func getUnansweredPolicyKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "unanswered.button_leave_empty"), "unanswered__set "+db.UnansweredLeaveEmpty)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "unanswered.button_fallback"), "unanswered__fallback")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.T(lang, "unanswered.button_same_as_next"), "unanswered__set "+db.UnansweredSameAsNext)),
+	)
+}
@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestAutoFillUser(t *testing.T) {
+	const userID common.UserID = 3
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   string
+		fallback bool
+		// wantActivity — путь активности, которой заполнится пропуск; пусто — не заполнится.
+		wantActivity string
+	}{
+		{name: "leave empty", policy: db.UnansweredLeaveEmpty},
+		{name: "fallback", policy: db.UnansweredFallback, fallback: true, wantActivity: "Прочее / Неизвестно"},
+		{name: "fallback without activity", policy: db.UnansweredFallback},
+		{name: "same as next", policy: db.UnansweredSameAsNext, wantActivity: "Работа / Код"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			h, repo, sender := newTestHandlers(t)
+
+			if err := repo.AddUser(db.User{ID: userID, ChatID: 3, Language: string(i18n.Russian)}); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+			activityIDs := make(map[string]int64)
+			for _, path := range []string{"Работа / Код", "Прочее / Неизвестно"} {
+				id, err := repo.FindOrAddActivityPath(userID, path)
+				if err != nil {
+					t.Fatalf("FindOrAddActivityPath(%q): %v", path, err)
+				}
+				activityIDs[path] = id
+			}
+			user, err := repo.GetUserByID(userID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			user.UnansweredPolicy = tt.policy
+			if tt.fallback {
+				user.FallbackActivityID = sql.NullInt64{Int64: activityIDs["Прочее / Неизвестно"], Valid: true}
+			}
+			if err := repo.UpdateUser(*user); err != nil {
+				t.Fatalf("UpdateUser: %v", err)
+			}
+
+			// Пропуск, ответ на следующее уведомление и последнее уведомление, ещё ждущее ответа.
+			for i, sentAt := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour), now.Add(-time.Minute)} {
+				prompt := db.Prompt{MessageID: int64(i + 1), UserID: userID, ChatID: 3, SentAt: sentAt, IntervalMinutes: 60}
+				if err := repo.AddPrompt(prompt); err != nil {
+					t.Fatalf("AddPrompt: %v", err)
+				}
+			}
+			err = repo.AddActivityLog(db.ActivityLog{
+				MessageID: 2, UserID: int64(userID), ActivityID: activityIDs["Работа / Код"],
+				Timestamp: now.Add(-time.Hour), IntervalMinutes: 60,
+			})
+			if err != nil {
+				t.Fatalf("AddActivityLog: %v", err)
+			}
+
+			// Второй проход не должен заполнять уже заполненное.
+			h.autoFillUser(ctx, *user, now)
+			h.autoFillUser(ctx, *user, now)
+
+			slots, err := repo.GetTimeline(userID, now.Add(-3*time.Hour), now)
+			if err != nil {
+				t.Fatalf("GetTimeline: %v", err)
+			}
+			if len(slots) != 3 {
+				t.Fatalf("got %d slots, want 3: %+v", len(slots), slots)
+			}
+			missed := slots[0]
+			if tt.wantActivity == "" {
+				if missed.Answered {
+					t.Errorf("missed prompt was filled with %q", missed.ActivityName)
+				}
+				if len(sender.Sent()) != 0 {
+					t.Errorf("sent %d messages, want none", len(sender.Sent()))
+				}
+			} else {
+				if missed.ActivityName != tt.wantActivity || missed.IntervalMinutes != 60 {
+					t.Errorf("missed prompt = %+v, want 60 minutes of %q", missed, tt.wantActivity)
+				}
+				if len(sender.Sent()) != 1 {
+					t.Fatalf("sent %d messages, want 1", len(sender.Sent()))
+				}
+				edit := lastEdit(t, sender)
+				if want := i18n.T(i18n.Russian, "unanswered.auto_filled", tt.wantActivity); edit.Text != want {
+					t.Errorf("edited text = %q, want %q", edit.Text, want)
+				}
+				if edit.MessageID != 1 {
+					t.Errorf("edited message %d, want 1", edit.MessageID)
+				}
+			}
+			if slots[2].Answered {
+				t.Errorf("the latest prompt was filled: %+v", slots[2])
+			}
+		})
+	}
+}
+
+func TestUnansweredPolicyCallbacks(t *testing.T) {
+	const userID common.UserID = 4
+	ctx := context.Background()
+	h, repo, _, users := newColumnTestHandlers(t)
+	if err := repo.AddUser(db.User{ID: userID, ChatID: 4, Language: string(i18n.Russian)}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	fallbackID, err := repo.FindOrAddActivityPath(userID, "Прочее / Неизвестно")
+	if err != nil {
+		t.Fatalf("FindOrAddActivityPath: %v", err)
+	}
+	callback := func(data string) *tgbotapi.CallbackQuery {
+		return &tgbotapi.CallbackQuery{
+			ID:      "1",
+			From:    &tgbotapi.User{ID: int64(userID)},
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: int64(userID)}},
+			Data:    data,
+		}
+	}
+
+	tests := []struct {
+		data         string
+		wantPolicy   string
+		wantFallback sql.NullInt64
+		wantColumns  []string
+	}{
+		{data: "unanswered__set next", wantPolicy: db.UnansweredSameAsNext},
+		{data: "unanswered__set sideways", wantPolicy: db.UnansweredSameAsNext, wantColumns: []string{}},
+		{
+			data:         fmt.Sprintf("unanswered__fallback %d 0", fallbackID),
+			wantPolicy:   db.UnansweredFallback,
+			wantFallback: sql.NullInt64{Int64: fallbackID, Valid: true},
+		},
+		{
+			data:         "unanswered__set empty",
+			wantPolicy:   db.UnansweredLeaveEmpty,
+			wantFallback: sql.NullInt64{Int64: fallbackID, Valid: true},
+		},
+	}
+
+	for _, tt := range tests {
+		users.columns = nil
+		if strings.HasPrefix(tt.data, "unanswered__set") {
+			h.UnansweredSetCallback(ctx, callback(tt.data))
+		} else {
+			h.UnansweredFallbackCallback(ctx, callback(tt.data))
+		}
+
+		user, err := repo.GetUserByID(userID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if user.UnansweredPolicy != tt.wantPolicy || user.FallbackActivityID != tt.wantFallback {
+			t.Errorf("%s: policy %q, fallback %v, want %q, %v",
+				tt.data, user.UnansweredPolicy, user.FallbackActivityID, tt.wantPolicy, tt.wantFallback)
+		}
+		wantColumns := tt.wantColumns
+		if wantColumns == nil {
+			wantColumns = []string{"fallback_activity_id", "unanswered_policy"}
+		}
+		if !slices.Equal(users.columns, wantColumns) {
+			t.Errorf("%s: wrote columns %v, want %v", tt.data, users.columns, wantColumns)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"TimeCounterBot/csvimport"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxImportSummaryProjects — сколько проектов показывать в итогах импорта.
+const maxImportSummaryProjects = 10
+
+// processTimeEntriesImport импортирует CSV-экспорт Toggl/Clockify в логи активностей.
+// Время в экспорте записано без часового пояса и читается по часовому поясу бота.
+func (h *Handlers) processTimeEntriesImport(ctx context.Context, user db.User, data []byte) {
+	lang := userLang(user)
+	format, entries, err := csvimport.Parse(data, h.location())
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора CSV", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "csv_import.parse_error", err)))
+		return
+	}
+
+	summary, err := h.logs.ImportTimeEntries(user.ID, entries, i18n.T(lang, "csv_import.no_project"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка импорта записей", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "csv_import.error", err)))
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), formatTimeEntriesImportSummary(lang, format, summary)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// formatTimeEntriesImportSummary форматирует итоги импорта CSV.
+func formatTimeEntriesImportSummary(lang i18n.Lang, format string, summary *db.TimeEntriesImportSummary) string {
+	result := i18n.T(lang, "csv_import.summary",
+		format,
+		summary.EntriesImported, formatMinutes(lang, summary.MinutesImported),
+		summary.EntriesSkipped,
+		summary.ActivitiesCreated,
+	)
+
+	if len(summary.ProjectMinutes) == 0 {
+		return result
+	}
+
+	projects := make([]string, 0, len(summary.ProjectMinutes))
+	for project := range summary.ProjectMinutes {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return summary.ProjectMinutes[projects[i]] > summary.ProjectMinutes[projects[j]]
+	})
+
+	result += i18n.T(lang, "csv_import.by_project")
+	for i, project := range projects {
+		if i >= maxImportSummaryProjects {
+			result += i18n.T(lang, "csv_import.more_projects", len(projects)-maxImportSummaryProjects)
+			break
+		}
+		result += fmt.Sprintf("• %s: %s\n", project, formatMinutes(lang, summary.ProjectMinutes[project]))
+	}
+	return result
+}
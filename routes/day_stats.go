@@ -3,9 +3,12 @@ package routes
 import (
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
-	tg "TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/tg/bot"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
@@ -14,128 +17,180 @@ import (
 )
 
 const Day = time.Duration(24) * time.Hour
-const DayStatsWaitDuration = 5 * time.Second
 
-func TestDayStatsRoutine(message *tgbotapi.Message) {
-	user, err := db.GetUserByID(common.UserID(message.From.ID))
+func (h *Handlers) TestDayStatsRoutine(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
-	startDayStatsRoutine(*user)
+	h.scheduleDayStats(ctx, *user, time.Now())
 }
 
-func startDayStatsRoutine(user db.User) {
-	time.Sleep(DayStatsWaitDuration)
-	msgconf := tgbotapi.NewMessage(int64(user.ChatID), "Если заполнил все активности за сегодня - ЖМИ НА КНОПКУ!")
-	msgconf.ReplyMarkup = buildDayStatsRoutineKeyboardMarkup()
+// scheduleDayStats откладывает на DayStatsWaitDuration предложение посмотреть итоги дня.
+// Сообщение сохраняется в базе и отправляется диспетчером, поэтому переживает перезапуск бота.
+func (h *Handlers) scheduleDayStats(ctx context.Context, user db.User, now time.Time) {
+	err := h.scheduled.AddScheduledMessage(db.ScheduledMessage{
+		UserID:    user.ID,
+		ChatID:    user.ChatID,
+		Kind:      db.ScheduledMessageDayStats,
+		SendAt:    now.Add(h.settings.DayStatsWaitDuration),
+		CreatedAt: now,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения отложенного сообщения", "err", err)
+	}
+}
+
+// maxScheduledMessageAttempts — сколько раз пытаться отправить отложенное сообщение,
+// прежде чем отказаться от него.
+const maxScheduledMessageAttempts = 5
 
-	_, err := tg.Bot.Send(msgconf)
+// sendDueScheduledMessages отправляет отложенные сообщения, время которых наступило.
+// После временного сбоя сообщение остаётся в базе и отправляется на следующем проходе
+// диспетчера. Если Telegram отклонил сообщение (bot.IsPermanent) или попытки
+// закончились, сообщение удаляется: повтор ничего не изменит.
+func (h *Handlers) sendDueScheduledMessages(ctx context.Context, now time.Time) {
+	messages, err := h.scheduled.GetDueScheduledMessages(now)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения отложенных сообщений", "err", err)
+		return
+	}
+
+	for _, message := range messages {
+		ctx := logging.WithUserID(ctx, message.UserID)
+		if err := h.sendScheduledMessage(ctx, message); err != nil {
+			attempts := message.Attempts + 1
+			if !bot.IsPermanent(err) && attempts < maxScheduledMessageAttempts {
+				slog.ErrorContext(ctx, "Ошибка отправки отложенного сообщения", "err", err, "attempt", attempts)
+				if err := h.scheduled.IncrementScheduledMessageAttempts(message.ID); err != nil {
+					slog.ErrorContext(ctx, "Ошибка сохранения попытки отправки", "err", err)
+				}
+				continue
+			}
+			slog.ErrorContext(ctx, "Отложенное сообщение не отправлено и удалено",
+				"err", err, "kind", message.Kind, "attempts", attempts)
+		}
+
+		if err := h.scheduled.DeleteScheduledMessage(message.ID); err != nil {
+			slog.ErrorContext(ctx, "Ошибка удаления отложенного сообщения", "err", err)
+		}
 	}
 }
 
-func buildDayStatsRoutineKeyboardMarkup() tgbotapi.InlineKeyboardMarkup {
+func buildDayStatsRoutineKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
 	rows := make([][]tgbotapi.InlineKeyboardButton, 1)
 	rows[0] = make([]tgbotapi.InlineKeyboardButton, 1)
 
 	now := time.Now()
-	log.Println("buildDayStatsRoutineKeyboardMarkup: ", now)
+	slog.Debug("Построение клавиатуры итогов дня", "now", now)
 	callbackData := fmt.Sprintf(
 		"day_stats__send_chart %d %d",
 		now.Add(-Day).Unix(),
 		now.Unix(),
 	)
 	rows[0][0] = tgbotapi.InlineKeyboardButton{
-		Text:         "Кнопка",
+		Text:         i18n.T(lang, "day_stats.button"),
 		CallbackData: &callbackData,
 	}
 
 	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
 }
 
-func SendDayStatsRoutineCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) SendDayStatsRoutineCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
 	var tsStartUnix int64
 	var tsEndUnix int64
 	_, err = fmt.Sscanf(callback.Data, "day_stats__send_chart %d %d", &tsStartUnix, &tsEndUnix)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 	tsStart := time.Unix(tsStartUnix, 0)
 	tsEnd := time.Unix(tsEndUnix, 0)
 
-	data := getUserActivityDataForInterval(*user, tsStart, tsEnd)
+	data, err := h.getUserActivityDataForInterval(*user, tsStart, tsEnd)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения данных для диаграммы", "err", err)
+		return
+	}
 
 	outputFile := fmt.Sprintf("sunburst_chart_%d_%d.png", user.ID, callback.Message.MessageID)
-	generateActivityChart(data, outputFile)
+	if err := h.generateActivityChart(ctx, data, outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы", "err", err)
+		return
+	}
+	defer removeChartFile(ctx, outputFile)
 
 	// Отправляем картинку в Telegram
 	msgconf := tgbotapi.NewPhoto(int64(user.ChatID), tgbotapi.FilePath(outputFile))
-	msgconf.Caption = fmt.Sprintf(
-		"Диаграмма активности за сегодняшний день (сделал в %s за интервал [%s, %s]).",
-		time.Now().Format(time.RFC3339), tsStart, tsEnd,
-	)
+	lang := userLang(*user)
+	msgconf.Caption = i18n.T(lang, "day_stats.caption", time.Now().Format(time.RFC3339), tsStart, tsEnd)
 
 	msgconf.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				"\U0001F504 Refresh chart",
+				i18n.T(lang, "day_stats.refresh"),
 				fmt.Sprintf("day_stats__refresh_chart %d %d", tsStartUnix, tsEndUnix)),
 		),
 	)
 
-	_, err = tg.Bot.Send(msgconf)
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Fatalf("❌ Ошибка отправки изображения: %v", err)
+		slog.ErrorContext(ctx, "Ошибка отправки изображения", "err", err)
+		return
 	}
 
-	_, err = tg.Bot.Send(tgbotapi.NewDeleteMessage(int64(user.ChatID), callback.Message.MessageID))
+	_, err = h.sender.Send(tgbotapi.NewDeleteMessage(int64(user.ChatID), callback.Message.MessageID))
 	if err != nil && !strings.Contains(err.Error(), "cannot unmarshal bool into Go value of type tgbotapi.Message") {
-		log.Fatal(err)
-	}
-
-	err = os.Remove(outputFile)
-	if err != nil {
-		log.Println("Couldn't delete output file: ", err)
+		slog.ErrorContext(ctx, "Ошибка удаления сообщения", "err", err)
 	}
 }
 
-func RefreshDayStatsChartCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) RefreshDayStatsChartCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
 	var tsStartUnix int64
 	var tsEndUnix int64
 	_, err = fmt.Sscanf(callback.Data, "day_stats__refresh_chart %d %d", &tsStartUnix, &tsEndUnix)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 	tsStart := time.Unix(tsStartUnix, 0)
 	tsEnd := time.Unix(tsEndUnix, 0)
 
-	data := getUserActivityDataForInterval(*user, tsStart, tsEnd)
+	data, err := h.getUserActivityDataForInterval(*user, tsStart, tsEnd)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения данных для диаграммы", "err", err)
+		return
+	}
 
 	outputFile := fmt.Sprintf("sunburst_chart_%d_%d.png", user.ID, callback.Message.MessageID)
-	generateActivityChart(data, outputFile)
+	if err := h.generateActivityChart(ctx, data, outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы", "err", err)
+		return
+	}
+	defer removeChartFile(ctx, outputFile)
 
 	// Отправляем картинку в Telegram
 	newPhoto := tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(outputFile))
-	newPhoto.Caption = fmt.Sprintf(
-		"Диаграмма активности за сегодняшний день (сделал в %s за интервал [%s, %s]).",
-		time.Now().Format(time.RFC3339), tsStart, tsEnd,
-	)
+	lang := userLang(*user)
+	newPhoto.Caption = i18n.T(lang, "day_stats.caption", time.Now().Format(time.RFC3339), tsStart, tsEnd)
 
 	newKeyboardMarkup := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				"\U0001F504 Refresh chart",
+				i18n.T(lang, "day_stats.refresh"),
 				fmt.Sprintf("day_stats__refresh_chart %d %d", tsStartUnix, tsEndUnix)),
 		),
 	)
@@ -149,13 +204,52 @@ func RefreshDayStatsChartCallback(callback *tgbotapi.CallbackQuery) {
 		Media: newPhoto,
 	}
 
-	_, err = tg.Bot.Send(editMedia)
+	_, err = h.sender.Send(editMedia)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
 	}
+}
 
-	err = os.Remove(outputFile)
+// sendScheduledMessage отправляет одно отложенное сообщение в текущий чат пользователя.
+// Сообщения пользователям, до которых доставка отключена, и сообщения неизвестного
+// вида не отправляются и не считаются ошибкой — повторять их бессмысленно.
+func (h *Handlers) sendScheduledMessage(ctx context.Context, message db.ScheduledMessage) error {
+	user, err := h.users.GetUserByID(message.UserID)
 	if err != nil {
-		log.Println("Couldn't delete output file: ", err)
+		return err
+	}
+	if user.DeliveryDisabledReason.Valid {
+		return nil
+	}
+
+	var msgconf tgbotapi.MessageConfig
+	switch message.Kind {
+	case db.ScheduledMessageDayStats:
+		// Выходной могли добавить уже после того, как итоги дня были запланированы.
+		dayOff, err := h.users.IsDayOff(user.ID, message.CreatedAt.In(h.location()))
+		if err != nil {
+			return err
+		}
+		if dayOff {
+			return nil
+		}
+		msgconf = tgbotapi.NewMessage(int64(user.ChatID), i18n.T(userLang(*user), "day_stats.prompt"))
+		msgconf.ReplyMarkup = buildDayStatsRoutineKeyboardMarkup(userLang(*user))
+	default:
+		slog.WarnContext(ctx, "Неизвестный вид отложенного сообщения", "kind", message.Kind)
+		return nil
+	}
+
+	_, err = h.sendToUser(ctx, user, msgconf)
+	if user.DeliveryDisabledReason.Valid {
+		return nil
+	}
+	return err
+}
+
+// removeChartFile удаляет временный файл диаграммы после отправки.
+func removeChartFile(ctx context.Context, path string) {
+	if err := os.Remove(path); err != nil {
+		slog.WarnContext(ctx, "Не удалось удалить временный файл диаграммы", "path", path, "err", err)
 	}
 }
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSendDueScheduledMessages(t *testing.T) {
+	const userID common.UserID = 7
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		attempts     int64
+		errs         []error
+		wantSent     int
+		wantKept     bool
+		wantAttempts int64
+	}{
+		{name: "delivered", wantSent: 1},
+		{
+			name:         "server error",
+			errs:         []error{&tgbotapi.Error{Code: 502, Message: "Bad Gateway"}},
+			wantKept:     true,
+			wantAttempts: 1,
+		},
+		{
+			name:         "network error",
+			attempts:     2,
+			errs:         []error{errors.New("connection reset")},
+			wantKept:     true,
+			wantAttempts: 3,
+		},
+		{
+			name: "bad request",
+			errs: []error{&tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities"}},
+		},
+		{
+			name: "blocked",
+			errs: []error{&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}},
+		},
+		{
+			name:     "last attempt",
+			attempts: maxScheduledMessageAttempts - 1,
+			errs:     []error{&tgbotapi.Error{Code: 502, Message: "Bad Gateway"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := db.NewMemoryRepository()
+			if err != nil {
+				t.Fatalf("NewMemoryRepository: %v", err)
+			}
+			sender := &failingSender{FakeSender: bot.NewFakeSender(), errs: tt.errs}
+			settings := Settings{DayStatsWaitDuration: time.Minute, Location: time.UTC}
+			h := NewHandlers(repo, repo, repo, repo, sender, settings)
+
+			if err := repo.AddUser(db.User{ID: userID, ChatID: 7, TimerEnabled: true}); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+			err = repo.AddScheduledMessage(db.ScheduledMessage{
+				UserID:    userID,
+				ChatID:    7,
+				Kind:      db.ScheduledMessageDayStats,
+				SendAt:    now.Add(-time.Minute),
+				CreatedAt: now.Add(-time.Hour),
+				Attempts:  tt.attempts,
+			})
+			if err != nil {
+				t.Fatalf("AddScheduledMessage: %v", err)
+			}
+
+			h.sendDueScheduledMessages(context.Background(), now)
+
+			if got := len(sender.Sent()); got != tt.wantSent {
+				t.Errorf("sent %d messages, want %d", got, tt.wantSent)
+			}
+			due, err := repo.GetDueScheduledMessages(now)
+			if err != nil {
+				t.Fatalf("GetDueScheduledMessages: %v", err)
+			}
+			if kept := len(due) > 0; kept != tt.wantKept {
+				t.Fatalf("due messages = %+v, want kept %v", due, tt.wantKept)
+			}
+			if tt.wantKept && due[0].Attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", due[0].Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendToUser отправляет пользователю сообщение, которое бот пишет сам, без запроса
+// пользователя (уведомления, отложенные сообщения). Если группа пользователя стала
+// супергруппой, ChatID обновляется и сообщение отправляется повторно; если бот
+// заблокирован или чат удалён, уведомления пользователю отключаются.
+func (h *Handlers) sendToUser(
+	ctx context.Context, user *db.User, msg tgbotapi.MessageConfig,
+) (tgbotapi.Message, error) {
+	sent, err := h.sender.Send(msg)
+
+	if newChatID, ok := bot.MigratedChatID(err); ok {
+		slog.InfoContext(ctx, "Чат пользователя перенесён", "old_chat_id", msg.ChatID, "new_chat_id", newChatID)
+		user.ChatID = common.ChatID(newChatID)
+		if err := h.users.UpdateUserColumns(user.ID, map[string]any{"chat_id": user.ChatID}); err != nil {
+			slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		}
+		msg.ChatID = newChatID
+		sent, err = h.sender.Send(msg)
+	}
+
+	switch {
+	case bot.IsBlocked(err):
+		h.disableDelivery(ctx, user, db.DeliveryBlocked)
+	case bot.IsChatNotFound(err):
+		h.disableDelivery(ctx, user, db.DeliveryChatNotFound)
+	}
+	return sent, err
+}
+
+// disableDelivery выключает уведомления пользователю, до которого сообщения
+// не доходят, и запоминает причину. Пользователь включит их снова командой /start.
+func (h *Handlers) disableDelivery(ctx context.Context, user *db.User, reason string) {
+	user.TimerEnabled = false
+	user.DeliveryDisabledReason = sql.NullString{String: reason, Valid: true}
+	user.DeliveryDisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	// user может быть снимком начала рассылки, поэтому пишем только столбцы доставки.
+	err := h.users.UpdateUserColumns(user.ID, map[string]any{
+		"timer_enabled":            user.TimerEnabled,
+		"delivery_disabled_reason": user.DeliveryDisabledReason,
+		"delivery_disabled_at":     user.DeliveryDisabledAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+	slog.WarnContext(ctx, "Сообщения пользователю не доставляются, уведомления отключены", "reason", reason)
+}
+
+// resumeDelivery снова включает уведомления пользователю, доставка которому была
+// отключена, если он уже настроил расписание. Возвращает true, если доставка была отключена.
+func (h *Handlers) resumeDelivery(ctx context.Context, user *db.User, chatID common.ChatID) bool {
+	if !user.DeliveryDisabledReason.Valid {
+		return false
+	}
+
+	slog.InfoContext(ctx, "Пользователь вернулся, доставка сообщений возобновлена",
+		"reason", user.DeliveryDisabledReason.String)
+	user.ChatID = chatID
+	user.TimerEnabled = user.TimerMinutes.Valid && user.ScheduleMorningStartHour.Valid &&
+		user.ScheduleEveningFinishHour.Valid
+	user.DeliveryDisabledReason = sql.NullString{}
+	user.DeliveryDisabledAt = sql.NullTime{}
+	err := h.users.UpdateUserColumns(user.ID, map[string]any{
+		"chat_id":                  user.ChatID,
+		"timer_enabled":            user.TimerEnabled,
+		"delivery_disabled_reason": user.DeliveryDisabledReason,
+		"delivery_disabled_at":     user.DeliveryDisabledAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+	}
+	return true
+}
+
+// MigrateChat обрабатывает служебное сообщение о преобразовании группы в супергруппу.
+func (h *Handlers) MigrateChat(ctx context.Context, message *tgbotapi.Message) {
+	err := h.users.MigrateChatID(common.ChatID(message.Chat.ID), common.ChatID(message.MigrateToChatID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка переноса чата", "err", err)
+		return
+	}
+	slog.InfoContext(ctx, "Чат перенесён", "old_chat_id", message.Chat.ID, "new_chat_id", message.MigrateToChatID)
+}
+
+// MyChatMemberUpdated обрабатывает изменение статуса бота в личном чате: если пользователь
+// заблокировал бота, уведомления ему сразу отключаются, не дожидаясь ошибки отправки.
+func (h *Handlers) MyChatMemberUpdated(ctx context.Context, update *tgbotapi.ChatMemberUpdated) {
+	if update.Chat.Type != "private" || update.NewChatMember.Status != "kicked" {
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(update.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+	h.disableDelivery(ctx, user, db.DeliveryBlocked)
+}
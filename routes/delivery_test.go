@@ -0,0 +1,239 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// failingSender отвечает ошибками из errs на первые вызовы Send, дальше работает как FakeSender.
+type failingSender struct {
+	*bot.FakeSender
+
+	mu   sync.Mutex
+	errs []error
+}
+
+func (s *failingSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.mu.Lock()
+	var err error
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return s.FakeSender.Send(c)
+}
+
+func TestSendToUser(t *testing.T) {
+	const userID common.UserID = 7
+
+	tests := []struct {
+		name        string
+		errs        []error
+		wantErr     bool
+		wantReason  string
+		wantChatID  common.ChatID
+		wantEnabled bool
+	}{
+		{
+			name:        "delivered",
+			wantChatID:  7,
+			wantEnabled: true,
+		},
+		{
+			name:       "blocked",
+			errs:       []error{&tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}},
+			wantErr:    true,
+			wantReason: db.DeliveryBlocked,
+			wantChatID: 7,
+		},
+		{
+			name:       "chat not found",
+			errs:       []error{&tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}},
+			wantErr:    true,
+			wantReason: db.DeliveryChatNotFound,
+			wantChatID: 7,
+		},
+		{
+			name: "chat migrated",
+			errs: []error{&tgbotapi.Error{
+				Code:               400,
+				Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+				ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -1007},
+			}},
+			wantChatID:  -1007,
+			wantEnabled: true,
+		},
+		{
+			name:        "other error",
+			errs:        []error{errors.New("boom")},
+			wantErr:     true,
+			wantChatID:  7,
+			wantEnabled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := db.NewMemoryRepository()
+			if err != nil {
+				t.Fatalf("NewMemoryRepository: %v", err)
+			}
+			sender := &failingSender{FakeSender: bot.NewFakeSender(), errs: tt.errs}
+			settings := Settings{DayStatsWaitDuration: time.Minute, Location: time.UTC}
+			h := NewHandlers(repo, repo, repo, repo, sender, settings)
+
+			if err := repo.AddUser(db.User{ID: userID, ChatID: 7, TimerEnabled: true}); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+			user, err := repo.GetUserByID(userID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+
+			_, err = h.sendToUser(context.Background(), user, tgbotapi.NewMessage(int64(user.ChatID), "текст"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sendToUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			stored, err := repo.GetUserByID(userID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if stored.ChatID != tt.wantChatID {
+				t.Errorf("chat_id = %d, want %d", stored.ChatID, tt.wantChatID)
+			}
+			if stored.TimerEnabled != tt.wantEnabled {
+				t.Errorf("timer_enabled = %v, want %v", stored.TimerEnabled, tt.wantEnabled)
+			}
+			if stored.DeliveryDisabledReason.String != tt.wantReason {
+				t.Errorf("delivery_disabled_reason = %q, want %q", stored.DeliveryDisabledReason.String, tt.wantReason)
+			}
+			if stored.DeliveryDisabledAt.Valid != (tt.wantReason != "") {
+				t.Errorf("delivery_disabled_at = %v, want set %v", stored.DeliveryDisabledAt, tt.wantReason != "")
+			}
+		})
+	}
+}
+
+func TestResumeDelivery(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        db.User
+		wantResumed bool
+		wantEnabled bool
+	}{
+		{
+			name:        "delivery was not disabled",
+			user:        db.User{ID: 1, ChatID: 1, TimerEnabled: true},
+			wantEnabled: true,
+		},
+		{
+			name: "blocked with a schedule",
+			user: db.User{
+				ID:                        1,
+				ChatID:                    1,
+				TimerMinutes:              sql.NullInt64{Int64: 30, Valid: true},
+				ScheduleMorningStartHour:  sql.NullInt64{Int64: 9, Valid: true},
+				ScheduleEveningFinishHour: sql.NullInt64{Int64: 18, Valid: true},
+				DeliveryDisabledReason:    sql.NullString{String: db.DeliveryBlocked, Valid: true},
+				DeliveryDisabledAt:        sql.NullTime{Time: time.Now(), Valid: true},
+			},
+			wantResumed: true,
+			wantEnabled: true,
+		},
+		{
+			name: "blocked without a schedule",
+			user: db.User{
+				ID:                     1,
+				ChatID:                 1,
+				DeliveryDisabledReason: sql.NullString{String: db.DeliveryChatNotFound, Valid: true},
+			},
+			wantResumed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo, _ := newTestHandlers(t)
+			if err := repo.AddUser(tt.user); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+			user, err := repo.GetUserByID(tt.user.ID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+
+			if got := h.resumeDelivery(context.Background(), user, 99); got != tt.wantResumed {
+				t.Errorf("resumeDelivery() = %v, want %v", got, tt.wantResumed)
+			}
+
+			stored, err := repo.GetUserByID(tt.user.ID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if stored.TimerEnabled != tt.wantEnabled {
+				t.Errorf("timer_enabled = %v, want %v", stored.TimerEnabled, tt.wantEnabled)
+			}
+			if stored.DeliveryDisabledReason.Valid || stored.DeliveryDisabledAt.Valid {
+				t.Errorf("delivery is still marked disabled: %+v", stored)
+			}
+			wantChatID := tt.user.ChatID
+			if tt.wantResumed {
+				wantChatID = 99
+			}
+			if stored.ChatID != wantChatID {
+				t.Errorf("chat_id = %d, want %d", stored.ChatID, wantChatID)
+			}
+		})
+	}
+}
+
+func TestMyChatMemberUpdated(t *testing.T) {
+	tests := []struct {
+		name        string
+		chatType    string
+		status      string
+		wantEnabled bool
+	}{
+		{name: "blocked in private chat", chatType: "private", status: "kicked"},
+		{name: "unblocked", chatType: "private", status: "member", wantEnabled: true},
+		{name: "removed from group", chatType: "group", status: "kicked", wantEnabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo, _ := newTestHandlers(t)
+			if err := repo.AddUser(db.User{ID: 5, ChatID: 5, TimerEnabled: true}); err != nil {
+				t.Fatalf("AddUser: %v", err)
+			}
+
+			h.MyChatMemberUpdated(context.Background(), &tgbotapi.ChatMemberUpdated{
+				Chat:          tgbotapi.Chat{ID: 5, Type: tt.chatType},
+				From:          tgbotapi.User{ID: 5},
+				NewChatMember: tgbotapi.ChatMember{Status: tt.status},
+			})
+
+			stored, err := repo.GetUserByID(5)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if stored.TimerEnabled != tt.wantEnabled {
+				t.Errorf("timer_enabled = %v, want %v", stored.TimerEnabled, tt.wantEnabled)
+			}
+		})
+	}
+}
@@ -1,58 +1,104 @@
 package routes
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"log/slog"
 	"time"
 
 	"TimeCounterBot/db"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/metrics"
 )
 
-const DispatchInterval = time.Second * 5
-
-func isTimeInInterval(ts time.Time, startHour, finishHour int64) bool {
-	if startHour < finishHour {
-		return ts.Hour() >= int(startHour) && ts.Hour() < int(finishHour)
+func (h *Handlers) processUser(ctx context.Context, user db.User, now time.Time) {
+	if !user.TimerEnabled {
+		return
 	}
-	return ts.Hour() >= int(startHour) || ts.Hour() < int(finishHour)
-}
 
-func processUser(user db.User, now time.Time) {
-	if !user.TimerEnabled {
+	if isSnoozed(user, now) {
 		return
 	}
+	if user.SnoozedUntil.Valid {
+		// Пауза закончилась — уведомления возобновляются сами.
+		user.SnoozedUntil = sql.NullTime{}
+		if err := h.users.UpdateUserColumns(user.ID, map[string]any{"snoozed_until": user.SnoozedUntil}); err != nil {
+			slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+			return
+		}
+		slog.InfoContext(ctx, "Пауза уведомлений закончилась")
+	}
 
 	if !user.ScheduleMorningStartHour.Valid || !user.ScheduleEveningFinishHour.Valid || !user.TimerMinutes.Valid {
-		log.Fatalf("something is invalid for user %d", user.ID)
+		slog.ErrorContext(ctx, "Расписание пользователя заполнено не полностью")
+		return
 	}
 
-	startHour := user.ScheduleMorningStartHour.Int64
-	finishHour := user.ScheduleEveningFinishHour.Int64
-	if !isTimeInInterval(now, startHour, finishHour) {
+	windows, err := h.userWindows(user)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения расписания", "err", err)
 		return
 	}
-
-	if user.LastNotify.Valid && now.Sub(user.LastNotify.Time) < time.Minute*time.Duration(user.TimerMinutes.Int64) {
+	day, ok := scheduleDay(windows, now, h.location())
+	if !ok {
 		return
 	}
 
-	go notifyUser(user)
-	if !isTimeInInterval(now.Add(time.Minute*time.Duration(user.TimerMinutes.Int64)), startHour, finishHour) {
-		go startDayStatsRoutine(user)
+	interval := time.Minute * time.Duration(notifyIntervalMinutes(user))
+	if user.LastNotify.Valid && now.Sub(user.LastNotify.Time) < interval {
+		return
 	}
-}
 
-func DispatchNotifications() {
-	now := time.Now()
-
-	users, err := db.GetUsers()
+	dayOff, err := h.users.IsDayOff(user.ID, day)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка проверки выходных", "err", err)
+		return
 	}
-	for _, user := range users {
-		processUser(user, now)
+	if dayOff {
+		return
+	}
+
+	h.goBackground(func() { h.notifyUser(ctx, user) })
+	if nextDay, ok := scheduleDay(windows, now.Add(interval), h.location()); !ok || !nextDay.Equal(day) {
+		h.scheduleDayStats(ctx, user, now)
 	}
+}
+
+// DispatchNotifications раз в DispatchInterval рассылает уведомления пользователям,
+// которым пора ответить, и отложенные сообщения, время которых наступило.
+// Проходы запускаются с фиксированным шагом; если проход начался позже
+// запланированного, задержка попадает в метрику dispatcher_lag_seconds.
+// Возвращается после отмены ctx, не начиная новых рассылок; уже запущенные дожидается Wait.
+func (h *Handlers) DispatchNotifications(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	scheduled := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
 
-	time.Sleep(DispatchInterval)
+		now := time.Now()
+		metrics.DispatcherLag.Set(now.Sub(scheduled).Seconds())
+		metrics.DispatcherLastRun.Set(float64(now.Unix()))
 
-	go DispatchNotifications()
+		users, err := h.users.GetUsers()
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка получения списка пользователей", "err", err)
+		}
+		for _, user := range users {
+			// После остановки новых уведомлений не рассылаем, даже если проход не закончен.
+			if ctx.Err() != nil {
+				return
+			}
+			h.processUser(logging.WithUserID(ctx, user.ID), user, now)
+		}
+		h.sendDueScheduledMessages(ctx, now)
+
+		scheduled = now.Add(h.settings.DispatchInterval)
+		timer.Reset(time.Until(scheduled))
+	}
 }
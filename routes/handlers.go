@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/tg/bot"
+)
+
+// Settings — настройки обработчиков, которые задаются конфигурацией бота.
+type Settings struct {
+	// DispatchInterval — период проверки, кому пора прислать уведомление.
+	DispatchInterval time.Duration
+	// DayStatsWaitDuration — пауза перед предложением прислать итоги дня.
+	DayStatsWaitDuration time.Duration
+	// AutoFillInterval — период заполнения пропущенных уведомлений по правилу пользователя.
+	AutoFillInterval time.Duration
+	// PythonBin и ChartScriptsDir — интерпретатор и каталог скриптов построения графиков.
+	PythonBin       string
+	ChartScriptsDir string
+	// PublicURL — внешний адрес HTTP-сервера для ссылок на ICS-ленты.
+	PublicURL string
+	// Location — часовой пояс расписаний уведомлений, границ дней («сегодня», отчёты)
+	// и времени в сообщениях; nil — часовой пояс сервера.
+	Location *time.Location
+}
+
+// Handlers — обработчики команд и callback-ов бота. Хранилища и отправитель
+// сообщений передаются явно, поэтому весь сценарий «уведомление → ответ → аналитика»
+// можно прогнать в тестах на db.NewMemoryRepository и bot.FakeSender.
+type Handlers struct {
+	users      db.UserStore
+	activities db.ActivityStore
+	logs       db.ActivityLogStore
+	scheduled  db.ScheduledMessageStore
+	sender     bot.Sender
+	settings   Settings
+
+	// background — фоновые задачи (рассылка уведомлений, ожидание ответов),
+	// которые нужно дождаться при остановке бота.
+	background sync.WaitGroup
+
+	// splits — незавершённые разделения интервала уведомления между активностями.
+	splitsMu sync.Mutex
+	splits   map[splitKey]*splitDraft
+
+	// dialogsDone закрывается в StopDialogs: обработчики перестают ждать ответы пользователей.
+	dialogsDone chan struct{}
+	stopDialogs sync.Once
+}
+
+// NewHandlers создаёт обработчики поверх переданных хранилищ и отправителя.
+func NewHandlers(
+	users db.UserStore, activities db.ActivityStore, logs db.ActivityLogStore, scheduled db.ScheduledMessageStore,
+	sender bot.Sender, settings Settings,
+) *Handlers {
+	return &Handlers{
+		users:       users,
+		activities:  activities,
+		logs:        logs,
+		scheduled:   scheduled,
+		sender:      sender,
+		settings:    settings,
+		splits:      make(map[splitKey]*splitDraft),
+		dialogsDone: make(chan struct{}),
+	}
+}
+
+// goBackground запускает f в отдельной горутине, которую дождётся Wait.
+func (h *Handlers) goBackground(f func()) {
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		f()
+	}()
+}
+
+// Wait блокируется, пока не завершатся все фоновые задачи обработчиков.
+func (h *Handlers) Wait() {
+	h.background.Wait()
+}
+
+// waitForReply ждёт ответ пользователя из канала диалога. Возвращает false,
+// если бот останавливается и ответа дожидаться не нужно.
+func (h *Handlers) waitForReply(ctx context.Context, replies <-chan string) (string, bool) {
+	select {
+	case reply := <-replies:
+		return reply, true
+	case <-ctx.Done():
+		return "", false
+	case <-h.dialogsDone:
+		return "", false
+	}
+}
+
+// StopDialogs прекращает ожидание ответов в открытых и новых диалогах. Вызывается
+// при остановке, когда обновления больше не принимаются и ответов уже не будет:
+// иначе обработчики, ждущие ответа, не дали бы дождаться остальных обновлений.
+func (h *Handlers) StopDialogs() {
+	h.stopDialogs.Do(func() { close(h.dialogsDone) })
+}
+
+// location возвращает часовой пояс бота (Settings.Location).
+func (h *Handlers) location() *time.Location {
+	if h.settings.Location == nil {
+		return time.Local
+	}
+	return h.settings.Location
+}
+
+// zoneName возвращает короткое название часового пояса бота ("UTC", "MSK") для сообщений.
+func (h *Handlers) zoneName() string {
+	return time.Now().In(h.location()).Format("MST")
+}
+
+// userLang возвращает язык интерфейса пользователя.
+func userLang(user db.User) i18n.Lang {
+	return i18n.Parse(user.Language)
+}
+
+// langOf возвращает язык интерфейса пользователя userID или язык по умолчанию,
+// если пользователя не удалось получить.
+func (h *Handlers) langOf(userID common.UserID) i18n.Lang {
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		return i18n.Default
+	}
+	return userLang(*user)
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newTestHandlers создаёт обработчики поверх хранилища в памяти и FakeSender.
+func newTestHandlers(t *testing.T) (*Handlers, db.Repository, *bot.FakeSender) {
+	t.Helper()
+
+	repo, err := db.NewMemoryRepository()
+	if err != nil {
+		t.Fatalf("NewMemoryRepository: %v", err)
+	}
+	sender := bot.NewFakeSender()
+	settings := Settings{DayStatsWaitDuration: time.Minute, Location: time.UTC}
+	return NewHandlers(repo, repo, repo, repo, sender, settings), repo, sender
+}
+
+// columnUsers — хранилище пользователей для тестов, которое запоминает записанные
+// обработчиками столбцы и не даёт сохранять строку пользователя целиком: её
+// одновременно меняют рассылка уведомлений и другие обработчики.
+type columnUsers struct {
+	db.UserStore
+	t       *testing.T
+	columns []string
+}
+
+func (u *columnUsers) UpdateUser(user db.User) error {
+	u.t.Errorf("UpdateUser(%d) saves the whole row, want UpdateUserColumns", user.ID)
+	return u.UserStore.UpdateUser(user)
+}
+
+func (u *columnUsers) UpdateUserColumns(userID common.UserID, columns map[string]any) error {
+	for column := range columns {
+		if !slices.Contains(u.columns, column) {
+			u.columns = append(u.columns, column)
+		}
+	}
+	slices.Sort(u.columns)
+	return u.UserStore.UpdateUserColumns(userID, columns)
+}
+
+// newColumnTestHandlers — newTestHandlers, в котором обработчики пишут пользователей
+// через columnUsers.
+func newColumnTestHandlers(t *testing.T) (*Handlers, db.Repository, *bot.FakeSender, *columnUsers) {
+	t.Helper()
+
+	h, repo, sender := newTestHandlers(t)
+	users := &columnUsers{UserStore: repo, t: t}
+	h.users = users
+	return h, repo, sender, users
+}
+
+// buttonData возвращает callback data кнопки с текстом text из keyboard.
+func buttonData(t *testing.T, keyboard tgbotapi.InlineKeyboardMarkup, text string) string {
+	t.Helper()
+
+	for _, row := range keyboard.InlineKeyboard {
+		for _, button := range row {
+			if button.Text == text && button.CallbackData != nil {
+				return *button.CallbackData
+			}
+		}
+	}
+	t.Fatalf("button %q not found in %+v", text, keyboard.InlineKeyboard)
+	return ""
+}
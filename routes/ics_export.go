@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/ics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultICSExportDays — период экспорта по умолчанию, если даты не указаны.
+const defaultICSExportDays = 30
+
+// ExportICSCommand обрабатывает команду /export_ics [YYYY-MM-DD YYYY-MM-DD]
+// и присылает логи активностей за период в виде .ics файла.
+func (h *Handlers) ExportICSCommand(ctx context.Context, message *tgbotapi.Message) {
+	tgUser := message.From
+	if tgUser == nil {
+		return
+	}
+
+	userID := common.UserID(tgUser.ID)
+
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	start, end, err := parseICSExportPeriod(message.Text, time.Now(), h.location())
+	if err != nil {
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_export.usage"))
+		h.sender.Send(msgConf)
+		return
+	}
+
+	events, err := h.logs.GetCalendarEvents(userID, start, end)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения событий календаря", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_export.error"))
+		h.sender.Send(msgConf)
+		return
+	}
+
+	if len(events) == 0 {
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_export.empty"))
+		h.sender.Send(msgConf)
+		return
+	}
+
+	document := tgbotapi.NewDocument(int64(user.ChatID), tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("activities_%s_%s.ics", start.Format(time.DateOnly), end.AddDate(0, 0, -1).Format(time.DateOnly)),
+		Bytes: ics.Encode("Time Counter", db.CalendarEventsToICS(userID, events)),
+	})
+	document.Caption = i18n.T(lang, "ics_export.caption",
+		start.Format(i18n.T(lang, "format.date")), end.AddDate(0, 0, -1).Format(i18n.T(lang, "format.date")), len(events))
+
+	_, err = h.sender.Send(document)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки файла", "err", err)
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "common.send_file_error"))
+		h.sender.Send(msgConf)
+	}
+}
+
+// parseICSExportPeriod разбирает аргументы команды /export_ics.
+// Без аргументов возвращает последние defaultICSExportDays дней до now.
+// Границы дней в обоих случаях — полночь по часовому поясу loc.
+func parseICSExportPeriod(text string, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	args := strings.Fields(text)[1:]
+	if len(args) == 0 {
+		end := startOfDay(now, loc).AddDate(0, 0, 1)
+		return end.AddDate(0, 0, -defaultICSExportDays), end, nil
+	}
+	if len(args) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected 2 dates, got %d", len(args))
+	}
+
+	start, err := time.ParseInLocation(time.DateOnly, args[0], loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.ParseInLocation(time.DateOnly, args[1], loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end date %s is before start date %s", args[1], args[0])
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// CalendarFeedCommand обрабатывает команду /ics_feed [reset] и присылает
+// ссылку на приватную ICS-ленту пользователя.
+func (h *Handlers) CalendarFeedCommand(ctx context.Context, message *tgbotapi.Message) {
+	tgUser := message.From
+	if tgUser == nil {
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(tgUser.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	publicURL := strings.TrimSuffix(h.settings.PublicURL, "/")
+	if publicURL == "" {
+		msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_feed.disabled"))
+		h.sender.Send(msgConf)
+		return
+	}
+
+	args := strings.Fields(message.Text)[1:]
+	if !user.CalendarToken.Valid || (len(args) > 0 && args[0] == "reset") {
+		token, err := generateCalendarToken()
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка генерации токена календаря", "err", err)
+			return
+		}
+		user.CalendarToken = sql.NullString{String: token, Valid: true}
+		// Пишем только токен: строку пользователя одновременно меняет рассылка уведомлений.
+		err = h.users.UpdateUserColumns(user.ID, map[string]any{"calendar_token": user.CalendarToken})
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка сохранения токена календаря", "err", err)
+			return
+		}
+	}
+
+	msgConf := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_feed.link",
+		publicURL, user.CalendarToken.String))
+	_, err = h.sender.Send(msgConf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// generateCalendarToken генерирует случайный токен для приватной ICS-ленты.
+func generateCalendarToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
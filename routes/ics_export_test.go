@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseICSExportPeriod(t *testing.T) {
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	now := time.Date(2025, 3, 10, 22, 0, 0, 0, time.UTC) // 11 марта в loc
+	tests := []struct {
+		name      string
+		text      string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "default period ends after today in loc",
+			text:      "/export_ics",
+			wantStart: time.Date(2025, 2, 10, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2025, 3, 12, 0, 0, 0, 0, loc),
+		},
+		{
+			name:      "explicit dates in loc",
+			text:      "/export_ics 2025-01-01 2025-01-31",
+			wantStart: time.Date(2025, 1, 1, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2025, 2, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			name:      "single day",
+			text:      "/export_ics 2025-01-05 2025-01-05",
+			wantStart: time.Date(2025, 1, 5, 0, 0, 0, 0, loc),
+			wantEnd:   time.Date(2025, 1, 6, 0, 0, 0, 0, loc),
+		},
+		{name: "one date", text: "/export_ics 2025-01-01", wantErr: true},
+		{name: "bad date", text: "/export_ics 2025-13-01 2025-12-31", wantErr: true},
+		{name: "end before start", text: "/export_ics 2025-02-01 2025-01-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseICSExportPeriod(tt.text, now, loc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseICSExportPeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("parseICSExportPeriod() = [%v, %v), want [%v, %v)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCalendarFeedCommand(t *testing.T) {
+	const userID common.UserID = 5
+	h, repo, sender, users := newColumnTestHandlers(t)
+	h.settings.PublicURL = "https://bot.example/"
+	lastNotify := sql.NullTime{Time: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), Valid: true}
+	if err := repo.AddUser(db.User{ID: userID, ChatID: 5, Language: "ru", LastNotify: lastNotify}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+
+	feed := func(text string) (token, link string) {
+		t.Helper()
+		users.columns = nil
+		h.CalendarFeedCommand(context.Background(), &tgbotapi.Message{
+			From: &tgbotapi.User{ID: int64(userID)},
+			Chat: &tgbotapi.Chat{ID: int64(userID)},
+			Text: text,
+		})
+		user, err := repo.GetUserByID(userID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if !user.LastNotify.Valid || !user.LastNotify.Time.Equal(lastNotify.Time) {
+			t.Errorf("%s: last_notify = %v, want %v", text, user.LastNotify, lastNotify)
+		}
+		sent := sender.Sent()
+		return user.CalendarToken.String, sent[len(sent)-1].(tgbotapi.MessageConfig).Text
+	}
+
+	token, link := feed("/ics_feed")
+	if token == "" || !slices.Equal(users.columns, []string{"calendar_token"}) {
+		t.Fatalf("first /ics_feed: token %q, columns %v, want a token in calendar_token", token, users.columns)
+	}
+	if want := i18n.T(i18n.Russian, "ics_feed.link", "https://bot.example", token); link != want {
+		t.Errorf("link message = %q, want %q", link, want)
+	}
+
+	if again, _ := feed("/ics_feed"); again != token || len(users.columns) != 0 {
+		t.Errorf("repeated /ics_feed: token %q, columns %v, want %q unchanged", again, users.columns, token)
+	}
+	if reset, _ := feed("/ics_feed reset"); reset == token || !slices.Equal(users.columns, []string{"calendar_token"}) {
+		t.Errorf("/ics_feed reset: token %q, columns %v, want a new token", reset, users.columns)
+	}
+}
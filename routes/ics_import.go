@@ -0,0 +1,179 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/ics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// importRuleSeparator разделяет регулярное выражение и путь активности в правиле.
+const importRuleSeparator = "=>"
+
+// ImportRulesCommand обрабатывает команду /import_rules: показывает текущие
+// правила импорта календаря и ждёт от пользователя новый список правил.
+func (h *Handlers) ImportRulesCommand(ctx context.Context, message *tgbotapi.Message) {
+	tgUser := message.From
+	if tgUser == nil {
+		return
+	}
+
+	userID := common.UserID(tgUser.ID)
+
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	userState := common.GetUserState(userID)
+	if userState.State == common.InCommand {
+		_, err = h.sender.Send(
+			tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "common.already_in_command")),
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	rules, err := h.logs.GetImportRules(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения правил импорта", "err", err)
+		return
+	}
+
+	waitChan := make(chan string, 1)
+	common.SetUserState(userID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(userID, common.UserState{State: common.Idle, WaitingChannel: nil})
+
+	reply := tgbotapi.NewMessage(int64(user.ChatID), formatImportRules(lang, rules)+"\n\n"+
+		i18n.T(lang, "ics_import.ask_rules"))
+	reply.ParseMode = "Markdown"
+	reply.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+
+	_, err = h.sender.Send(reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+
+	newRules, err := parseImportRules(lang, ans)
+	if err != nil {
+		_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_import.rules_error", err)))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	err = h.logs.ReplaceImportRules(userID, newRules)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения правил импорта", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_import.rules_saved", len(newRules))))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// parseImportRules разбирает правила импорта из текста, по одному на строку.
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func parseImportRules(lang i18n.Lang, text string) ([]db.ImportRule, error) {
+	if strings.TrimSpace(text) == "-" {
+		return nil, nil
+	}
+
+	var rules []db.ImportRule
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		pattern, activityPath, found := strings.Cut(line, importRuleSeparator)
+		pattern = strings.TrimSpace(pattern)
+		activityPath = strings.TrimSpace(activityPath)
+		if !found || pattern == "" || activityPath == "" {
+			return nil, errors.New(i18n.T(lang, "ics_import.error_format", i+1))
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, errors.New(i18n.T(lang, "ics_import.error_regexp", i+1, err))
+		}
+
+		rules = append(rules, db.ImportRule{Pattern: pattern, ActivityPath: activityPath})
+	}
+	return rules, nil
+}
+
+// formatImportRules форматирует список правил импорта для показа пользователю в разметке
+// Markdown. Правила вводит пользователь, поэтому символы разметки в них экранируются:
+// внутри `кода` обратную кавычку экранировать нельзя, так что правила выводятся обычным текстом.
+func formatImportRules(lang i18n.Lang, rules []db.ImportRule) string {
+	if len(rules) == 0 {
+		return i18n.T(lang, "ics_import.rules_title") + i18n.T(lang, "ics_import.no_rules")
+	}
+
+	result := i18n.T(lang, "ics_import.rules_title")
+	for i, rule := range rules {
+		result += fmt.Sprintf("%d. %s → %s\n", i+1,
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, rule.Pattern),
+			tgbotapi.EscapeText(tgbotapi.ModeMarkdown, rule.ActivityPath))
+	}
+	return result
+}
+
+// processCalendarImport импортирует события календаря из .ics файла в логи активностей.
+func (h *Handlers) processCalendarImport(ctx context.Context, user db.User, data []byte) {
+	lang := userLang(user)
+	events, err := ics.Parse(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора календаря", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_import.parse_error", err)))
+		return
+	}
+
+	rules, err := h.logs.GetImportRules(user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения правил импорта", "err", err)
+		return
+	}
+	if len(rules) == 0 {
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_import.no_rules_yet")))
+		return
+	}
+
+	summary, err := h.logs.ImportCalendarEvents(user.ID, events, rules, user.TimerMinutes.Int64)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка импорта календаря", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "ics_import.error", err)))
+		return
+	}
+
+	msgText := i18n.T(lang, "ics_import.summary",
+		summary.EventsMatched, summary.EventsUnmatched,
+		summary.SlotsImported, formatMinutes(lang, summary.MinutesImported),
+		summary.SlotsSkipped,
+	)
+	_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), msgText))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
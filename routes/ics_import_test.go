@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"testing"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+)
+
+func TestParseImportRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []db.ImportRule
+		wantErr bool
+	}{
+		{name: "dash clears rules", text: " - ", want: nil},
+		{
+			name: "rules with blank lines",
+			text: "(?i)созвон => Работа / Встречи\n\n  ^Обед$=>Отдых  ",
+			want: []db.ImportRule{
+				{Pattern: "(?i)созвон", ActivityPath: "Работа / Встречи"},
+				{Pattern: "^Обед$", ActivityPath: "Отдых"},
+			},
+		},
+		{name: "no separator", text: "созвон Работа", wantErr: true},
+		{name: "empty path", text: "созвон =>", wantErr: true},
+		{name: "bad regexp", text: "([ => Работа", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportRules(i18n.Russian, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseImportRules() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Pattern != tt.want[i].Pattern || got[i].ActivityPath != tt.want[i].ActivityPath {
+					t.Errorf("rule %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatImportRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []db.ImportRule
+		want  string
+	}{
+		{name: "no rules", want: "📥 *Правила импорта календаря*\n\nПравил пока нет."},
+		{
+			name: "plain rule",
+			rules: []db.ImportRule{
+				{Pattern: "(?i)созвон", ActivityPath: "Работа / Встречи"},
+			},
+			want: "📥 *Правила импорта календаря*\n\n1. (?i)созвон → Работа / Встречи\n",
+		},
+		{
+			name: "markdown characters",
+			rules: []db.ImportRule{
+				{Pattern: "`code`|a*b|snake_case|\\[x\\]", ActivityPath: "Работа / *Важное* [1]"},
+			},
+			want: "📥 *Правила импорта календаря*\n\n" +
+				"1. \\`code\\`|a\\*b|snake\\_case|\\\\[x\\] → Работа / \\*Важное\\* \\[1]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatImportRules(i18n.Russian, tt.rules); got != tt.want {
+				t.Errorf("formatImportRules() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,15 +3,16 @@ package routes
 import (
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"slices"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func MuteActivityCommand(message *tgbotapi.Message, mute bool) {
+func (h *Handlers) MuteActivityCommand(ctx context.Context, message *tgbotapi.Message, mute bool) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -19,61 +20,68 @@ func MuteActivityCommand(message *tgbotapi.Message, mute bool) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	msgText := "Что хочешь размьютить?"
+	lang := userLang(*user)
+	msgText := i18n.T(lang, "mute.ask_unmute")
 	callbackCommand := "unmute_activity__unmute"
 	var isMuted *bool = nil
 	hasMutedLeaves := BoolPtr(true)
 	if mute {
-		msgText = "Что хочешь замьютить?"
+		msgText = i18n.T(lang, "mute.ask_mute")
 		callbackCommand = "mute_activity__mute"
 		isMuted = BoolPtr(false)
 		hasMutedLeaves = nil
 	}
 
 	msgconf := tgbotapi.NewMessage(int64(user.ChatID), msgText)
-	msgconf.ReplyMarkup = buildActivitiesKeyboardMarkupForUser(
-		*user, -1, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(mute))
+	msgconf.ReplyMarkup = h.buildActivitiesKeyboardMarkupForUser(
+		ctx, *user, -1, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(lang, mute))
 
-	_, err = bot.Bot.Send(msgconf)
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 
-	_, err = bot.Bot.Request(
+	_, err = h.sender.Request(
 		tgbotapi.NewDeleteMessage(message.Chat.ID, message.MessageID),
 	)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка запроса к Telegram", "err", err)
+		return
 	}
 }
 
-func MuteActivityCancelCallback(callback *tgbotapi.CallbackQuery) {
-	_, err := bot.Bot.Request(
+func (h *Handlers) MuteActivityCancelCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	_, err := h.sender.Request(
 		tgbotapi.NewDeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID),
 	)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка запроса к Telegram", "err", err)
+		return
 	}
 }
 
-func MuteActivityRefreshCallback(callback *tgbotapi.CallbackQuery, mute bool) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) MuteActivityRefreshCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, mute bool) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
+	lang := userLang(*user)
 	var isMuted *bool = nil
 	hasMutedLeaves := BoolPtr(true)
-	msgText := "Что хочешь размьютить?"
+	msgText := i18n.T(lang, "mute.ask_unmute")
 	callbackCommand := "unmute_activity__unmute"
 	if mute {
 		isMuted = BoolPtr(false)
 		hasMutedLeaves = nil
-		msgText = "Что хочешь замьютить?"
+		msgText = i18n.T(lang, "mute.ask_mute")
 		callbackCommand = "mute_activity__mute"
 	}
 
@@ -81,91 +89,102 @@ func MuteActivityRefreshCallback(callback *tgbotapi.CallbackQuery, mute bool) {
 		int64(user.ChatID),
 		callback.Message.MessageID,
 		msgText,
-		buildActivitiesKeyboardMarkupForUser(
-			*user, -1, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(mute)))
-	_, err = bot.Bot.Send(msgconf)
+		h.buildActivitiesKeyboardMarkupForUser(
+			ctx, *user, -1, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(lang, mute)))
+	_, err = h.sender.Send(msgconf)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func MuteActivityCallback(callback *tgbotapi.CallbackQuery, mute bool) {
+func (h *Handlers) MuteActivityCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, mute bool) {
 	var nodeID int64
 	var timerMinutes int64
 	var callbackCommand string
 	_, err := fmt.Sscanf(callback.Data, "%s %d %d", &callbackCommand, &nodeID, &timerMinutes)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 
+	lang := h.langOf(common.UserID(callback.From.ID))
 	var isMuted *bool = nil
 	hasMutedLeaves := BoolPtr(true)
-	finalMsgFirstPart := "Unmuted activity"
+	resultKey := "mute.unmuted"
 	if mute {
 		isMuted = BoolPtr(false)
 		hasMutedLeaves = nil
-		finalMsgFirstPart = "Muted activity"
+		resultKey = "mute.muted"
 	}
 
-	activities, err := db.GetSimpleActivities(common.UserID(callback.From.ID), isMuted, hasMutedLeaves)
+	activities, err := h.activities.GetSimpleActivities(common.UserID(callback.From.ID), isMuted, hasMutedLeaves)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		return
 	}
 
 	idx := slices.IndexFunc(activities, func(a db.Activity) bool { return a.ID == nodeID })
 	if idx == -1 {
-		log.Fatalf("activity with id %d was not found in user activities.", nodeID)
+		slog.ErrorContext(ctx, "Активность не найдена среди активностей пользователя", "activity_id", nodeID)
+		return
 	}
 
 	if activities[idx].IsLeaf {
 		if mute {
-			err = db.MuteActivityAndMaybeParents(nodeID)
+			err = h.activities.MuteActivityAndMaybeParents(nodeID)
 			if err != nil {
-				log.Fatal(err)
+				slog.ErrorContext(ctx, "Ошибка мьюта активности", "err", err)
+				return
 			}
 		} else {
-			err = db.UnmuteActivityAndMaybeParents(nodeID)
+			err = h.activities.UnmuteActivityAndMaybeParents(nodeID)
 			if err != nil {
-				log.Fatal(err)
+				slog.ErrorContext(ctx, "Ошибка размьюта активности", "err", err)
+				return
 			}
 		}
 
-		activityName, err := db.GetFullActivityNameByID(nodeID, common.UserID(callback.From.ID))
+		activityName, err := h.activities.GetFullActivityNameByID(nodeID, common.UserID(callback.From.ID))
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+			return
 		}
 
-		_, err = bot.Bot.Send(
+		_, err = h.sender.Send(
 			tgbotapi.NewEditMessageTextAndMarkup(
 				callback.Message.Chat.ID, callback.Message.MessageID,
-				finalMsgFirstPart+" \""+activityName+"\"",
+				i18n.T(lang, resultKey, activityName),
 				tgbotapi.InlineKeyboardMarkup{InlineKeyboard: make([][]tgbotapi.InlineKeyboardButton, 0)},
 			),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+			return
 		}
 	} else {
-		user, err := db.GetUserByID(common.UserID(callback.From.ID))
+		user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+			return
 		}
 
-		keyboard := buildActivitiesKeyboardMarkupForUser(
-			*user, nodeID, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(mute))
+		keyboard := h.buildActivitiesKeyboardMarkupForUser(
+			ctx, *user, nodeID, isMuted, hasMutedLeaves, callbackCommand, getMuteActivitiesLastRow(lang, mute))
 
-		_, err = bot.Bot.Send(
+		_, err = h.sender.Send(
 			tgbotapi.NewEditMessageTextAndMarkup(
 				callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text, keyboard,
 			),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+			return
 		}
 	}
 }
 
-func getMuteActivitiesLastRow(mute bool) []tgbotapi.InlineKeyboardButton {
+func getMuteActivitiesLastRow(lang i18n.Lang, mute bool) []tgbotapi.InlineKeyboardButton {
 	cancelMuteCallbackText := "unmute_activity__cancel"
 	refreshActivitiesCallbackText := "unmute_activity__refresh"
 	if mute {
@@ -175,11 +194,11 @@ func getMuteActivitiesLastRow(mute bool) []tgbotapi.InlineKeyboardButton {
 	return append(
 		make([]tgbotapi.InlineKeyboardButton, 0),
 		tgbotapi.InlineKeyboardButton{
-			Text:         "\U0000274C Cancel",
+			Text:         i18n.T(lang, "button.cancel"),
 			CallbackData: &cancelMuteCallbackText,
 		},
 		tgbotapi.InlineKeyboardButton{
-			Text:         "\U0001F504 Refresh activities",
+			Text:         i18n.T(lang, "button.refresh"),
 			CallbackData: &refreshActivitiesCallbackText,
 		},
 	)
@@ -1,16 +1,18 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"slices"
 	"strings"
 	"time"
 
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
-	tg "TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/metrics"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -20,131 +22,268 @@ import (
 // LogUserActivityCallback gets callback, switch:
 //  node_id is a leaf -> logs leaf-activity, deletes Ki
 //  node_id is not a leaf -> load all children of node_id, creates new Keyboard Ki+1
+// saved answer keeps [activity_log__change] and [activity_log__remove] buttons:
+//  change -> shows Ki with the tree again, remove -> deletes the log and restores M
 
-func notifyUser(user db.User) {
+func (h *Handlers) notifyUser(ctx context.Context, user db.User) {
+	previousNotify := user.LastNotify
 	user.LastNotify = sql.NullTime{Time: time.Now(), Valid: true}
-	err := db.UpdateUser(user)
+	// user — снимок начала прохода диспетчера: пишем только свой столбец, чтобы
+	// не затереть паузу, адаптивный интервал и настройки, изменённые за это время.
+	err := h.users.UpdateUserColumns(user.ID, map[string]any{"last_notify": user.LastNotify})
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 
-	msgconf := tgbotapi.NewMessage(int64(user.ChatID), "Чё делаеш?))0)")
+	lang := userLang(user)
+	interval := promptIntervalMinutes(user, previousNotify, user.LastNotify.Time)
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), h.promptText(ctx, user, user.LastNotify.Time, interval))
 	isMuted := false
-	msgconf.ReplyMarkup = buildActivitiesKeyboardMarkupForUser(
-		user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow())
+	keyboard := h.buildActivitiesKeyboardMarkupForUser(
+		ctx, user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow(lang))
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, getSnoozeRow(lang))
+	// Пропуски за сегодня собираем в одну кнопку вместо множества живых клавиатур.
+	if missed := h.countMissedToday(ctx, user.ID, user.LastNotify.Time); missed > 0 {
+		msgconf.Text += i18n.T(lang, "notify.missed", missed)
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "notify.missed_button", missed), "missed__fill"),
+		))
+	}
+	msgconf.ReplyMarkup = keyboard
+
+	sent, err := h.sendToUser(ctx, &user, msgconf)
+	if err != nil {
+		metrics.NotificationsTotal.WithLabelValues("failed").Inc()
+		slog.ErrorContext(ctx, "Ошибка отправки уведомления", "err", err)
+		return
+	}
+	metrics.NotificationsTotal.WithLabelValues("sent").Inc()
 
-	_, err = tg.Bot.Send(msgconf)
+	// Запоминаем уведомление, чтобы в хронологии дня были видны пропущенные ответы.
+	err = h.logs.AddPrompt(db.Prompt{
+		MessageID:       int64(sent.MessageID),
+		UserID:          user.ID,
+		ChatID:          user.ChatID,
+		SentAt:          sent.Time(),
+		IntervalMinutes: interval,
+	})
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка сохранения уведомления", "err", err)
 	}
+
+	h.collapseStalePrompts(ctx, user, int64(sent.MessageID), user.LastNotify.Time)
 }
 
-func LogUserActivityCallback(callback *tgbotapi.CallbackQuery) {
+func (h *Handlers) LogUserActivityCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	var nodeID int64
 
 	var timerMinutes int64
 
 	_, err := fmt.Sscanf(callback.Data, "activity_log %d %d", &nodeID, &timerMinutes)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	if h.rejectExpiredAnswer(ctx, callback) {
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
+	lang := userLang(*user)
 
 	isMuted := false
-	activities, err := db.GetSimpleActivities(common.UserID(callback.From.ID), &isMuted, nil)
+	activities, err := h.activities.GetSimpleActivities(user.ID, &isMuted, nil)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		return
 	}
 
 	idx := slices.IndexFunc(activities, func(a db.Activity) bool { return a.ID == nodeID })
 	if idx == -1 {
-		log.Fatalf("activity with id %d was not found in user activities.", nodeID)
+		slog.ErrorContext(ctx, "Активность не найдена среди активностей пользователя", "activity_id", nodeID)
+		return
 	}
 
 	if activities[idx].IsLeaf {
-		err = db.AddActivityLog(
-			db.ActivityLog{
-				MessageID:       int64(callback.Message.MessageID),
-				UserID:          callback.From.ID,
+		userID := common.UserID(callback.From.ID)
+		messageID := int64(callback.Message.MessageID)
+		// Длительность берём из самого уведомления: в адаптивном режиме она не равна
+		// текущему интервалу из callback data.
+		if prompt, err := h.logs.GetPrompt(userID, messageID); err == nil {
+			timerMinutes = prompt.IntervalMinutes
+		}
+		wasAnswered := false
+		if slot, err := h.logs.GetTimelineSlot(userID, messageID); err == nil {
+			wasAnswered = slot.Answered
+		}
+
+		err = h.logs.ReplaceActivityLogs(
+			common.UserID(callback.From.ID), int64(callback.Message.MessageID),
+			[]db.ActivityLog{{
 				ActivityID:      nodeID,
 				Timestamp:       callback.Message.Time(),
 				IntervalMinutes: timerMinutes,
-			},
+			}},
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка сохранения лога активности", "err", err)
+			return
+		}
+		if !wasAnswered {
+			h.adaptInterval(ctx, userID, nodeID, callback.Message.Time())
 		}
 
-		activityName, err := db.GetFullActivityNameByID(nodeID, common.UserID(callback.From.ID))
+		activityName, err := h.activities.GetFullActivityNameByID(nodeID, common.UserID(callback.From.ID))
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+			return
 		}
 
-		_, err = tg.Bot.Send(
+		_, err = h.sender.Send(
 			tgbotapi.NewEditMessageTextAndMarkup(
 				callback.Message.Chat.ID, callback.Message.MessageID,
-				"Saved activity \""+activityName+"\"",
-				tgbotapi.InlineKeyboardMarkup{InlineKeyboard: make([][]tgbotapi.InlineKeyboardButton, 0)},
+				i18n.T(lang, "notify.saved", activityName),
+				getSavedActivityKeyboardMarkup(lang),
 			),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+			return
 		}
 	} else {
-		user, err := db.GetUserByID(common.UserID(callback.From.ID))
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		isMuted := false
-		keyboard := buildActivitiesKeyboardMarkupForUser(
-			*user, nodeID, &isMuted, nil, "activity_log", getStandardActivitiesLastRow())
+		keyboard := h.buildActivitiesKeyboardMarkupForUser(
+			ctx, *user, nodeID, &isMuted, nil, "activity_log", getStandardActivitiesLastRow(lang))
 
-		_, err = tg.Bot.Send(
+		_, err = h.sender.Send(
 			tgbotapi.NewEditMessageTextAndMarkup(
 				callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text, keyboard,
 			),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+			return
 		}
 	}
 }
 
-func RefreshActivitiesCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+// ChangeActivityLogCallback снова показывает дерево активностей под сохранённым ответом,
+// чтобы выбрать другую активность. Длительность сохранённого ответа не меняется.
+func (h *Handlers) ChangeActivityLogCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	slot, err := h.logs.GetTimelineSlot(user.ID, int64(callback.Message.MessageID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения слота хронологии", "err", err)
+	} else if slot.IntervalMinutes > 0 {
+		user.TimerMinutes = sql.NullInt64{Int64: slot.IntervalMinutes, Valid: true}
+	}
+
+	isMuted := false
+	keyboard := h.buildActivitiesKeyboardMarkupForUser(
+		ctx, *user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow(userLang(*user)))
+
+	_, err = h.sender.Send(
+		tgbotapi.NewEditMessageTextAndMarkup(
+			callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text, keyboard,
+		),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// RemoveActivityLogCallback удаляет сохранённый ответ на уведомление и возвращает
+// уведомлению исходный вид, чтобы на него можно было ответить заново.
+func (h *Handlers) RemoveActivityLogCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	err = h.logs.DeleteActivityLog(user.ID, int64(callback.Message.MessageID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка удаления лога активности", "err", err)
+		return
+	}
+	h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "notify.removed")))
+
+	// На устаревшее уведомление заново ответить нельзя — сворачиваем его.
+	prompt, err := h.logs.GetPrompt(user.ID, int64(callback.Message.MessageID))
+	if err == nil && (prompt.ClosedAt.Valid || time.Since(prompt.SentAt) > promptExpiry) {
+		h.closePrompt(ctx, *prompt, time.Now())
+		return
+	}
+	sentAt, interval := callback.Message.Time(), user.TimerMinutes.Int64
+	if err == nil {
+		sentAt, interval = prompt.SentAt, prompt.IntervalMinutes
+	}
+
+	isMuted := false
+	keyboard := h.buildActivitiesKeyboardMarkupForUser(
+		ctx, *user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow(userLang(*user)))
+
+	_, err = h.sender.Send(
+		tgbotapi.NewEditMessageTextAndMarkup(
+			callback.Message.Chat.ID, callback.Message.MessageID, h.promptText(ctx, *user, sentAt, interval), keyboard,
+		),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+func (h *Handlers) RefreshActivitiesCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
 	isMuted := false
-	keyboard := buildActivitiesKeyboardMarkupForUser(
-		*user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow())
+	keyboard := h.buildActivitiesKeyboardMarkupForUser(
+		ctx, *user, -1, &isMuted, nil, "activity_log", getStandardActivitiesLastRow(userLang(*user)))
 
-	_, err = tg.Bot.Send(
+	_, err = h.sender.Send(
 		tgbotapi.NewEditMessageTextAndMarkup(
 			callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text, keyboard,
 		),
 	)
 	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+		return
 	}
 }
 
-func AddNewActivityCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) AddNewActivityCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	registerNewActivity(*user)
+	h.registerNewActivity(ctx, *user)
 }
 
-func buildActivitiesKeyboardMarkupForUser(
-	user db.User, parentActivityID int64, isMuted *bool, hasMutedLeaves *bool,
+func (h *Handlers) buildActivitiesKeyboardMarkupForUser(
+	ctx context.Context, user db.User, parentActivityID int64, isMuted *bool, hasMutedLeaves *bool,
 	callbackCommand string, lastRow []tgbotapi.InlineKeyboardButton) tgbotapi.InlineKeyboardMarkup {
-	activities, err := db.GetSimpleActivities(user.ID, isMuted, hasMutedLeaves)
+	activities, err := h.activities.GetSimpleActivities(user.ID, isMuted, hasMutedLeaves)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		// Оставляем только служебный ряд, чтобы можно было обновить список.
+		return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{lastRow}}
 	}
 
 	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
@@ -173,18 +312,19 @@ func buildActivitiesKeyboardMarkupForUser(
 	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
 }
 
-func getStandardActivitiesLastRow() []tgbotapi.InlineKeyboardButton {
-	newActivityCallbackText := "register_new_activity"
-	refreshActivitiesCallbackText := "refresh_activities"
-	return append(
-		make([]tgbotapi.InlineKeyboardButton, 0),
-		tgbotapi.InlineKeyboardButton{
-			Text:         "Add new activity",
-			CallbackData: &newActivityCallbackText,
-		},
-		tgbotapi.InlineKeyboardButton{
-			Text:         "\U0001F504 Refresh activities",
-			CallbackData: &refreshActivitiesCallbackText,
-		},
+func getStandardActivitiesLastRow(lang i18n.Lang) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.add_activity"), "register_new_activity"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.refresh"), "refresh_activities"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.split"), "split__start"),
+	)
+}
+
+func getSavedActivityKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.change"), "activity_log__change"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.remove"), "activity_log__remove"),
+		),
 	)
 }
@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestNotifyAndAnswer проходит путь уведомление → ответ по дереву активностей → аналитика.
+func TestNotifyAndAnswer(t *testing.T) {
+	ctx := context.Background()
+	h, repo, sender := newTestHandlers(t)
+
+	prompt, callback := notifyTestUser(t, h, repo, sender)
+	user, err := repo.GetUserByID(testNotifyUserID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if !user.LastNotify.Valid {
+		t.Error("last_notify is not set after notifyUser")
+	}
+
+	// Сначала выбираем область: клавиатура перестраивается, лог ещё не пишется.
+	keyboard := prompt.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	h.LogUserActivityCallback(ctx, callback(buttonData(t, keyboard, "Работа")))
+
+	sent := sender.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d messages after choosing a group, want 2", len(sent))
+	}
+	edit, ok := sent[1].(tgbotapi.EditMessageTextConfig)
+	if !ok {
+		t.Fatalf("sent %T, want tgbotapi.EditMessageTextConfig", sent[1])
+	}
+	h.LogUserActivityCallback(ctx, callback(buttonData(t, *edit.ReplyMarkup, "Код")))
+
+	sent = sender.Sent()
+	if len(sent) != 3 {
+		t.Fatalf("sent %d messages after choosing a leaf, want 3", len(sent))
+	}
+	saved, ok := sent[2].(tgbotapi.EditMessageTextConfig)
+	if !ok {
+		t.Fatalf("sent %T, want tgbotapi.EditMessageTextConfig", sent[2])
+	}
+	if want := i18n.T(i18n.Russian, "notify.saved", "Работа / Код"); saved.Text != want {
+		t.Errorf("saved text = %q, want %q", saved.Text, want)
+	}
+
+	now := time.Now()
+	logs, err := repo.GetActivityLogs(testNotifyUserID, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetActivityLogs: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("got %d activity logs, want 1", len(logs))
+	}
+	if logs[0].MessageID != 1 || logs[0].IntervalMinutes != 30 {
+		t.Errorf("log = %+v, want message 1 with 30 minutes", logs[0])
+	}
+	name, err := repo.GetFullActivityNameByID(logs[0].ActivityID, testNotifyUserID)
+	if err != nil || name != "Работа / Код" {
+		t.Errorf("logged activity = %q (%v), want %q", name, err, "Работа / Код")
+	}
+
+	durations, err := repo.GetLogDurations(testNotifyUserID, now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetLogDurations: %v", err)
+	}
+	if got := durations[logs[0].ActivityID]; got != 30 {
+		t.Errorf("duration of the answered activity = %v, want 30", got)
+	}
+}
+
+// TestChangeAndRemoveAnswer проверяет кнопки изменения и удаления под сохранённым ответом.
+func TestChangeAndRemoveAnswer(t *testing.T) {
+	ctx := context.Background()
+	h, repo, sender := newTestHandlers(t)
+	prompt, callback := notifyTestUser(t, h, repo, sender)
+
+	answer := func(keyboard tgbotapi.InlineKeyboardMarkup) tgbotapi.EditMessageTextConfig {
+		t.Helper()
+		h.LogUserActivityCallback(ctx, callback(buttonData(t, keyboard, "Работа")))
+		h.LogUserActivityCallback(ctx, callback(buttonData(t, *lastEdit(t, sender).ReplyMarkup, "Код")))
+		return lastEdit(t, sender)
+	}
+	countLogs := func() int {
+		t.Helper()
+		now := time.Now()
+		logs, err := repo.GetActivityLogs(testNotifyUserID, now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("GetActivityLogs: %v", err)
+		}
+		return len(logs)
+	}
+
+	saved := answer(prompt.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup))
+	changeData := buttonData(t, *saved.ReplyMarkup, i18n.T(i18n.Russian, "button.change"))
+	removeData := buttonData(t, *saved.ReplyMarkup, i18n.T(i18n.Russian, "button.remove"))
+
+	// Изменение снова показывает дерево, а ответ остаётся записанным до выбора новой активности.
+	h.ChangeActivityLogCallback(ctx, callback(changeData))
+	tree := lastEdit(t, sender)
+	buttonData(t, *tree.ReplyMarkup, "Работа")
+	if got := countLogs(); got != 1 {
+		t.Errorf("got %d logs after change, want 1", got)
+	}
+
+	saved = answer(*tree.ReplyMarkup)
+	if got := countLogs(); got != 1 {
+		t.Errorf("got %d logs after answering again, want 1", got)
+	}
+
+	// Удаление стирает ответ и возвращает уведомлению клавиатуру с деревом.
+	h.RemoveActivityLogCallback(ctx, callback(removeData))
+	if got := countLogs(); got != 0 {
+		t.Errorf("got %d logs after remove, want 0", got)
+	}
+	restored := lastEdit(t, sender)
+	if restored.Text != prompt.Text {
+		t.Errorf("restored text = %q, want %q", restored.Text, prompt.Text)
+	}
+	buttonData(t, *restored.ReplyMarkup, "Работа")
+
+	requests := sender.Requests()
+	answered, ok := requests[len(requests)-1].(tgbotapi.CallbackConfig)
+	if !ok || answered.Text != i18n.T(i18n.Russian, "notify.removed") {
+		t.Errorf("last request = %+v, want callback answer %q", requests[len(requests)-1],
+			i18n.T(i18n.Russian, "notify.removed"))
+	}
+}
+
+// testNotifyUserID — пользователь, которого создаёт notifyTestUser.
+const testNotifyUserID common.UserID = 42
+
+// notifyTestUser создаёт пользователя с активностью "Работа / Код" и присылает ему
+// уведомление. Возвращает уведомление и конструктор callback-ов нажатий на его кнопки.
+func notifyTestUser(
+	t *testing.T, h *Handlers, repo db.Repository, sender *bot.FakeSender,
+) (tgbotapi.MessageConfig, func(data string) *tgbotapi.CallbackQuery) {
+	t.Helper()
+
+	err := repo.AddUser(db.User{
+		ID:           testNotifyUserID,
+		ChatID:       common.ChatID(testNotifyUserID),
+		TimerEnabled: true,
+		TimerMinutes: sql.NullInt64{Int64: 30, Valid: true},
+		Language:     string(i18n.Russian),
+	})
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	if err := repo.ParseAndAddActivity(testNotifyUserID, "Работа / Код"); err != nil {
+		t.Fatalf("ParseAndAddActivity: %v", err)
+	}
+	user, err := repo.GetUserByID(testNotifyUserID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+
+	h.notifyUser(context.Background(), *user)
+
+	sent := sender.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages after notifyUser, want 1", len(sent))
+	}
+	prompt, ok := sent[0].(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatalf("sent %T, want tgbotapi.MessageConfig", sent[0])
+	}
+	if prompt.ChatID != int64(testNotifyUserID) {
+		t.Errorf("prompt chat = %d, want %d", prompt.ChatID, testNotifyUserID)
+	}
+
+	message := &tgbotapi.Message{
+		MessageID: 1,
+		Chat:      &tgbotapi.Chat{ID: int64(testNotifyUserID)},
+		Date:      int(time.Now().Unix()),
+		Text:      prompt.Text,
+	}
+	callback := func(data string) *tgbotapi.CallbackQuery {
+		return &tgbotapi.CallbackQuery{
+			ID: "callback", From: &tgbotapi.User{ID: int64(testNotifyUserID)}, Message: message, Data: data,
+		}
+	}
+	return prompt, callback
+}
+
+// lastEdit возвращает последнее отправленное редактирование сообщения.
+func lastEdit(t *testing.T, sender *bot.FakeSender) tgbotapi.EditMessageTextConfig {
+	t.Helper()
+
+	sent := sender.Sent()
+	edit, ok := sent[len(sent)-1].(tgbotapi.EditMessageTextConfig)
+	if !ok {
+		t.Fatalf("last sent %T, want tgbotapi.EditMessageTextConfig", sent[len(sent)-1])
+	}
+	return edit
+}
@@ -0,0 +1,292 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxPromptTemplateLength — самый длинный шаблон текста уведомлений в символах.
+const maxPromptTemplateLength = 300
+
+// maxPromptStreakDays — на сколько дней назад считается серия дней с ответами:
+// плейсхолдер {streak} не должен заставлять читать всю историю при каждом уведомлении.
+const maxPromptStreakDays = 100
+
+// promptTones — тоны текста уведомлений в порядке показа в /prompt.
+var promptTones = []string{db.PromptTonePlayful, db.PromptToneNeutral, db.PromptToneWork}
+
+// Плейсхолдеры шаблона текста уведомлений.
+const (
+	placeholderFrom     = "{from}"
+	placeholderTo       = "{to}"
+	placeholderInterval = "{interval}"
+	placeholderStreak   = "{streak}"
+	placeholderLast     = "{last}"
+)
+
+var (
+	promptPlaceholders = []string{
+		placeholderFrom, placeholderTo, placeholderInterval, placeholderStreak, placeholderLast,
+	}
+	promptPlaceholderPattern = regexp.MustCompile(`\{[^{}\s]*\}`)
+)
+
+// promptTemplate возвращает шаблон текста уведомлений пользователя: собственный
+// шаблон или шаблон выбранного тона на языке пользователя.
+func promptTemplate(user db.User) string {
+	if user.PromptTemplate != "" {
+		return user.PromptTemplate
+	}
+	return i18n.T(userLang(user), "prompt.tone_"+promptToneOrDefault(user.PromptTone))
+}
+
+// promptToneOrDefault возвращает тон пользователя или шуточный тон, если тон неизвестен.
+func promptToneOrDefault(tone string) string {
+	if slices.Contains(promptTones, tone) {
+		return tone
+	}
+	return db.PromptTonePlayful
+}
+
+// promptText формирует текст уведомления, отправленного в sentAt и спрашивающего
+// об интервале длиной intervalMinutes. Серия дней и последняя активность считаются
+// только если они есть в шаблоне, и только по ответам до sentAt, поэтому текст
+// можно пересобрать для уже отправленного уведомления.
+func (h *Handlers) promptText(ctx context.Context, user db.User, sentAt time.Time, intervalMinutes int64) string {
+	lang := userLang(user)
+	template := promptTemplate(user)
+	sentAt = sentAt.In(h.location())
+	from := sentAt.Add(-time.Duration(intervalMinutes) * time.Minute).Format("15:04")
+	to := sentAt.Format("15:04")
+
+	replacements := []string{
+		placeholderFrom, from,
+		placeholderTo, to,
+		placeholderInterval, formatMinutes(lang, intervalMinutes),
+	}
+	if strings.Contains(template, placeholderStreak) {
+		replacements = append(replacements, placeholderStreak, strconv.Itoa(h.answerStreak(ctx, user.ID, sentAt)))
+	}
+	if strings.Contains(template, placeholderLast) {
+		replacements = append(replacements, placeholderLast, h.lastActivityName(ctx, user, sentAt))
+	}
+	text := strings.NewReplacer(replacements...).Replace(template)
+
+	if user.PromptShowSince {
+		text += i18n.T(lang, "prompt.since", from, to, sentAt.Format("MST"))
+	}
+	return text
+}
+
+// answerStreak считает, сколько дней подряд (по часовому поясу бота) до дня before
+// включительно у пользователя есть ответы.
+func (h *Handlers) answerStreak(ctx context.Context, userID common.UserID, before time.Time) int {
+	today := startOfDay(before, h.location())
+	logs, err := h.logs.GetActivityLogs(userID, today.AddDate(0, 0, -maxPromptStreakDays), before.Add(-time.Second))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения логов активностей", "err", err)
+		return 0
+	}
+
+	timestamps := make([]time.Time, 0, len(logs))
+	for _, activityLog := range logs {
+		timestamps = append(timestamps, activityLog.Timestamp)
+	}
+	return countStreak(timestamps, today)
+}
+
+// countStreak считает, сколько дней подряд до дня today (полночь в часовом поясе,
+// в котором считаются дни) включительно есть хотя бы одна метка из timestamps.
+// День today ещё не закончился, поэтому без меток он серию не прерывает.
+func countStreak(timestamps []time.Time, today time.Time) int {
+	answered := make(map[time.Time]bool)
+	for _, timestamp := range timestamps {
+		answered[startOfDay(timestamp, today.Location())] = true
+	}
+
+	day := today
+	if !answered[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for answered[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// lastActivityName возвращает полное название активности последнего ответа за сутки до before.
+func (h *Handlers) lastActivityName(ctx context.Context, user db.User, before time.Time) string {
+	logs, err := h.logs.GetActivityLogs(user.ID, before.Add(-Day), before.Add(-time.Second))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения логов активностей", "err", err)
+	}
+	if len(logs) == 0 {
+		return i18n.T(userLang(user), "prompt.no_last")
+	}
+
+	name, err := h.activities.GetFullActivityNameByID(logs[len(logs)-1].ActivityID, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+		return i18n.T(userLang(user), "prompt.no_last")
+	}
+	return name
+}
+
+// parsePromptTemplate проверяет шаблон текста уведомлений из /prompt <текст>.
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func parsePromptTemplate(lang i18n.Lang, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New(i18n.T(lang, "prompt.error_empty"))
+	}
+	if utf8.RuneCountInString(text) > maxPromptTemplateLength {
+		return "", errors.New(i18n.T(lang, "prompt.error_too_long", maxPromptTemplateLength))
+	}
+	for _, placeholder := range promptPlaceholderPattern.FindAllString(text, -1) {
+		if !slices.Contains(promptPlaceholders, placeholder) {
+			return "", errors.New(i18n.T(lang, "prompt.error_placeholder", placeholder))
+		}
+	}
+	return text, nil
+}
+
+// PromptCommand обрабатывает команду /prompt: без аргументов показывает настройки
+// текста уведомлений, "/prompt <текст>" сохраняет собственный шаблон.
+func (h *Handlers) PromptCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+	lang := userLang(*user)
+
+	if arguments := message.CommandArguments(); arguments != "" {
+		template, err := parsePromptTemplate(lang, arguments)
+		if err != nil {
+			h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "prompt.parse_error", err)))
+			return
+		}
+		user.PromptTemplate = template
+		if err := h.users.UpdateUserColumns(user.ID, promptColumns(*user)); err != nil {
+			slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+			return
+		}
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "prompt.saved")))
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), h.promptSettingsText(ctx, *user))
+	msgconf.ReplyMarkup = getPromptSettingsKeyboardMarkup(*user)
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// PromptToneCallback выбирает тон текста уведомлений; собственный шаблон при этом
+// сбрасывается. Callback data: "prompt__tone <тон>".
+func (h *Handlers) PromptToneCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	tone := strings.TrimPrefix(callback.Data, "prompt__tone ")
+	if !slices.Contains(promptTones, tone) {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "data", callback.Data)
+		return
+	}
+
+	h.updatePromptSettings(ctx, callback, func(user *db.User) {
+		user.PromptTone = tone
+		user.PromptTemplate = ""
+	})
+}
+
+// PromptSinceCallback включает и выключает интервал в тексте уведомлений.
+func (h *Handlers) PromptSinceCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	h.updatePromptSettings(ctx, callback, func(user *db.User) {
+		user.PromptShowSince = !user.PromptShowSince
+	})
+}
+
+// updatePromptSettings применяет change к настройкам пользователя и обновляет сообщение
+// с настройками текста уведомлений.
+func (h *Handlers) updatePromptSettings(
+	ctx context.Context, callback *tgbotapi.CallbackQuery, change func(user *db.User),
+) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	change(user)
+	if err := h.users.UpdateUserColumns(user.ID, promptColumns(*user)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		h.promptSettingsText(ctx, *user), getPromptSettingsKeyboardMarkup(*user)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// promptColumns возвращает столбцы настроек текста уведомлений. Пишем только их:
+// строку пользователя одновременно меняют рассылка и адаптивный интервал.
+func promptColumns(user db.User) map[string]any {
+	return map[string]any{
+		"prompt_tone":       user.PromptTone,
+		"prompt_template":   user.PromptTemplate,
+		"prompt_show_since": user.PromptShowSince,
+	}
+}
+
+// promptSettingsText описывает настройки текста уведомлений с примером уведомления,
+// которое пришло бы сейчас.
+func (h *Handlers) promptSettingsText(ctx context.Context, user db.User) string {
+	lang := userLang(user)
+	tone := i18n.T(lang, "prompt.custom")
+	if user.PromptTemplate == "" {
+		tone = i18n.T(lang, "prompt.tone_name_"+promptToneOrDefault(user.PromptTone))
+	}
+	since := i18n.T(lang, "prompt.since_off")
+	if user.PromptShowSince {
+		since = i18n.T(lang, "prompt.since_on")
+	}
+	preview := h.promptText(ctx, user, time.Now(), notifyIntervalMinutes(user))
+	return i18n.T(lang, "prompt.settings", tone, since, preview)
+}
+
+func getPromptSettingsKeyboardMarkup(user db.User) tgbotapi.InlineKeyboardMarkup {
+	lang := userLang(user)
+	tones := make([]tgbotapi.InlineKeyboardButton, 0, len(promptTones))
+	for _, tone := range promptTones {
+		text := i18n.T(lang, "prompt.tone_name_"+tone)
+		if user.PromptTemplate == "" && promptToneOrDefault(user.PromptTone) == tone {
+			text = "• " + text
+		}
+		tones = append(tones, tgbotapi.NewInlineKeyboardButtonData(text, "prompt__tone "+tone))
+	}
+
+	since := i18n.T(lang, "prompt.button_since_on")
+	if user.PromptShowSince {
+		since = i18n.T(lang, "prompt.button_since_off")
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tones,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(since, "prompt__since")),
+	)
+}
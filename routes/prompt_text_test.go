@@ -0,0 +1,203 @@
+package routes
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParsePromptTemplate(t *testing.T) {
+	lang := i18n.Russian
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "plain text", text: "  Что делаешь?  ", want: "Что делаешь?"},
+		{name: "all placeholders", text: "{from}–{to} ({interval}), серия {streak}, до этого {last}",
+			want: "{from}–{to} ({interval}), серия {streak}, до этого {last}"},
+		{name: "braces with spaces are text", text: "{ не плейсхолдер }", want: "{ не плейсхолдер }"},
+		{name: "empty", text: "   ", wantErr: i18n.T(lang, "prompt.error_empty")},
+		{name: "unknown placeholder", text: "С {start} до {to}",
+			wantErr: i18n.T(lang, "prompt.error_placeholder", "{start}")},
+		{name: "longest allowed", text: strings.Repeat("я", maxPromptTemplateLength),
+			want: strings.Repeat("я", maxPromptTemplateLength)},
+		{name: "too long", text: strings.Repeat("я", maxPromptTemplateLength+1),
+			wantErr: i18n.T(lang, "prompt.error_too_long", maxPromptTemplateLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePromptTemplate(lang, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parsePromptTemplate(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parsePromptTemplate(%q) = (%q, %v), want %q", tt.text, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountStreak(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	today := time.Date(2024, 5, 10, 0, 0, 0, 0, moscow)
+	at := func(daysAgo, hour int) time.Time {
+		// Метки хранятся в UTC, дни считаются по Москве.
+		return today.AddDate(0, 0, -daysAgo).Add(time.Duration(hour) * time.Hour).UTC()
+	}
+
+	tests := []struct {
+		name       string
+		timestamps []time.Time
+		want       int
+	}{
+		{name: "no answers", want: 0},
+		{name: "only today", timestamps: []time.Time{at(0, 10)}, want: 1},
+		{name: "today not answered yet", timestamps: []time.Time{at(1, 10), at(2, 10)}, want: 2},
+		{name: "three days with today", timestamps: []time.Time{at(0, 9), at(1, 12), at(1, 15), at(2, 20)}, want: 3},
+		{name: "gap breaks the streak", timestamps: []time.Time{at(0, 10), at(2, 10), at(3, 10)}, want: 1},
+		{name: "yesterday missed", timestamps: []time.Time{at(2, 10), at(3, 10)}, want: 0},
+		// 01:00 по Москве — ещё вчера по UTC, но день считается московский.
+		{name: "after midnight in the bot zone", timestamps: []time.Time{at(0, 1), at(1, 1)}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countStreak(tt.timestamps, today); got != tt.want {
+				t.Errorf("countStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptText(t *testing.T) {
+	const userID common.UserID = 17
+	h, repo, _ := newTestHandlers(t)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	h.settings.Location = moscow
+	sentAt := time.Date(2024, 5, 10, 10, 0, 0, 0, moscow)
+
+	if err := repo.AddUser(db.User{ID: userID, ChatID: 17, Language: string(i18n.Russian)}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	activityID, err := repo.FindOrAddActivityPath(userID, "Работа / Код")
+	if err != nil {
+		t.Fatalf("FindOrAddActivityPath: %v", err)
+	}
+	for i, timestamp := range []time.Time{sentAt.AddDate(0, 0, -1), sentAt.Add(-30 * time.Minute)} {
+		err := repo.AddActivityLog(db.ActivityLog{
+			MessageID: int64(i + 1), UserID: int64(userID), ActivityID: activityID, Timestamp: timestamp, IntervalMinutes: 30,
+		})
+		if err != nil {
+			t.Fatalf("AddActivityLog: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		user db.User
+		want string
+	}{
+		{
+			name: "custom template",
+			user: db.User{PromptTemplate: "{from}–{to} ({interval}), серия {streak}, до этого {last}"},
+			want: "09:00–10:00 (" + formatMinutes(i18n.Russian, 60) + "), серия 2, до этого Работа / Код",
+		},
+		{
+			name: "neutral tone",
+			user: db.User{PromptTone: db.PromptToneNeutral},
+			want: strings.ReplaceAll(i18n.T(i18n.Russian, "prompt.tone_neutral"),
+				placeholderInterval, formatMinutes(i18n.Russian, 60)),
+		},
+		{
+			name: "unknown tone with interval",
+			user: db.User{PromptTone: "grumpy", PromptShowSince: true},
+			want: i18n.T(i18n.Russian, "prompt.tone_playful") + i18n.T(i18n.Russian, "prompt.since", "09:00", "10:00", "MSK"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			user.ID, user.Language = userID, string(i18n.Russian)
+			// Время отправки передаётся в UTC: текст показывается в часовом поясе бота.
+			if got := h.promptText(context.Background(), user, sentAt.UTC(), 60); got != tt.want {
+				t.Errorf("promptText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptSettingsUpdates(t *testing.T) {
+	const userID common.UserID = 6
+	ctx := context.Background()
+	h, repo, _, users := newColumnTestHandlers(t)
+	if err := repo.AddUser(db.User{ID: userID, ChatID: 6, Language: string(i18n.Russian)}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	command := func(text string) {
+		h.PromptCommand(ctx, &tgbotapi.Message{
+			From:     &tgbotapi.User{ID: int64(userID)},
+			Chat:     &tgbotapi.Chat{ID: int64(userID)},
+			Text:     text,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/prompt")}},
+		})
+	}
+	callback := func(handler func(context.Context, *tgbotapi.CallbackQuery), data string) func() {
+		return func() {
+			handler(ctx, &tgbotapi.CallbackQuery{
+				ID:      "1",
+				From:    &tgbotapi.User{ID: int64(userID)},
+				Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: int64(userID)}},
+				Data:    data,
+			})
+		}
+	}
+
+	tests := []struct {
+		name         string
+		update       func()
+		wantTone     string
+		wantTemplate string
+		wantSince    bool
+	}{
+		{name: "template", update: func() { command("/prompt Чем занят?") }, wantTone: db.PromptTonePlayful,
+			wantTemplate: "Чем занят?"},
+		{name: "tone resets the template", update: callback(h.PromptToneCallback, "prompt__tone work"),
+			wantTone: db.PromptToneWork},
+		{name: "since on", update: callback(h.PromptSinceCallback, "prompt__since"), wantTone: db.PromptToneWork,
+			wantSince: true},
+	}
+
+	for _, tt := range tests {
+		users.columns = nil
+		tt.update()
+
+		user, err := repo.GetUserByID(userID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if user.PromptTone != tt.wantTone || user.PromptTemplate != tt.wantTemplate ||
+			user.PromptShowSince != tt.wantSince {
+			t.Errorf("%s: tone %q, template %q, since %v, want %q, %q, %v", tt.name,
+				user.PromptTone, user.PromptTemplate, user.PromptShowSince, tt.wantTone, tt.wantTemplate, tt.wantSince)
+		}
+		if want := []string{"prompt_show_since", "prompt_template", "prompt_tone"}; !slices.Equal(users.columns, want) {
+			t.Errorf("%s: wrote columns %v, want %v", tt.name, users.columns, want)
+		}
+	}
+}
@@ -1,68 +1,76 @@
 package routes
 
 import (
-	"log"
+	"context"
+	"log/slog"
 
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func RegisterNewActivityCommand(message *tgbotapi.Message) {
+func (h *Handlers) RegisterNewActivityCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
 	}
 
-	user, err := db.GetUserByID(common.UserID(tgUser.ID))
+	user, err := h.users.GetUserByID(common.UserID(tgUser.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	registerNewActivity(*user)
+	h.registerNewActivity(ctx, *user)
 }
 
-func registerNewActivity(user db.User) {
-	userState := common.UserStates[user.ID]
+func (h *Handlers) registerNewActivity(ctx context.Context, user db.User) {
+	lang := userLang(user)
+	userState := common.GetUserState(user.ID)
 
 	if userState.State == common.InCommand {
-		_, err := bot.Bot.Send(
-			tgbotapi.NewMessage(int64(user.ChatID), "You're already executing some command"),
+		_, err := h.sender.Send(
+			tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "common.already_in_command")),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+			return
 		}
 	}
 
 	userState.State = common.InCommand
-	waitChan := make(chan string)
-	common.UserStates[user.ID] = common.UserState{State: common.InCommand, WaitingChannel: &waitChan}
+	waitChan := make(chan string, 1)
+	common.SetUserState(user.ID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(user.ID, common.UserState{State: common.Idle, WaitingChannel: nil})
 
-	reply := tgbotapi.NewMessage(int64(user.ChatID), "Write new activity")
+	reply := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.ask_new"))
 	forceReply := tgbotapi.ForceReply{ForceReply: true}
 	reply.ReplyMarkup = forceReply
 
-	_, err := bot.Bot.Send(reply)
+	_, err := h.sender.Send(reply)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 
-	ans := <-waitChan
-	err = db.ParseAndAddActivity(user.ID, ans)
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+	err = h.activities.ParseAndAddActivity(user.ID, ans)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка добавления активности", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.add_error")))
+		return
 	}
 
-	close(waitChan)
-
-	common.UserStates[user.ID] = common.UserState{State: common.Idle, WaitingChannel: nil}
+	reply = tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "activities.added", ans))
 
-	reply = tgbotapi.NewMessage(int64(user.ChatID), "New activity \""+ans+"\" added!")
-
-	_, err = bot.Bot.Send(reply)
+	_, err = h.sender.Send(reply)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
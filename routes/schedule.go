@@ -0,0 +1,429 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// weekdayOrder — дни недели в порядке показа пользователю.
+var weekdayOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+// weekdayName возвращает короткое название дня недели на языке lang.
+func weekdayName(lang i18n.Lang, weekday time.Weekday) string {
+	return i18n.T(lang, fmt.Sprintf("weekday.%d", weekday))
+}
+
+// dailyWindow — окно уведомлений на один день в минутах от полуночи по часовому поясу бота.
+// Если finish <= start, окно продолжается после полуночи (start == finish — круглые сутки).
+type dailyWindow struct {
+	enabled bool
+	custom  bool
+	start   int64
+	finish  int64
+}
+
+// userWindows возвращает окна уведомлений пользователя по дням недели: общее
+// расписание из /settings, поверх которого наложены расписания из /schedule.
+func (h *Handlers) userWindows(user db.User) ([7]dailyWindow, error) {
+	var windows [7]dailyWindow
+	for i := range windows {
+		windows[i] = dailyWindow{
+			enabled: true,
+			start:   user.ScheduleMorningStartHour.Int64*60 + user.ScheduleMorningStartMinute,
+			finish:  user.ScheduleEveningFinishHour.Int64*60 + user.ScheduleEveningFinishMinute,
+		}
+	}
+
+	schedules, err := h.users.GetWeekdaySchedules(user.ID)
+	if err != nil {
+		return windows, err
+	}
+	for _, schedule := range schedules {
+		windows[schedule.Weekday] = dailyWindow{
+			enabled: schedule.Enabled,
+			custom:  true,
+			start:   schedule.StartMinute,
+			finish:  schedule.FinishMinute,
+		}
+	}
+	return windows, nil
+}
+
+// startOfDay возвращает полночь дня, в который попадает ts, в часовом поясе loc.
+func startOfDay(ts time.Time, loc *time.Location) time.Time {
+	year, month, day := ts.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// scheduleDay проверяет, попадает ли ts в окно уведомлений, и возвращает начало дня
+// (полночь в loc), к которому относится окно: время после полуночи в окне, начавшемся
+// накануне, относится ко вчерашнему дню. Окна задаются в часовом поясе loc.
+func scheduleDay(windows [7]dailyWindow, ts time.Time, loc *time.Location) (time.Time, bool) {
+	ts = ts.In(loc)
+	today := startOfDay(ts, loc)
+	minute := int64(ts.Hour()*60 + ts.Minute())
+
+	window := windows[ts.Weekday()]
+	if window.enabled {
+		if window.start < window.finish && minute >= window.start && minute < window.finish {
+			return today, true
+		}
+		if window.start >= window.finish && minute >= window.start {
+			return today, true
+		}
+	}
+
+	yesterday := today.AddDate(0, 0, -1)
+	previous := windows[yesterday.Weekday()]
+	if previous.enabled && previous.start >= previous.finish && minute < previous.finish {
+		return yesterday, true
+	}
+	return time.Time{}, false
+}
+
+// formatWindow возвращает окно в виде "09:30–18:00".
+func formatWindow(lang i18n.Lang, window dailyWindow) string {
+	if !window.enabled {
+		return i18n.T(lang, "schedule.window_off")
+	}
+	if window.start == window.finish {
+		return i18n.T(lang, "schedule.window_all_day", formatClock(window.start))
+	}
+	return formatClock(window.start) + "–" + formatClock(window.finish)
+}
+
+func formatClock(minute int64) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// parseTimeWindow разбирает окно вида "9:30-18:00" в минуты от полуночи.
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func parseTimeWindow(lang i18n.Lang, text string) (int64, int64, error) {
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == '-' || r == '–' || r == '—' })
+	if len(parts) != 2 {
+		return 0, 0, errors.New(i18n.T(lang, "schedule.error_window"))
+	}
+
+	var bounds [2]int64
+	for i, part := range parts {
+		minute, err := parseClock(lang, part)
+		if err != nil {
+			return 0, 0, err
+		}
+		bounds[i] = minute
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// parseClock разбирает время вида "9:30" или "9" (целый час) в минуты от полуночи.
+func parseClock(lang i18n.Lang, text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	layout := "15:04"
+	if !strings.Contains(text, ":") {
+		layout = "15"
+	}
+	clock, err := time.Parse(layout, text)
+	if err != nil {
+		return 0, errors.New(i18n.T(lang, "schedule.error_clock", text))
+	}
+	return int64(clock.Hour()*60 + clock.Minute()), nil
+}
+
+// ScheduleCommand обрабатывает команду /schedule: расписание уведомлений по дням недели.
+func (h *Handlers) ScheduleCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+	lang := userLang(*user)
+	if !user.ScheduleMorningStartHour.Valid || !user.ScheduleEveningFinishHour.Valid {
+		_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "schedule.not_configured")))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	text, err := h.scheduleText(*user)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения расписания", "err", err)
+		return
+	}
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), text)
+	msgconf.ReplyMarkup = getScheduleKeyboardMarkup(lang)
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// ScheduleEditCallback спрашивает новое окно уведомлений для выбранных дней недели.
+// Callback data: "schedule__edit <дни>", где дни — цифры time.Weekday ("12345" — будни).
+func (h *Handlers) ScheduleEditCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var digits string
+	_, err := fmt.Sscanf(callback.Data, "schedule__edit %s", &digits)
+	weekdays := parseWeekdays(digits)
+	if err != nil || len(weekdays) == 0 {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	if common.GetUserState(user.ID).State == common.InCommand {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "common.finish_command")))
+		return
+	}
+
+	waitChan := make(chan string, 1)
+	common.SetUserState(user.ID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(user.ID, common.UserState{State: common.Idle, WaitingChannel: nil})
+
+	reply := tgbotapi.NewMessage(int64(user.ChatID),
+		i18n.T(lang, "schedule.ask_window", h.zoneName(), weekdaysText(lang, weekdays)))
+	reply.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	_, err = h.sender.Send(reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(ans))
+	for _, weekday := range weekdays {
+		switch answer {
+		case "общее", "default":
+			err = h.users.DeleteWeekdaySchedule(user.ID, weekday)
+		case "выкл", "off":
+			err = h.users.SetWeekdaySchedule(db.WeekdaySchedule{UserID: user.ID, Weekday: weekday})
+		default:
+			var start, finish int64
+			start, finish, err = parseTimeWindow(lang, answer)
+			if err != nil {
+				_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID),
+					i18n.T(lang, "schedule.parse_error", err.Error())))
+				if err != nil {
+					slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+				}
+				return
+			}
+			err = h.users.SetWeekdaySchedule(db.WeekdaySchedule{
+				UserID:       user.ID,
+				Weekday:      weekday,
+				Enabled:      true,
+				StartMinute:  start,
+				FinishMinute: finish,
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка сохранения расписания", "err", err)
+			return
+		}
+	}
+
+	text, err := h.scheduleText(*user)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения расписания", "err", err)
+		return
+	}
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID, text, getScheduleKeyboardMarkup(lang)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+func (h *Handlers) scheduleText(user db.User) (string, error) {
+	windows, err := h.userWindows(user)
+	if err != nil {
+		return "", err
+	}
+
+	lang := userLang(user)
+	var text strings.Builder
+	text.WriteString(i18n.T(lang, "schedule.title", h.zoneName()))
+	for _, weekday := range weekdayOrder {
+		window := windows[weekday]
+		note := ""
+		if !window.custom {
+			note = i18n.T(lang, "schedule.default_note")
+		}
+		fmt.Fprintf(&text, "%s: %s%s\n", weekdayName(lang, weekday), formatWindow(lang, window), note)
+	}
+	text.WriteString(i18n.T(lang, "schedule.hint"))
+	return text.String(), nil
+}
+
+func getScheduleKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	dayButton := func(weekday time.Weekday) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(weekdayName(lang, weekday), fmt.Sprintf("schedule__edit %d", weekday))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			dayButton(time.Monday), dayButton(time.Tuesday), dayButton(time.Wednesday), dayButton(time.Thursday),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			dayButton(time.Friday), dayButton(time.Saturday), dayButton(time.Sunday),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.button_weekdays"), "schedule__edit 12345"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.button_weekend"), "schedule__edit 60"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "schedule.button_all_days"), "schedule__edit 1234560"),
+		),
+	)
+}
+
+// parseWeekdays разбирает цифры time.Weekday из callback data.
+func parseWeekdays(digits string) []time.Weekday {
+	weekdays := make([]time.Weekday, 0, len(digits))
+	for _, digit := range digits {
+		if digit < '0' || digit > '6' {
+			return nil
+		}
+		weekdays = append(weekdays, time.Weekday(digit-'0'))
+	}
+	return weekdays
+}
+
+func weekdaysText(lang i18n.Lang, weekdays []time.Weekday) string {
+	names := make([]string, 0, len(weekdays))
+	for _, weekday := range weekdays {
+		names = append(names, weekdayName(lang, weekday))
+	}
+	return strings.Join(names, ", ")
+}
+
+// DayOffCommand обрабатывает команду /day_off: без аргументов — выходной сегодня,
+// "/day_off 2025-01-31" — выходной в этот день, "/day_off 2025-01-31 2025-02-09" — отпуск.
+func (h *Handlers) DayOffCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := common.UserID(message.From.ID)
+	lang := h.langOf(userID)
+	args := strings.Fields(message.Text)[1:]
+	start := startOfDay(time.Now(), h.location())
+	end := start
+	var err error
+	if len(args) >= 1 {
+		start, err = time.ParseInLocation(time.DateOnly, args[0], h.location())
+		end = start
+	}
+	if len(args) == 2 && err == nil {
+		end, err = time.ParseInLocation(time.DateOnly, args[1], h.location())
+	}
+	if len(args) > 2 || err != nil || end.Before(start) {
+		_, err = h.sender.Send(tgbotapi.NewMessage(message.Chat.ID, i18n.T(lang, "day_off.usage")))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	err = h.users.AddDayOff(db.DayOff{UserID: userID, StartDate: start, EndDate: end})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения выходного", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(message.Chat.ID, i18n.T(lang, "day_off.save_error")))
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewMessage(message.Chat.ID, i18n.T(lang, "day_off.saved",
+		formatDayOff(db.DayOff{StartDate: start, EndDate: end}))))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// DaysOffCommand обрабатывает команду /days_off: список предстоящих выходных.
+func (h *Handlers) DaysOffCommand(ctx context.Context, message *tgbotapi.Message) {
+	text, markup, err := h.daysOffMessage(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения выходных", "err", err)
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(message.Chat.ID, text)
+	if markup != nil {
+		msgconf.ReplyMarkup = *markup
+	}
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// DayOffDeleteCallback удаляет выходной. Callback data: "dayoff__delete <id>".
+func (h *Handlers) DayOffDeleteCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(callback.Data, "dayoff__delete "), 10, 64)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	userID := common.UserID(callback.From.ID)
+	if err := h.users.DeleteDayOff(userID, id); err != nil {
+		slog.ErrorContext(ctx, "Ошибка удаления выходного", "err", err)
+		return
+	}
+	h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(h.langOf(userID), "notify.removed")))
+
+	text, markup, err := h.daysOffMessage(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения выходных", "err", err)
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	edit.ReplyMarkup = markup
+	_, err = h.sender.Send(edit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+func (h *Handlers) daysOffMessage(userID common.UserID) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	daysOff, err := h.users.GetDaysOff(userID, time.Now().In(h.location()))
+	if err != nil {
+		return "", nil, err
+	}
+	lang := h.langOf(userID)
+	if len(daysOff) == 0 {
+		return i18n.T(lang, "day_off.none"), nil, nil
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(daysOff))
+	for _, dayOff := range daysOff {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			"🗑 "+formatDayOff(dayOff), fmt.Sprintf("dayoff__delete %d", dayOff.ID))))
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return i18n.T(lang, "day_off.list"), &markup, nil
+}
+
+// formatDayOff форматирует даты выходного; в базе они хранятся как полночь UTC.
+func formatDayOff(dayOff db.DayOff) string {
+	start, end := dayOff.StartDate.UTC().Format(time.DateOnly), dayOff.EndDate.UTC().Format(time.DateOnly)
+	if start == end {
+		return start
+	}
+	return start + " — " + end
+}
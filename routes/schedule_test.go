@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+)
+
+func TestScheduleDay(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	var windows [7]dailyWindow
+	for i := range windows {
+		windows[i] = dailyWindow{enabled: true, start: 9 * 60, finish: 18 * 60}
+	}
+	windows[time.Thursday] = dailyWindow{enabled: true, custom: true, start: 22 * 60, finish: 2 * 60}
+	windows[time.Saturday] = dailyWindow{enabled: true, custom: true, start: 8 * 60, finish: 8 * 60}
+	windows[time.Sunday] = dailyWindow{custom: true}
+
+	at := func(day, hour, minute int) time.Time {
+		// Время передаётся в UTC: scheduleDay сам переводит его в часовой пояс бота.
+		return time.Date(2024, 5, day, hour, minute, 0, 0, moscow).UTC()
+	}
+	midnight := func(day int) time.Time { return time.Date(2024, 5, day, 0, 0, 0, 0, moscow) }
+
+	tests := []struct {
+		name    string
+		ts      time.Time
+		wantDay time.Time
+		wantOK  bool
+	}{
+		{name: "inside a day window", ts: at(10, 10, 0), wantDay: midnight(10), wantOK: true},
+		{name: "window start is included", ts: at(10, 9, 0), wantDay: midnight(10), wantOK: true},
+		{name: "before the window", ts: at(10, 8, 59)},
+		{name: "window finish is excluded", ts: at(10, 18, 0)},
+		{name: "overnight window before midnight", ts: at(9, 23, 0), wantDay: midnight(9), wantOK: true},
+		{name: "overnight window after midnight", ts: at(10, 1, 30), wantDay: midnight(9), wantOK: true},
+		{name: "overnight window finish", ts: at(10, 2, 0)},
+		{name: "all-day window", ts: at(11, 20, 0), wantDay: midnight(11), wantOK: true},
+		{name: "all-day window into a disabled day", ts: at(12, 7, 59), wantDay: midnight(11), wantOK: true},
+		{name: "disabled day", ts: at(12, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day, ok := scheduleDay(windows, tt.ts, moscow)
+			if ok != tt.wantOK || !day.Equal(tt.wantDay) {
+				t.Errorf("scheduleDay(%v) = (%v, %v), want (%v, %v)", tt.ts.In(moscow), day, ok, tt.wantDay, tt.wantOK)
+			}
+			if ok && day.Location() != moscow {
+				t.Errorf("day location = %v, want %v", day.Location(), moscow)
+			}
+		})
+	}
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	lang := i18n.Russian
+
+	tests := []struct {
+		text       string
+		wantStart  int64
+		wantFinish int64
+		wantErr    string
+	}{
+		{text: "9:30-18:00", wantStart: 9*60 + 30, wantFinish: 18 * 60},
+		{text: "9 – 18", wantStart: 9 * 60, wantFinish: 18 * 60},
+		{text: "22:00—02:00", wantStart: 22 * 60, wantFinish: 2 * 60},
+		{text: "9:30", wantErr: i18n.T(lang, "schedule.error_window")},
+		{text: "9-12-18", wantErr: i18n.T(lang, "schedule.error_window")},
+		{text: "9:30-25:00", wantErr: i18n.T(lang, "schedule.error_clock", "25:00")},
+		{text: "утро-вечер", wantErr: i18n.T(lang, "schedule.error_clock", "утро")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			start, finish, err := parseTimeWindow(lang, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseTimeWindow(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || start != tt.wantStart || finish != tt.wantFinish {
+				t.Errorf("parseTimeWindow(%q) = (%d, %d, %v), want (%d, %d)",
+					tt.text, start, finish, err, tt.wantStart, tt.wantFinish)
+			}
+		})
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   []time.Weekday
+	}{
+		{digits: "12345", want: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{digits: "60", want: []time.Weekday{time.Saturday, time.Sunday}},
+		{digits: "", want: []time.Weekday{}},
+		{digits: "7", want: nil},
+		{digits: "1a", want: nil},
+	}
+
+	for _, tt := range tests {
+		got := parseWeekdays(tt.digits)
+		if !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("parseWeekdays(%q) = %v, want %v", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestUserWindows(t *testing.T) {
+	const userID common.UserID = 13
+	h, repo, _ := newTestHandlers(t)
+
+	user := db.User{
+		ID:                          userID,
+		ChatID:                      13,
+		ScheduleMorningStartHour:    sql.NullInt64{Int64: 9, Valid: true},
+		ScheduleMorningStartMinute:  30,
+		ScheduleEveningFinishHour:   sql.NullInt64{Int64: 18, Valid: true},
+		ScheduleEveningFinishMinute: 15,
+	}
+	if err := repo.AddUser(user); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	schedules := []db.WeekdaySchedule{
+		{UserID: userID, Weekday: time.Saturday, Enabled: true, StartMinute: 12 * 60, FinishMinute: 14 * 60},
+		{UserID: userID, Weekday: time.Sunday},
+	}
+	for _, schedule := range schedules {
+		if err := repo.SetWeekdaySchedule(schedule); err != nil {
+			t.Fatalf("SetWeekdaySchedule: %v", err)
+		}
+	}
+
+	windows, err := h.userWindows(user)
+	if err != nil {
+		t.Fatalf("userWindows: %v", err)
+	}
+	want := [7]dailyWindow{
+		time.Sunday:   {custom: true},
+		time.Saturday: {enabled: true, custom: true, start: 12 * 60, finish: 14 * 60},
+	}
+	for _, weekday := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday} {
+		want[weekday] = dailyWindow{enabled: true, start: 9*60 + 30, finish: 18*60 + 15}
+	}
+	if windows != want {
+		t.Errorf("userWindows() = %+v, want %+v", windows, want)
+	}
+}
@@ -0,0 +1,284 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxTimerMinutes — самый длинный интервал уведомлений, который можно задать в /settings.
+const maxTimerMinutes = 12 * 60
+
+// Настройки, которые можно изменить по отдельности в /settings.
+const (
+	settingInterval = "interval"
+	settingStart    = "start"
+	settingFinish   = "finish"
+)
+
+// SettingsCommand обрабатывает команду /settings: текущие настройки с кнопками
+// для изменения каждой из них без повторного прохождения /start.
+func (h *Handlers) SettingsCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), settingsText(*user, h.zoneName()))
+	msgconf.ReplyMarkup = getSettingsKeyboardMarkup(*user)
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// SettingsEditCallback спрашивает новое значение одной настройки.
+// Callback data: "settings__edit interval|start|finish".
+func (h *Handlers) SettingsEditCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	userID := common.UserID(callback.From.ID)
+	lang := h.langOf(userID)
+	setting := strings.TrimPrefix(callback.Data, "settings__edit ")
+	var question string
+	switch setting {
+	case settingInterval:
+		question = i18n.T(lang, "settings.ask_interval", maxTimerMinutes)
+	case settingStart:
+		question = i18n.T(lang, "settings.ask_start", h.zoneName())
+	case settingFinish:
+		question = i18n.T(lang, "settings.ask_finish", h.zoneName())
+	default:
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "data", callback.Data)
+		return
+	}
+
+	if common.GetUserState(userID).State == common.InCommand {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "common.finish_command")))
+		return
+	}
+
+	waitChan := make(chan string, 1)
+	common.SetUserState(userID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(userID, common.UserState{State: common.Idle, WaitingChannel: nil})
+
+	reply := tgbotapi.NewMessage(callback.Message.Chat.ID, question)
+	reply.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	_, err := h.sender.Send(reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+
+	// Пользователя читаем после ответа: пока ждали, настройки могли измениться.
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	if err := applySetting(lang, user, setting, ans); err != nil {
+		_, err = h.sender.Send(tgbotapi.NewMessage(callback.Message.Chat.ID,
+			i18n.T(lang, "settings.save_error", err.Error())))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+	if err := h.users.UpdateUserColumns(userID, settingColumns(*user, setting)); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	h.updateSettingsMessage(ctx, callback, *user)
+}
+
+// SettingsToggleCallback включает и выключает уведомления.
+func (h *Handlers) SettingsToggleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	if !user.TimerEnabled &&
+		(!user.TimerMinutes.Valid || !user.ScheduleMorningStartHour.Valid || !user.ScheduleEveningFinishHour.Valid) {
+		h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(userLang(*user), "settings.incomplete")))
+		return
+	}
+
+	user.TimerEnabled = !user.TimerEnabled
+	if user.TimerEnabled {
+		user.SnoozedUntil = sql.NullTime{}
+	}
+	err = h.users.UpdateUserColumns(user.ID, map[string]any{
+		"timer_enabled": user.TimerEnabled,
+		"snoozed_until": user.SnoozedUntil,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	h.updateSettingsMessage(ctx, callback, *user)
+}
+
+// SettingsLanguageCallback меняет язык интерфейса.
+// Callback data: "settings__language <код языка>".
+func (h *Handlers) SettingsLanguageCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	lang := i18n.Lang(strings.TrimPrefix(callback.Data, "settings__language "))
+	if !slices.Contains(i18n.Supported, lang) {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "data", callback.Data)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	user.Language = string(lang)
+	if err := h.users.UpdateUserColumns(user.ID, map[string]any{"language": user.Language}); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	h.updateSettingsMessage(ctx, callback, *user)
+}
+
+// applySetting разбирает новое значение настройки и записывает его в user.
+// Совпадающие начало и конец, как и в /schedule, задают круглосуточное окно.
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func applySetting(lang i18n.Lang, user *db.User, setting, text string) error {
+	text = strings.TrimSpace(text)
+	if setting == settingInterval {
+		minutes, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || minutes < 1 || minutes > maxTimerMinutes {
+			return errors.New(i18n.T(lang, "settings.error_interval", maxTimerMinutes))
+		}
+		user.TimerMinutes = sql.NullInt64{Int64: minutes, Valid: true}
+		return nil
+	}
+
+	minute, err := parseClock(lang, text)
+	if err != nil {
+		return err
+	}
+
+	if setting == settingStart {
+		user.ScheduleMorningStartHour = sql.NullInt64{Int64: minute / 60, Valid: true}
+		user.ScheduleMorningStartMinute = minute % 60
+	} else {
+		user.ScheduleEveningFinishHour = sql.NullInt64{Int64: minute / 60, Valid: true}
+		user.ScheduleEveningFinishMinute = minute % 60
+	}
+	return nil
+}
+
+// settingColumns возвращает столбцы пользователя, в которых хранится настройка setting.
+func settingColumns(user db.User, setting string) map[string]any {
+	switch setting {
+	case settingInterval:
+		return map[string]any{"timer_minutes": user.TimerMinutes}
+	case settingStart:
+		return map[string]any{
+			"schedule_morning_start_hour":   user.ScheduleMorningStartHour,
+			"schedule_morning_start_minute": user.ScheduleMorningStartMinute,
+		}
+	default:
+		return map[string]any{
+			"schedule_evening_finish_hour":   user.ScheduleEveningFinishHour,
+			"schedule_evening_finish_minute": user.ScheduleEveningFinishMinute,
+		}
+	}
+}
+
+// scheduleMinute возвращает границу общего расписания в минутах от полуночи.
+func scheduleMinute(hour sql.NullInt64, minute int64) (int64, bool) {
+	return hour.Int64*60 + minute, hour.Valid
+}
+
+func (h *Handlers) updateSettingsMessage(ctx context.Context, callback *tgbotapi.CallbackQuery, user db.User) {
+	_, err := h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		settingsText(user, h.zoneName()), getSettingsKeyboardMarkup(user)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// settingsText описывает настройки пользователя; время расписания — в часовом поясе zone.
+func settingsText(user db.User, zone string) string {
+	lang := userLang(user)
+	interval := i18n.T(lang, "settings.interval_unset")
+	if user.TimerMinutes.Valid {
+		interval = formatMinutes(lang, user.TimerMinutes.Int64)
+	}
+	clock := func(hour sql.NullInt64, minute int64) string {
+		if !hour.Valid {
+			return i18n.T(lang, "settings.time_unset")
+		}
+		value, _ := scheduleMinute(hour, minute)
+		return formatClock(value) + " " + zone
+	}
+	finish := clock(user.ScheduleEveningFinishHour, user.ScheduleEveningFinishMinute)
+	startMinute, startSet := scheduleMinute(user.ScheduleMorningStartHour, user.ScheduleMorningStartMinute)
+	finishMinute, finishSet := scheduleMinute(user.ScheduleEveningFinishHour, user.ScheduleEveningFinishMinute)
+	if startSet && finishSet && startMinute == finishMinute {
+		finish += i18n.T(lang, "settings.all_day")
+	}
+	notifications := i18n.T(lang, "settings.notifications_off")
+	if user.TimerEnabled {
+		notifications = i18n.T(lang, "settings.notifications_on")
+	}
+
+	return i18n.T(lang, "settings.text",
+		interval,
+		clock(user.ScheduleMorningStartHour, user.ScheduleMorningStartMinute),
+		finish,
+		notifications,
+		lang.Name())
+}
+
+func getSettingsKeyboardMarkup(user db.User) tgbotapi.InlineKeyboardMarkup {
+	lang := userLang(user)
+	toggle := i18n.T(lang, "settings.button_enable")
+	if user.TimerEnabled {
+		toggle = i18n.T(lang, "settings.button_disable")
+	}
+
+	languages := make([]tgbotapi.InlineKeyboardButton, 0, len(i18n.Supported))
+	for _, supported := range i18n.Supported {
+		text := supported.Name()
+		if supported == lang {
+			text = "• " + text
+		}
+		languages = append(languages,
+			tgbotapi.NewInlineKeyboardButtonData(text, "settings__language "+string(supported)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "settings.button_interval"), "settings__edit "+settingInterval),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "settings.button_start"), "settings__edit "+settingStart),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "settings.button_finish"), "settings__edit "+settingFinish),
+		),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(toggle, "settings__toggle")),
+		languages,
+	)
+}
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+)
+
+func TestApplySetting(t *testing.T) {
+	lang := i18n.Russian
+	configured := db.User{
+		TimerMinutes:                sql.NullInt64{Int64: 30, Valid: true},
+		ScheduleMorningStartHour:    sql.NullInt64{Int64: 9, Valid: true},
+		ScheduleEveningFinishHour:   sql.NullInt64{Int64: 18, Valid: true},
+		ScheduleEveningFinishMinute: 30,
+	}
+
+	tests := []struct {
+		name    string
+		user    db.User
+		setting string
+		text    string
+		want    db.User
+		wantErr string
+	}{
+		{
+			name: "interval", user: configured, setting: settingInterval, text: " 45 ",
+			want: func() db.User {
+				user := configured
+				user.TimerMinutes = sql.NullInt64{Int64: 45, Valid: true}
+				return user
+			}(),
+		},
+		{
+			name: "interval of zero", user: configured, setting: settingInterval, text: "0",
+			wantErr: i18n.T(lang, "settings.error_interval", maxTimerMinutes),
+		},
+		{
+			name: "interval too long", user: configured, setting: settingInterval, text: "721",
+			wantErr: i18n.T(lang, "settings.error_interval", maxTimerMinutes),
+		},
+		{
+			name: "interval not a number", user: configured, setting: settingInterval, text: "полчаса",
+			wantErr: i18n.T(lang, "settings.error_interval", maxTimerMinutes),
+		},
+		{
+			name: "start with minutes", user: configured, setting: settingStart, text: "8:45",
+			want: func() db.User {
+				user := configured
+				user.ScheduleMorningStartHour = sql.NullInt64{Int64: 8, Valid: true}
+				user.ScheduleMorningStartMinute = 45
+				return user
+			}(),
+		},
+		{
+			name: "finish after midnight", user: configured, setting: settingFinish, text: "1",
+			want: func() db.User {
+				user := configured
+				user.ScheduleEveningFinishHour = sql.NullInt64{Int64: 1, Valid: true}
+				user.ScheduleEveningFinishMinute = 0
+				return user
+			}(),
+		},
+		{
+			name: "first start on a new user", setting: settingStart, text: "18:30",
+			want: db.User{ScheduleMorningStartHour: sql.NullInt64{Int64: 18, Valid: true}, ScheduleMorningStartMinute: 30},
+		},
+		{
+			name: "all-day window", user: configured, setting: settingStart, text: "18:30",
+			want: func() db.User {
+				user := configured
+				user.ScheduleMorningStartHour = sql.NullInt64{Int64: 18, Valid: true}
+				user.ScheduleMorningStartMinute = 30
+				return user
+			}(),
+		},
+		{
+			name: "bad clock", user: configured, setting: settingFinish, text: "25:00",
+			wantErr: i18n.T(lang, "schedule.error_clock", "25:00"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			err := applySetting(lang, &user, tt.setting, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("applySetting(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				if user != tt.user {
+					t.Errorf("user changed on error: %+v", user)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applySetting(%q): %v", tt.text, err)
+			}
+			if user != tt.want {
+				t.Errorf("applySetting(%q) user = %+v, want %+v", tt.text, user, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettingsText(t *testing.T) {
+	window := db.User{
+		TimerMinutes:              sql.NullInt64{Int64: 30, Valid: true},
+		ScheduleMorningStartHour:  sql.NullInt64{Int64: 9, Valid: true},
+		ScheduleEveningFinishHour: sql.NullInt64{Int64: 18, Valid: true},
+	}
+	allDay := window
+	allDay.ScheduleEveningFinishHour = sql.NullInt64{Int64: 9, Valid: true}
+
+	tests := []struct {
+		name       string
+		user       db.User
+		wantAllDay bool
+	}{
+		{name: "window", user: window},
+		{name: "all-day window", user: allDay, wantAllDay: true},
+		{name: "finish not set", user: db.User{ScheduleMorningStartHour: sql.NullInt64{Valid: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := settingsText(tt.user, "UTC")
+			if got := strings.Contains(text, i18n.T(i18n.Russian, "settings.all_day")); got != tt.wantAllDay {
+				t.Errorf("settingsText() = %q, want all-day note %v", text, tt.wantAllDay)
+			}
+		})
+	}
+}
+
+func TestSettingColumns(t *testing.T) {
+	user := db.User{
+		TimerMinutes:               sql.NullInt64{Int64: 30, Valid: true},
+		ScheduleMorningStartHour:   sql.NullInt64{Int64: 9, Valid: true},
+		ScheduleMorningStartMinute: 15,
+	}
+
+	tests := []struct {
+		setting string
+		want    []string
+	}{
+		{setting: settingInterval, want: []string{"timer_minutes"}},
+		{setting: settingStart, want: []string{"schedule_morning_start_hour", "schedule_morning_start_minute"}},
+		{setting: settingFinish, want: []string{"schedule_evening_finish_hour", "schedule_evening_finish_minute"}},
+	}
+
+	for _, tt := range tests {
+		columns := settingColumns(user, tt.setting)
+		if len(columns) != len(tt.want) {
+			t.Errorf("settingColumns(%q) = %v, want columns %v", tt.setting, columns, tt.want)
+			continue
+		}
+		for _, column := range tt.want {
+			if _, ok := columns[column]; !ok {
+				t.Errorf("settingColumns(%q) has no column %q", tt.setting, column)
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxSnooze — самая длинная пауза, которую можно поставить через /dnd.
+// Для отпуска есть /day_off.
+const maxSnooze = 30 * Day
+
+// isSnoozed проверяет, стоят ли уведомления пользователя на паузе в момент now.
+func isSnoozed(user db.User, now time.Time) bool {
+	return user.SnoozedUntil.Valid && now.Before(user.SnoozedUntil.Time)
+}
+
+// nextDayStart возвращает начало первого окна уведомлений после дня, к которому
+// относится now: "пауза до завтра" заканчивается, когда начинается завтрашнее расписание.
+func nextDayStart(windows [7]dailyWindow, now time.Time, loc *time.Location) time.Time {
+	day, ok := scheduleDay(windows, now, loc)
+	if !ok {
+		day = startOfDay(now, loc)
+	}
+	for i := 1; i <= 7; i++ {
+		next := day.AddDate(0, 0, i)
+		if window := windows[next.Weekday()]; window.enabled {
+			return time.Date(next.Year(), next.Month(), next.Day(), 0, int(window.start), 0, 0, loc)
+		}
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// parseSnoozeDuration разбирает длительность паузы: "90" (минуты), "2h", "1ч30мин", "3d".
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func parseSnoozeDuration(lang i18n.Lang, text string) (time.Duration, error) {
+	input := strings.TrimSpace(text)
+	text = strings.NewReplacer("мин", "m", "ч", "h", "м", "m", "д", "d").Replace(strings.ToLower(input))
+
+	var duration time.Duration
+	if minutes, err := strconv.Atoi(text); err == nil {
+		duration = time.Duration(minutes) * time.Minute
+	} else if days, found := strings.CutSuffix(text, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.New(i18n.T(lang, "snooze.error_days", days))
+		}
+		duration = time.Duration(count) * Day
+	} else {
+		duration, err = time.ParseDuration(text)
+		if err != nil {
+			return 0, errors.New(i18n.T(lang, "snooze.error_duration", input))
+		}
+	}
+
+	if duration <= 0 {
+		return 0, errors.New(i18n.T(lang, "snooze.error_positive"))
+	}
+	if duration > maxSnooze {
+		return 0, errors.New(i18n.T(lang, "snooze.error_too_long"))
+	}
+	return duration, nil
+}
+
+// snoozeUntil ставит уведомления пользователя на паузу до until или снимает паузу,
+// если until не задано.
+func (h *Handlers) snoozeUntil(userID common.UserID, until sql.NullTime) (*db.User, error) {
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	user.SnoozedUntil = until
+	return user, h.users.UpdateUserColumns(userID, map[string]any{"snoozed_until": until})
+}
+
+// snoozeTarget вычисляет окончание паузы по callback data: "<prefix>__for <минуты>"
+// или "<prefix>__tomorrow"; "<prefix>__off" снимает паузу.
+func (h *Handlers) snoozeTarget(user db.User, action string, now time.Time) (sql.NullTime, error) {
+	switch {
+	case action == "off":
+		return sql.NullTime{}, nil
+	case action == "tomorrow":
+		windows, err := h.userWindows(user)
+		if err != nil {
+			return sql.NullTime{}, err
+		}
+		return sql.NullTime{Time: nextDayStart(windows, now, h.location()), Valid: true}, nil
+	case strings.HasPrefix(action, "for "):
+		minutes, err := strconv.Atoi(strings.TrimPrefix(action, "for "))
+		if err != nil || minutes <= 0 {
+			return sql.NullTime{}, fmt.Errorf("invalid snooze minutes %q", action)
+		}
+		return sql.NullTime{Time: now.Add(time.Duration(minutes) * time.Minute), Valid: true}, nil
+	default:
+		return sql.NullTime{}, fmt.Errorf("unknown snooze action %q", action)
+	}
+}
+
+// SnoozeCallback ставит уведомления на паузу с кнопки под уведомлением.
+// Callback data: "snooze__for <минуты>" или "snooze__tomorrow".
+func (h *Handlers) SnoozeCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	until, err := h.snoozeTarget(*user, strings.TrimPrefix(callback.Data, "snooze__"), time.Now())
+	if err != nil || !until.Valid {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	if _, err := h.snoozeUntil(user.ID, until); err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+	lang := userLang(*user)
+	h.sender.Request(tgbotapi.NewCallback(callback.ID,
+		i18n.T(lang, "snooze.until", formatSnoozeTime(lang, until.Time, h.location()))))
+}
+
+// DndCommand обрабатывает команду /dnd: без аргументов показывает меню паузы,
+// "/dnd 2h" ставит паузу на указанное время, "/dnd off" снимает её.
+func (h *Handlers) DndCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+	lang := userLang(*user)
+	args := strings.Fields(message.Text)[1:]
+
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && (args[0] == "off" || args[0] == "выкл"):
+		user, err = h.snoozeUntil(user.ID, sql.NullTime{})
+	default:
+		duration, parseErr := parseSnoozeDuration(lang, strings.Join(args, ""))
+		if parseErr != nil {
+			_, err = h.sender.Send(tgbotapi.NewMessage(message.Chat.ID,
+				i18n.T(lang, "snooze.parse_error", parseErr.Error())))
+			if err != nil {
+				slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+			}
+			return
+		}
+		user, err = h.snoozeUntil(user.ID, sql.NullTime{Time: time.Now().Add(duration), Valid: true})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления паузы уведомлений", "err", err)
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(message.Chat.ID, dndStatusText(*user, time.Now(), h.location()))
+	msgconf.ReplyMarkup = getDndKeyboardMarkup(*user, time.Now())
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+	}
+}
+
+// DndCallback ставит или снимает паузу из меню /dnd.
+// Callback data: "dnd__for <минуты>", "dnd__tomorrow" или "dnd__off".
+func (h *Handlers) DndCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	now := time.Now()
+	until, err := h.snoozeTarget(*user, strings.TrimPrefix(callback.Data, "dnd__"), now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err, "data", callback.Data)
+		return
+	}
+
+	user, err = h.snoozeUntil(user.ID, until)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		dndStatusText(*user, now, h.location()), getDndKeyboardMarkup(*user, now)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// dndStatusText описывает, стоят ли уведомления на паузе; время — в часовом поясе loc.
+func dndStatusText(user db.User, now time.Time, loc *time.Location) string {
+	lang := userLang(user)
+	if isSnoozed(user, now) {
+		return i18n.T(lang, "snooze.status_paused", formatSnoozeTime(lang, user.SnoozedUntil.Time, loc))
+	}
+	return i18n.T(lang, "snooze.status_active")
+}
+
+func getDndKeyboardMarkup(user db.User, now time.Time) tgbotapi.InlineKeyboardMarkup {
+	lang := userLang(user)
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(formatMinutes(lang, 60), "dnd__for 60"),
+			tgbotapi.NewInlineKeyboardButtonData(formatMinutes(lang, 180), "dnd__for 180"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "snooze.button_tomorrow"), "dnd__tomorrow"),
+		),
+	}
+	if isSnoozed(user, now) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "snooze.button_resume"), "dnd__off"),
+		))
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func getSnoozeRow(lang i18n.Lang) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "snooze.button_pause", formatMinutes(lang, 60)), "snooze__for 60"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "snooze.button_tomorrow"), "snooze__tomorrow"),
+	)
+}
+
+// formatSnoozeTime форматирует окончание паузы в часовом поясе loc.
+func formatSnoozeTime(lang i18n.Lang, ts time.Time, loc *time.Location) string {
+	return ts.In(loc).Format(i18n.T(lang, "format.datetime_short") + " MST")
+}
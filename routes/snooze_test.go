@@ -0,0 +1,152 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseSnoozeDuration(t *testing.T) {
+	lang := i18n.Russian
+
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr string
+	}{
+		{text: "90", want: 90 * time.Minute},
+		{text: " 2h ", want: 2 * time.Hour},
+		{text: "1ч30мин", want: 90 * time.Minute},
+		{text: "45м", want: 45 * time.Minute},
+		{text: "3d", want: 3 * Day},
+		{text: "2Д", want: 2 * Day},
+		{text: "30d", want: maxSnooze},
+		{text: "0", wantErr: i18n.T(lang, "snooze.error_positive")},
+		{text: "-5m", wantErr: i18n.T(lang, "snooze.error_positive")},
+		{text: "31d", wantErr: i18n.T(lang, "snooze.error_too_long")},
+		{text: "xd", wantErr: i18n.T(lang, "snooze.error_days", "x")},
+		{text: "долго", wantErr: i18n.T(lang, "snooze.error_duration", "долго")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := parseSnoozeDuration(lang, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseSnoozeDuration(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseSnoozeDuration(%q) = (%v, %v), want %v", tt.text, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDayStart(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	var workdays [7]dailyWindow
+	for _, weekday := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday} {
+		workdays[weekday] = dailyWindow{enabled: true, start: 9 * 60, finish: 18 * 60}
+	}
+	workdays[time.Thursday] = dailyWindow{enabled: true, custom: true, start: 22 * 60, finish: 2 * 60}
+
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 5, day, hour, minute, 0, 0, moscow) }
+
+	tests := []struct {
+		name    string
+		windows [7]dailyWindow
+		now     time.Time
+		want    time.Time
+	}{
+		{name: "next workday", windows: workdays, now: at(6, 10, 0), want: at(7, 9, 0)},
+		{name: "before today's window", windows: workdays, now: at(6, 6, 0), want: at(7, 9, 0)},
+		{name: "skips the weekend", windows: workdays, now: at(10, 10, 0), want: at(13, 9, 0)},
+		{name: "after midnight in yesterday's window", windows: workdays, now: at(10, 1, 30), want: at(10, 9, 0)},
+		{name: "into an overnight window", windows: workdays, now: at(8, 12, 0), want: at(9, 22, 0)},
+		{name: "no windows at all", now: at(6, 10, 0), want: at(7, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextDayStart(tt.windows, tt.now.UTC(), moscow)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextDayStart(%v) = %v, want %v", tt.now, got.In(moscow), tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSnoozed(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until sql.NullTime
+		want  bool
+	}{
+		{name: "no pause"},
+		{name: "pause ahead", until: sql.NullTime{Time: now.Add(time.Minute), Valid: true}, want: true},
+		{name: "pause ended", until: sql.NullTime{Time: now, Valid: true}},
+	}
+
+	for _, tt := range tests {
+		if got := isSnoozed(db.User{SnoozedUntil: tt.until}, now); got != tt.want {
+			t.Errorf("%s: isSnoozed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyCommand(t *testing.T) {
+	const userID common.UserID = 12
+	h, repo, _, users := newColumnTestHandlers(t)
+	snoozedUntil := sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+	lastNotify := sql.NullTime{Time: time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC), Valid: true}
+	err := repo.AddUser(db.User{ID: userID, ChatID: 12, SnoozedUntil: snoozedUntil, LastNotify: lastNotify})
+	if err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+
+	tests := []struct {
+		command     string
+		start       bool
+		wantSnoozed bool
+		wantColumns []string
+	}{
+		{command: "/stop_notify", wantSnoozed: true, wantColumns: []string{"timer_enabled"}},
+		{command: "/start_notify", start: true, wantColumns: []string{"snoozed_until", "timer_enabled"}},
+	}
+
+	for _, tt := range tests {
+		users.columns = nil
+		h.NotifyCommand(context.Background(), &tgbotapi.Message{
+			From: &tgbotapi.User{ID: int64(userID)},
+			Chat: &tgbotapi.Chat{ID: int64(userID)},
+			Text: tt.command,
+		}, tt.start)
+
+		user, err := repo.GetUserByID(userID)
+		if err != nil {
+			t.Fatalf("GetUserByID: %v", err)
+		}
+		if user.TimerEnabled != tt.start || user.SnoozedUntil.Valid != tt.wantSnoozed {
+			t.Errorf("%s: timer_enabled %v, snoozed_until %v, want %v and snoozed %v",
+				tt.command, user.TimerEnabled, user.SnoozedUntil, tt.start, tt.wantSnoozed)
+		}
+		if !user.LastNotify.Time.Equal(lastNotify.Time) {
+			t.Errorf("%s: last_notify = %v, want %v", tt.command, user.LastNotify, lastNotify)
+		}
+		if !slices.Equal(users.columns, tt.wantColumns) {
+			t.Errorf("%s: wrote columns %v, want %v", tt.command, users.columns, tt.wantColumns)
+		}
+	}
+}
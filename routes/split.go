@@ -0,0 +1,352 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Разделение интервала уведомления между несколькими активностями:
+// [split__start] -> дерево активностей с callback-ами [split__pick node_id timer],
+// выбранные листья копятся в черновике; [split__equal] делит интервал поровну,
+// [split__custom] спрашивает минуты для каждой активности, [split__cancel]
+// возвращает сообщению прежний вид. Части сохраняются логами одного message_id.
+
+// splitKey — сообщение с уведомлением, интервал которого разделяют.
+type splitKey struct {
+	userID    common.UserID
+	messageID int
+}
+
+// splitDraft — черновик разделения: выбранные активности и исходный вид сообщения.
+type splitDraft struct {
+	end             time.Time
+	intervalMinutes int64
+	activityIDs     []int64
+	names           []string
+	// answered — был ли у уведомления ответ до разделения.
+	answered bool
+
+	text   string
+	markup *tgbotapi.InlineKeyboardMarkup
+}
+
+func (h *Handlers) getSplitDraft(key splitKey) *splitDraft {
+	h.splitsMu.Lock()
+	defer h.splitsMu.Unlock()
+	return h.splits[key]
+}
+
+func (h *Handlers) setSplitDraft(key splitKey, draft *splitDraft) {
+	h.splitsMu.Lock()
+	defer h.splitsMu.Unlock()
+	if draft == nil {
+		delete(h.splits, key)
+		return
+	}
+	h.splits[key] = draft
+}
+
+// SplitStartCallback начинает разделение интервала уведомления между активностями.
+func (h *Handlers) SplitStartCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	if h.rejectExpiredAnswer(ctx, callback) {
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	draft := &splitDraft{
+		end:             callback.Message.Time(),
+		intervalMinutes: user.TimerMinutes.Int64,
+		text:            callback.Message.Text,
+		markup:          callback.Message.ReplyMarkup,
+	}
+	// Для отправленных уведомлений интервал известен точно — он мог отличаться от текущего.
+	slot, err := h.logs.GetTimelineSlot(user.ID, int64(callback.Message.MessageID))
+	if err == nil {
+		draft.end = slot.End
+		draft.intervalMinutes = slot.IntervalMinutes
+		draft.answered = slot.Answered
+	}
+	if draft.intervalMinutes < 2 {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "split.too_short")))
+		return
+	}
+
+	h.setSplitDraft(splitKey{userID: user.ID, messageID: callback.Message.MessageID}, draft)
+	h.showSplitDraft(ctx, *user, callback.Message, draft, -1)
+}
+
+// SplitPickCallback переходит по дереву активностей или отмечает/снимает лист в черновике.
+func (h *Handlers) SplitPickCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var nodeID, timerMinutes int64
+	_, err := fmt.Sscanf(callback.Data, "split__pick %d %d", &nodeID, &timerMinutes)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	draft := h.getSplitDraft(splitKey{userID: user.ID, messageID: callback.Message.MessageID})
+	if draft == nil {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "split.expired_restart")))
+		return
+	}
+
+	isMuted := false
+	activities, err := h.activities.GetSimpleActivities(user.ID, &isMuted, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		return
+	}
+
+	idx := slices.IndexFunc(activities, func(a db.Activity) bool { return a.ID == nodeID })
+	if idx == -1 {
+		slog.ErrorContext(ctx, "Активность не найдена среди активностей пользователя", "activity_id", nodeID)
+		return
+	}
+
+	if !activities[idx].IsLeaf {
+		h.showSplitDraft(ctx, *user, callback.Message, draft, nodeID)
+		return
+	}
+
+	activityName, err := h.activities.GetFullActivityNameByID(nodeID, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+		return
+	}
+
+	h.splitsMu.Lock()
+	if i := slices.Index(draft.activityIDs, nodeID); i != -1 {
+		draft.activityIDs = slices.Delete(draft.activityIDs, i, i+1)
+		draft.names = slices.Delete(draft.names, i, i+1)
+	} else {
+		draft.activityIDs = append(draft.activityIDs, nodeID)
+		draft.names = append(draft.names, activityName)
+	}
+	h.splitsMu.Unlock()
+
+	h.showSplitDraft(ctx, *user, callback.Message, draft, -1)
+}
+
+// SplitEqualCallback делит интервал поровну между выбранными активностями.
+func (h *Handlers) SplitEqualCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	key := splitKey{userID: common.UserID(callback.From.ID), messageID: callback.Message.MessageID}
+	draft := h.getSplitDraft(key)
+	if !h.checkSplitDraft(callback, draft) {
+		return
+	}
+
+	h.saveSplit(ctx, key, callback.Message.Chat.ID, draft, equalSplit(draft.intervalMinutes, len(draft.activityIDs)))
+}
+
+// SplitCustomCallback спрашивает, сколько минут пришлось на каждую выбранную активность.
+func (h *Handlers) SplitCustomCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	key := splitKey{userID: user.ID, messageID: callback.Message.MessageID}
+	draft := h.getSplitDraft(key)
+	if !h.checkSplitDraft(callback, draft) {
+		return
+	}
+
+	if common.GetUserState(user.ID).State == common.InCommand {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "common.finish_command")))
+		return
+	}
+
+	waitChan := make(chan string, 1)
+	common.SetUserState(user.ID, common.UserState{State: common.InCommand, WaitingChannel: &waitChan})
+	defer common.SetUserState(user.ID, common.UserState{State: common.Idle, WaitingChannel: nil})
+
+	lang := userLang(*user)
+	reply := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "split.ask_minutes",
+		draft.intervalMinutes, strings.Join(draft.names, "\n")))
+	reply.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	_, err = h.sender.Send(reply)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	ans, ok := h.waitForReply(ctx, waitChan)
+	if !ok {
+		return
+	}
+
+	minutes, err := parseSplitMinutes(lang, ans, len(draft.activityIDs), draft.intervalMinutes)
+	if err != nil {
+		_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "split.parse_error", err.Error())))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	h.saveSplit(ctx, key, callback.Message.Chat.ID, draft, minutes)
+}
+
+// SplitCancelCallback отменяет разделение и возвращает сообщению прежний вид.
+func (h *Handlers) SplitCancelCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	key := splitKey{userID: common.UserID(callback.From.ID), messageID: callback.Message.MessageID}
+	draft := h.getSplitDraft(key)
+	if draft == nil {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(h.langOf(key.userID), "split.expired")))
+		return
+	}
+	h.setSplitDraft(key, nil)
+
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, draft.text)
+	edit.ReplyMarkup = draft.markup
+	_, err := h.sender.Send(edit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// checkSplitDraft проверяет, что черновик есть и в нём выбрано хотя бы две активности.
+func (h *Handlers) checkSplitDraft(callback *tgbotapi.CallbackQuery, draft *splitDraft) bool {
+	lang := h.langOf(common.UserID(callback.From.ID))
+	if draft == nil {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "split.expired_restart")))
+		return false
+	}
+	if len(draft.activityIDs) < 2 {
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(lang, "split.pick_two")))
+		return false
+	}
+	return true
+}
+
+// saveSplit сохраняет части интервала логами одного уведомления. Части идут подряд
+// в порядке выбора и заканчиваются временем уведомления.
+func (h *Handlers) saveSplit(ctx context.Context, key splitKey, chatID int64, draft *splitDraft, minutes []int64) {
+	logs := make([]db.ActivityLog, len(minutes))
+	timestamp := draft.end
+	for i := len(minutes) - 1; i >= 0; i-- {
+		logs[i] = db.ActivityLog{
+			ActivityID:      draft.activityIDs[i],
+			Timestamp:       timestamp,
+			IntervalMinutes: minutes[i],
+		}
+		timestamp = timestamp.Add(-time.Duration(minutes[i]) * time.Minute)
+	}
+
+	if err := h.logs.ReplaceActivityLogs(key.userID, int64(key.messageID), logs); err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения лога активности", "err", err)
+		return
+	}
+	h.setSplitDraft(key, nil)
+	if !draft.answered {
+		h.adaptInterval(ctx, key.userID, 0, draft.end)
+	}
+
+	lang := h.langOf(key.userID)
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "split.saved"))
+	for i, name := range draft.names {
+		fmt.Fprintf(&sb, "\n• %s — %s", name, formatMinutes(lang, minutes[i]))
+	}
+
+	_, err := h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		chatID, key.messageID, sb.String(), getSavedActivityKeyboardMarkup(lang)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// showSplitDraft показывает черновик разделения и дерево активностей от parentActivityID.
+func (h *Handlers) showSplitDraft(
+	ctx context.Context, user db.User, message *tgbotapi.Message, draft *splitDraft, parentActivityID int64,
+) {
+	lang := userLang(user)
+	h.splitsMu.Lock()
+	end := draft.end.In(h.location())
+	text := i18n.T(lang, "split.draft",
+		end.Add(-time.Duration(draft.intervalMinutes)*time.Minute).Format("15:04"),
+		end.Format("15:04"), formatMinutes(lang, draft.intervalMinutes))
+	if len(draft.names) == 0 {
+		text += i18n.T(lang, "split.pick_two")
+	} else {
+		text += i18n.T(lang, "split.selected") + strings.Join(draft.names, "\n")
+	}
+	h.splitsMu.Unlock()
+
+	isMuted := false
+	keyboard := h.buildActivitiesKeyboardMarkupForUser(
+		ctx, user, parentActivityID, &isMuted, nil, "split__pick", getSplitLastRow(lang))
+
+	_, err := h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, text, keyboard))
+	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+func getSplitLastRow(lang i18n.Lang) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "split.button_equal"), "split__equal"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "split.button_custom"), "split__custom"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.cancel"), "split__cancel"),
+	)
+}
+
+// equalSplit делит total минут на n частей; остаток достаётся первым частям.
+func equalSplit(total int64, n int) []int64 {
+	minutes := make([]int64, n)
+	for i := range minutes {
+		minutes[i] = total / int64(n)
+		if int64(i) < total%int64(n) {
+			minutes[i]++
+		}
+	}
+	return minutes
+}
+
+// parseSplitMinutes разбирает ответ вида "30 20 10": n положительных чисел с суммой total.
+// Тексты ошибок — на языке lang, они показываются пользователю.
+func parseSplitMinutes(lang i18n.Lang, text string, n int, total int64) ([]int64, error) {
+	fields := strings.Fields(text)
+	if len(fields) != n {
+		return nil, errors.New(i18n.T(lang, "split.error_count", n, len(fields)))
+	}
+
+	minutes := make([]int64, n)
+	var sum int64
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || value <= 0 {
+			return nil, errors.New(i18n.T(lang, "split.error_positive"))
+		}
+		minutes[i] = value
+		sum += value
+	}
+	if sum != total {
+		return nil, errors.New(i18n.T(lang, "split.error_sum", sum, total))
+	}
+	return minutes, nil
+}
@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"TimeCounterBot/i18n"
+)
+
+func TestEqualSplit(t *testing.T) {
+	tests := []struct {
+		total int64
+		n     int
+		want  []int64
+	}{
+		{total: 60, n: 2, want: []int64{30, 30}},
+		{total: 60, n: 3, want: []int64{20, 20, 20}},
+		{total: 50, n: 3, want: []int64{17, 17, 16}},
+		{total: 7, n: 4, want: []int64{2, 2, 2, 1}},
+		{total: 2, n: 2, want: []int64{1, 1}},
+	}
+
+	for _, tt := range tests {
+		got := equalSplit(tt.total, tt.n)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("equalSplit(%d, %d) = %v, want %v", tt.total, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseSplitMinutes(t *testing.T) {
+	lang := i18n.Russian
+
+	tests := []struct {
+		name    string
+		text    string
+		n       int
+		total   int64
+		want    []int64
+		wantErr string
+	}{
+		{name: "valid", text: "40 20", n: 2, total: 60, want: []int64{40, 20}},
+		{name: "extra spaces", text: "  10\t20   30 ", n: 3, total: 60, want: []int64{10, 20, 30}},
+		{name: "too few", text: "60", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_count", 2, 1)},
+		{name: "too many", text: "20 20 20", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_count", 2, 3)},
+		{name: "zero", text: "60 0", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_positive")},
+		{name: "negative", text: "70 -10", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_positive")},
+		{name: "not a number", text: "30 полчаса", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_positive")},
+		{name: "wrong sum", text: "30 20", n: 2, total: 60, wantErr: i18n.T(lang, "split.error_sum", 50, 60)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSplitMinutes(lang, tt.text, tt.n, tt.total)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseSplitMinutes(%q) error = %v, want %q", tt.text, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSplitMinutes(%q): %v", tt.text, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseSplitMinutes(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSplitEqually делит интервал уведомления поровну между двумя активностями.
+func TestSplitEqually(t *testing.T) {
+	ctx := context.Background()
+	h, repo, sender := newTestHandlers(t)
+	_, callback := notifyTestUser(t, h, repo, sender)
+	if err := repo.ParseAndAddActivity(testNotifyUserID, "Работа / Почта"); err != nil {
+		t.Fatalf("ParseAndAddActivity: %v", err)
+	}
+
+	h.SplitStartCallback(ctx, callback("split__start"))
+	for _, leaf := range []string{"Код", "Почта"} {
+		h.SplitPickCallback(ctx, callback(buttonData(t, *lastEdit(t, sender).ReplyMarkup, "Работа")))
+		h.SplitPickCallback(ctx, callback(buttonData(t, *lastEdit(t, sender).ReplyMarkup, leaf)))
+	}
+	h.SplitEqualCallback(ctx, callback("split__equal"))
+
+	saved := lastEdit(t, sender)
+	wantText := i18n.T(i18n.Russian, "split.saved") +
+		"\n• Работа / Код — " + formatMinutes(i18n.Russian, 15) +
+		"\n• Работа / Почта — " + formatMinutes(i18n.Russian, 15)
+	if saved.Text != wantText {
+		t.Errorf("saved text = %q, want %q", saved.Text, wantText)
+	}
+
+	slot, err := repo.GetTimelineSlot(testNotifyUserID, 1)
+	if err != nil {
+		t.Fatalf("GetTimelineSlot: %v", err)
+	}
+	if slot.ActivityName != "Работа / Код + Работа / Почта" || slot.IntervalMinutes != 30 {
+		t.Errorf("slot = %+v, want 30 minutes split between Код and Почта", slot)
+	}
+	if got := slot.End.Sub(slot.Start); got != 30*time.Minute {
+		t.Errorf("split parts cover %v, want 30m", got)
+	}
+}
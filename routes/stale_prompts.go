@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// promptExpiry — сколько после отправки на уведомление ещё можно ответить с его клавиатуры.
+// Более поздний ответ записал бы время давно прошедшего интервала; такие интервалы
+// заполняются явно через хронологию дня.
+const promptExpiry = Day
+
+// collapseStalePrompts сворачивает клавиатуры уведомлений пользователя, на которые
+// не ответили до прихода нового уведомления exceptMessageID.
+func (h *Handlers) collapseStalePrompts(ctx context.Context, user db.User, exceptMessageID int64, now time.Time) {
+	prompts, err := h.logs.GetUnansweredOpenPrompts(user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения неотвеченных уведомлений", "err", err)
+		return
+	}
+
+	for _, prompt := range prompts {
+		if prompt.MessageID == exceptMessageID {
+			continue
+		}
+		h.closePrompt(ctx, prompt, now)
+	}
+}
+
+// closePrompt отмечает уведомление закрытым и заменяет его клавиатуру кнопкой
+// заполнения интервала через хронологию.
+func (h *Handlers) closePrompt(ctx context.Context, prompt db.Prompt, now time.Time) {
+	if err := h.logs.ClosePrompt(prompt.UserID, prompt.MessageID, now); err != nil {
+		slog.ErrorContext(ctx, "Ошибка закрытия уведомления", "err", err)
+		return
+	}
+
+	slot := db.TimelineSlot{
+		Start: prompt.SentAt.Add(-time.Duration(prompt.IntervalMinutes) * time.Minute),
+		End:   prompt.SentAt,
+	}
+	lang := h.langOf(prompt.UserID)
+	_, err := h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		int64(prompt.ChatID), int(prompt.MessageID),
+		i18n.T(lang, "timeline.missed", formatSlotInterval(slot, h.location())),
+		tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "timeline.button_fill"),
+				fmt.Sprintf("timeline__change %d", prompt.MessageID)),
+		)),
+	))
+	if err != nil {
+		slog.WarnContext(ctx, "Не удалось свернуть уведомление", "err", err)
+	}
+}
+
+// rejectExpiredAnswer отклоняет ответ на устаревшее уведомление: закрытое или
+// отправленное раньше promptExpiry. Уже записанные ответы менять можно всегда.
+// Возвращает true, если ответ отклонён.
+func (h *Handlers) rejectExpiredAnswer(ctx context.Context, callback *tgbotapi.CallbackQuery) bool {
+	userID := common.UserID(callback.From.ID)
+	messageID := int64(callback.Message.MessageID)
+	now := time.Now()
+
+	if slot, err := h.logs.GetTimelineSlot(userID, messageID); err == nil && slot.Answered {
+		return false
+	}
+
+	prompt, err := h.logs.GetPrompt(userID, messageID)
+	if err != nil {
+		// Уведомления, отправленные до появления таблицы prompts, проверяем по времени сообщения.
+		if now.Sub(callback.Message.Time()) <= promptExpiry {
+			return false
+		}
+	} else if !prompt.ClosedAt.Valid && now.Sub(prompt.SentAt) <= promptExpiry {
+		return false
+	}
+
+	h.sender.Request(tgbotapi.NewCallbackWithAlert(callback.ID, i18n.T(h.langOf(userID), "timeline.expired_prompt")))
+	if prompt != nil && !prompt.ClosedAt.Valid {
+		h.closePrompt(ctx, *prompt, now)
+	}
+	return true
+}
+
+// countMissedToday считает сегодняшние (по часовому поясу бота) уведомления пользователя без ответа.
+func (h *Handlers) countMissedToday(ctx context.Context, userID common.UserID, now time.Time) int {
+	today := startOfDay(now, h.location())
+	slots, err := h.logs.GetTimeline(userID, today, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения хронологии", "err", err)
+		return 0
+	}
+
+	missed := 0
+	for _, slot := range slots {
+		if !slot.Answered {
+			missed++
+		}
+	}
+	return missed
+}
+
+// MissedFillCallback присылает хронологию сегодняшнего дня, чтобы заполнить пропуски.
+func (h *Handlers) MissedFillCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	h.sender.Request(tgbotapi.NewCallback(callback.ID, ""))
+	today := startOfDay(time.Now(), h.location())
+	h.sendTimeline(ctx, common.UserID(callback.From.ID), today, callback.Message.MessageID)
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// staleTestUserID — пользователь тестов устаревших уведомлений.
+const staleTestUserID common.UserID = 9
+
+// addStaleTestUser создаёт пользователя staleTestUserID с активностью "Работа / Код" и возвращает её ID.
+func addStaleTestUser(t *testing.T, repo db.Repository) int64 {
+	t.Helper()
+
+	if err := repo.AddUser(db.User{ID: staleTestUserID, ChatID: 9, Language: string(i18n.Russian)}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	activityID, err := repo.FindOrAddActivityPath(staleTestUserID, "Работа / Код")
+	if err != nil {
+		t.Fatalf("FindOrAddActivityPath: %v", err)
+	}
+	return activityID
+}
+
+func TestRejectExpiredAnswer(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		// prompt — отправленное уведомление; nil — уведомление не записано в prompts.
+		prompt      *db.Prompt
+		answered    bool
+		messageTime time.Time
+		want        bool
+		wantClosed  bool
+	}{
+		{
+			name:        "fresh prompt",
+			prompt:      &db.Prompt{SentAt: now.Add(-time.Hour)},
+			messageTime: now.Add(-time.Hour),
+		},
+		{
+			name:        "closed prompt",
+			prompt:      &db.Prompt{SentAt: now.Add(-time.Hour), ClosedAt: sql.NullTime{Time: now, Valid: true}},
+			messageTime: now.Add(-time.Hour),
+			want:        true,
+		},
+		{
+			name:        "expired prompt is closed",
+			prompt:      &db.Prompt{SentAt: now.Add(-promptExpiry - time.Hour)},
+			messageTime: now.Add(-promptExpiry - time.Hour),
+			want:        true,
+			wantClosed:  true,
+		},
+		{
+			name:        "answered expired prompt can be changed",
+			prompt:      &db.Prompt{SentAt: now.Add(-promptExpiry - time.Hour)},
+			answered:    true,
+			messageTime: now.Add(-promptExpiry - time.Hour),
+		},
+		{
+			name:        "unknown fresh message",
+			messageTime: now.Add(-time.Hour),
+		},
+		{
+			name:        "unknown old message",
+			messageTime: now.Add(-promptExpiry - time.Hour),
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo, sender := newTestHandlers(t)
+			activityID := addStaleTestUser(t, repo)
+
+			if tt.prompt != nil {
+				prompt := *tt.prompt
+				prompt.MessageID, prompt.UserID, prompt.ChatID, prompt.IntervalMinutes = 1, staleTestUserID, 9, 30
+				if err := repo.AddPrompt(prompt); err != nil {
+					t.Fatalf("AddPrompt: %v", err)
+				}
+			}
+			if tt.answered {
+				err := repo.AddActivityLog(db.ActivityLog{
+					MessageID: 1, UserID: int64(staleTestUserID), ActivityID: activityID,
+					Timestamp: tt.prompt.SentAt, IntervalMinutes: 30,
+				})
+				if err != nil {
+					t.Fatalf("AddActivityLog: %v", err)
+				}
+			}
+
+			callback := &tgbotapi.CallbackQuery{
+				ID:   "callback",
+				From: &tgbotapi.User{ID: int64(staleTestUserID)},
+				Message: &tgbotapi.Message{
+					MessageID: 1, Chat: &tgbotapi.Chat{ID: 9}, Date: int(tt.messageTime.Unix()),
+				},
+			}
+			if got := h.rejectExpiredAnswer(context.Background(), callback); got != tt.want {
+				t.Errorf("rejectExpiredAnswer() = %v, want %v", got, tt.want)
+			}
+
+			requests := sender.Requests()
+			if tt.want {
+				alert, ok := requests[len(requests)-1].(tgbotapi.CallbackConfig)
+				if !ok || !alert.ShowAlert || alert.Text != i18n.T(i18n.Russian, "timeline.expired_prompt") {
+					t.Errorf("last request = %+v, want an expired prompt alert", requests[len(requests)-1])
+				}
+			} else if len(requests) != 0 {
+				t.Errorf("sent %d requests, want none", len(requests))
+			}
+
+			if tt.prompt != nil {
+				prompt, err := repo.GetPrompt(staleTestUserID, 1)
+				if err != nil {
+					t.Fatalf("GetPrompt: %v", err)
+				}
+				if closed := prompt.ClosedAt.Valid && !tt.prompt.ClosedAt.Valid; closed != tt.wantClosed {
+					t.Errorf("prompt closed = %v, want %v", closed, tt.wantClosed)
+				}
+			}
+		})
+	}
+}
+
+func TestCollapseStalePrompts(t *testing.T) {
+	h, repo, sender := newTestHandlers(t)
+	activityID := addStaleTestUser(t, repo)
+	now := time.Now()
+
+	for i, sentAt := range []time.Time{now.Add(-90 * time.Minute), now.Add(-time.Hour), now.Add(-30 * time.Minute)} {
+		prompt := db.Prompt{MessageID: int64(i + 1), UserID: staleTestUserID, ChatID: 9, SentAt: sentAt, IntervalMinutes: 30}
+		if err := repo.AddPrompt(prompt); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+	// На второе уведомление ответили, третье — новое.
+	err := repo.AddActivityLog(db.ActivityLog{
+		MessageID: 2, UserID: int64(staleTestUserID), ActivityID: activityID,
+		Timestamp: now.Add(-time.Hour), IntervalMinutes: 30,
+	})
+	if err != nil {
+		t.Fatalf("AddActivityLog: %v", err)
+	}
+	user, err := repo.GetUserByID(staleTestUserID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+
+	h.collapseStalePrompts(context.Background(), *user, 3, now)
+
+	sent := sender.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d edits, want 1", len(sent))
+	}
+	edit := lastEdit(t, sender)
+	slot := db.TimelineSlot{Start: now.Add(-2 * time.Hour), End: now.Add(-90 * time.Minute)}
+	if edit.MessageID != 1 || edit.Text != i18n.T(i18n.Russian, "timeline.missed", formatSlotInterval(slot, time.UTC)) {
+		t.Errorf("edit = message %d %q, want the first prompt marked missed", edit.MessageID, edit.Text)
+	}
+	buttonData(t, *edit.ReplyMarkup, i18n.T(i18n.Russian, "timeline.button_fill"))
+
+	open, err := repo.GetUnansweredOpenPrompts(staleTestUserID)
+	if err != nil {
+		t.Fatalf("GetUnansweredOpenPrompts: %v", err)
+	}
+	if len(open) != 1 || open[0].MessageID != 3 {
+		t.Errorf("open prompts = %+v, want only the new one", open)
+	}
+}
+
+func TestCountMissedToday(t *testing.T) {
+	h, repo, _ := newTestHandlers(t)
+	// В Москве уже следующий день, хотя в UTC ещё нет.
+	h.settings.Location = time.FixedZone("MSK", 3*60*60)
+	addStaleTestUser(t, repo)
+	now := time.Date(2024, 5, 9, 22, 0, 0, 0, time.UTC)
+
+	for i, sentAt := range []time.Time{now.Add(-150 * time.Minute), now.Add(-30 * time.Minute)} {
+		prompt := db.Prompt{MessageID: int64(i + 1), UserID: staleTestUserID, ChatID: 9, SentAt: sentAt, IntervalMinutes: 30}
+		if err := repo.AddPrompt(prompt); err != nil {
+			t.Fatalf("AddPrompt: %v", err)
+		}
+	}
+
+	if got := h.countMissedToday(context.Background(), staleTestUserID, now); got != 1 {
+		t.Errorf("countMissedToday() = %d, want 1", got)
+	}
+}
@@ -2,172 +2,189 @@ package routes
 
 import (
 	"TimeCounterBot/common"
-	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func StartCommand(message *tgbotapi.Message) {
-	user, err := db.GetUserByID(common.UserID(message.From.ID))
+func (h *Handlers) StartCommand(ctx context.Context, message *tgbotapi.Message) {
+	user, err := h.users.GetUserByID(common.UserID(message.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	msg := tgbotapi.NewMessage(
-		int64(user.ChatID),
-		"Hi! You are using Andrew's time management bot.\n"+
-			"Firstly, tell me the time interval in which you want to receive question about your activity.",
-	)
-	msg.ReplyMarkup = getStartCommandTimerIntervalsKeyboardMarkup()
+	lang := userLang(*user)
+	if h.resumeDelivery(ctx, user, common.ChatID(message.Chat.ID)) && user.TimerEnabled {
+		_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "start.welcome_back")))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "start.greeting"))
+	msg.ReplyMarkup = getStartCommandTimerIntervalsKeyboardMarkup(lang)
 
-	_, err = bot.Bot.Send(msg)
+	_, err = h.sender.Send(msg)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func SetTimerMinutesCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) SetTimerMinutesCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 	var timerMinutes int64
 	_, err = fmt.Sscanf(callback.Data, "start__set_timer_minutes %d", &timerMinutes)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 	user.TimerMinutes = sql.NullInt64{Int64: timerMinutes, Valid: true}
-	err = db.UpdateUser(*user)
+	// Как и в /settings, пишем только изменённые столбцы: строку пользователя
+	// одновременно меняет рассылка уведомлений.
+	err = h.users.UpdateUserColumns(user.ID, settingColumns(*user, settingInterval))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 
 	msg := tgbotapi.NewEditMessageTextAndMarkup(
 		int64(user.ChatID),
 		callback.Message.MessageID,
-		fmt.Sprintf(
-			"Nice, your interval is %d minutes!\nNow tell me the hour in UTC to start sending you reminders.",
-			timerMinutes,
-		),
+		i18n.T(userLang(*user), "start.interval_set", formatMinutes(userLang(*user), timerMinutes), h.zoneName()),
 		getScheduleMorningStartHourKeyboardMarkup(),
 	)
 
-	_, err = bot.Bot.Send(msg)
+	_, err = h.sender.Send(msg)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func SetScheduleMorningStartHourCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) SetScheduleMorningStartHourCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 	var scheduleMorningStartHour int64
 	_, err = fmt.Sscanf(callback.Data, "start__schedule_morning_start_hour %d", &scheduleMorningStartHour)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 	user.ScheduleMorningStartHour = sql.NullInt64{Int64: scheduleMorningStartHour, Valid: true}
-	err = db.UpdateUser(*user)
+	user.ScheduleMorningStartMinute = 0
+	err = h.users.UpdateUserColumns(user.ID, settingColumns(*user, settingStart))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 
 	msg := tgbotapi.NewEditMessageTextAndMarkup(
 		int64(user.ChatID),
 		callback.Message.MessageID,
-		fmt.Sprintf(
-			"Wonderful, your start hour will be %d:00 UTC!\nAnd now tell me the hour"+
-				" in UTC to finish sending reminders and send day statistics.",
-			scheduleMorningStartHour,
-		),
+		i18n.T(userLang(*user), "start.start_set", scheduleMorningStartHour, h.zoneName()),
 		getScheduleEveningFinishHourKeyboardMarkup(),
 	)
 
-	_, err = bot.Bot.Send(msg)
+	_, err = h.sender.Send(msg)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func SetScheduleEveningFinishHourCallback(callback *tgbotapi.CallbackQuery) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) SetScheduleEveningFinishHourCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 	var scheduleEveningFinishHour int64
 	_, err = fmt.Sscanf(callback.Data, "start__schedule_evening_finish_hour %d", &scheduleEveningFinishHour)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
 	}
 	user.ScheduleEveningFinishHour = sql.NullInt64{Int64: scheduleEveningFinishHour, Valid: true}
-	err = db.UpdateUser(*user)
+	user.ScheduleEveningFinishMinute = 0
+	err = h.users.UpdateUserColumns(user.ID, settingColumns(*user, settingFinish))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 
-	var text string
-	var keyboardMarkup tgbotapi.InlineKeyboardMarkup
+	lang := userLang(*user)
+	key := "start.finish_set"
+	keyboardMarkup := getEnableNotificationsKeyboardMarkup(lang)
 	if user.TimerEnabled {
-		text = "You will get notifications every %d minutes, from %d:00 UTC to %d:00 UTC.\n" +
-			"Notifications enabled! You can disable them by pressing button below."
-		keyboardMarkup = getDisableNotificationsKeyboardMarkup()
-	} else {
-		text = "Cool. You will get notifications every %d minutes, from %d:00 UTC to %d:00 UTC.\n" +
-			"Now click the button to enable notifications."
-		keyboardMarkup = getEnableNotificationsKeyboardMarkup()
+		key = "start.finish_set_enabled"
+		keyboardMarkup = getDisableNotificationsKeyboardMarkup(lang)
 	}
 
 	msg := tgbotapi.NewEditMessageTextAndMarkup(
 		int64(user.ChatID),
 		callback.Message.MessageID,
-		fmt.Sprintf(
-			text,
-			user.TimerMinutes.Int64,
+		i18n.T(lang, key,
+			formatMinutes(lang, user.TimerMinutes.Int64),
 			user.ScheduleMorningStartHour.Int64,
 			user.ScheduleEveningFinishHour.Int64,
+			h.zoneName(),
 		),
 		keyboardMarkup,
 	)
 
-	_, err = bot.Bot.Send(msg)
+	_, err = h.sender.Send(msg)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func EnableNotificationsCallback(callback *tgbotapi.CallbackQuery, enable bool) {
-	user, err := db.GetUserByID(common.UserID(callback.From.ID))
+func (h *Handlers) EnableNotificationsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, enable bool) {
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 	user.TimerEnabled = enable
-	err = db.UpdateUser(*user)
+	err = h.users.UpdateUserColumns(user.ID, map[string]any{"timer_enabled": enable})
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 
-	message := fmt.Sprintf("You will get notifications every %d minutes, from %d:00 UTC to %d:00 UTC.\n",
-		user.TimerMinutes.Int64,
-		user.ScheduleMorningStartHour.Int64,
-		user.ScheduleEveningFinishHour.Int64,
+	start, _ := scheduleMinute(user.ScheduleMorningStartHour, user.ScheduleMorningStartMinute)
+	finish, _ := scheduleMinute(user.ScheduleEveningFinishHour, user.ScheduleEveningFinishMinute)
+	lang := userLang(*user)
+	message := i18n.T(lang, "start.summary",
+		formatMinutes(lang, user.TimerMinutes.Int64),
+		formatClock(start),
+		formatClock(finish),
+		h.zoneName(),
 	)
 	if enable {
-		message += "Notifications enabled!"
+		message += i18n.T(lang, "start.enabled")
 	} else {
-		message += "Notifications disabled!"
+		message += i18n.T(lang, "start.disabled")
 	}
 
 	var keyboardMarkup tgbotapi.InlineKeyboardMarkup
 	if enable {
-		keyboardMarkup = getDisableNotificationsKeyboardMarkup()
+		keyboardMarkup = getDisableNotificationsKeyboardMarkup(lang)
 	} else {
-		keyboardMarkup = getEnableNotificationsKeyboardMarkup()
+		keyboardMarkup = getEnableNotificationsKeyboardMarkup(lang)
 	}
 
 	msg := tgbotapi.NewEditMessageTextAndMarkup(
@@ -177,24 +194,23 @@ func EnableNotificationsCallback(callback *tgbotapi.CallbackQuery, enable bool)
 		keyboardMarkup,
 	)
 
-	_, err = bot.Bot.Send(msg)
+	_, err = h.sender.Send(msg)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
 	}
 }
 
-func getStartCommandTimerIntervalsKeyboardMarkup() tgbotapi.InlineKeyboardMarkup {
+func getStartCommandTimerIntervalsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	button := func(minutes int64) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.InlineKeyboardButton{
+			Text:         formatMinutes(lang, minutes),
+			CallbackData: StringPtr(fmt.Sprintf("start__set_timer_minutes %d", minutes)),
+		}
+	}
 	return tgbotapi.NewInlineKeyboardMarkup(
-		append(
-			make([]tgbotapi.InlineKeyboardButton, 0),
-			tgbotapi.InlineKeyboardButton{Text: "10 minutes", CallbackData: StringPtr("start__set_timer_minutes 10")},
-			tgbotapi.InlineKeyboardButton{Text: "20 minutes", CallbackData: StringPtr("start__set_timer_minutes 20")},
-		),
-		append(
-			make([]tgbotapi.InlineKeyboardButton, 0),
-			tgbotapi.InlineKeyboardButton{Text: "30 minutes", CallbackData: StringPtr("start__set_timer_minutes 30")},
-			tgbotapi.InlineKeyboardButton{Text: "1 hour", CallbackData: StringPtr("start__set_timer_minutes 60")},
-		),
+		append(make([]tgbotapi.InlineKeyboardButton, 0), button(10), button(20)),
+		append(make([]tgbotapi.InlineKeyboardButton, 0), button(30), button(60)),
 	)
 }
 
@@ -233,21 +249,24 @@ func getScheduleEveningFinishHourKeyboardMarkup() tgbotapi.InlineKeyboardMarkup
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func getEnableNotificationsKeyboardMarkup() tgbotapi.InlineKeyboardMarkup {
+func getEnableNotificationsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		append(
 			make([]tgbotapi.InlineKeyboardButton, 0),
-			tgbotapi.InlineKeyboardButton{Text: "Enable notifications!", CallbackData: StringPtr("start__enable_notifications")},
+			tgbotapi.InlineKeyboardButton{
+				Text:         i18n.T(lang, "start.button_enable"),
+				CallbackData: StringPtr("start__enable_notifications"),
+			},
 		),
 	)
 }
 
-func getDisableNotificationsKeyboardMarkup() tgbotapi.InlineKeyboardMarkup {
+func getDisableNotificationsKeyboardMarkup(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
 	return tgbotapi.NewInlineKeyboardMarkup(
 		append(
 			make([]tgbotapi.InlineKeyboardButton, 0),
 			tgbotapi.InlineKeyboardButton{
-				Text:         "Disable notifications!",
+				Text:         i18n.T(lang, "start.button_disable"),
 				CallbackData: StringPtr("start__disable_notifications"),
 			},
 		),
@@ -1,16 +1,17 @@
 package routes
 
 import (
-	"log"
+	"context"
+	"database/sql"
+	"log/slog"
 
 	"TimeCounterBot/common"
-	"TimeCounterBot/db"
-	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/i18n"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func NotifyCommand(message *tgbotapi.Message, start bool) {
+func (h *Handlers) NotifyCommand(ctx context.Context, message *tgbotapi.Message, start bool) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -18,30 +19,38 @@ func NotifyCommand(message *tgbotapi.Message, start bool) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	userState := common.UserStates[userID]
+	userState := common.GetUserState(userID)
 
 	if userState.State == common.InCommand {
-		_, err = bot.Bot.Send(
-			tgbotapi.NewMessage(message.Chat.ID, "You're already executing some command"),
+		_, err = h.sender.Send(
+			tgbotapi.NewMessage(message.Chat.ID, i18n.T(userLang(*user), "common.already_in_command")),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+			return
 		}
 	}
 
-	user.TimerEnabled = start
-	err = db.UpdateUser(*user)
+	// Пишем только переключаемые столбцы: строку пользователя одновременно меняет
+	// рассылка уведомлений (last_notify, адаптивный интервал).
+	columns := map[string]any{"timer_enabled": start}
+	if start {
+		columns["snoozed_until"] = sql.NullTime{}
+	}
+	err = h.users.UpdateUserColumns(user.ID, columns)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка обновления пользователя", "err", err)
+		return
 	}
 }
 
-func TestNotifyCommand(message *tgbotapi.Message) {
+func (h *Handlers) TestNotifyCommand(ctx context.Context, message *tgbotapi.Message) {
 	tgUser := message.From
 	if tgUser == nil {
 		return
@@ -49,21 +58,35 @@ func TestNotifyCommand(message *tgbotapi.Message) {
 
 	userID := common.UserID(tgUser.ID)
 
-	user, err := db.GetUserByID(userID)
+	user, err := h.users.GetUserByID(userID)
 	if err != nil {
-		log.Fatal(err)
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
 	}
 
-	userState := common.UserStates[userID]
+	userState := common.GetUserState(userID)
 
 	if userState.State == common.InCommand {
-		_, err = bot.Bot.Send(
-			tgbotapi.NewMessage(message.Chat.ID, "You're already executing some command"),
+		_, err = h.sender.Send(
+			tgbotapi.NewMessage(message.Chat.ID, i18n.T(userLang(*user), "common.already_in_command")),
 		)
 		if err != nil {
-			log.Fatal(err)
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+			return
 		}
 	}
 
-	notifyUser(*user)
+	h.notifyUser(ctx, *user)
+}
+
+// SendTestNotification присылает пользователю userID внеочередное уведомление
+// с выбором активности. Используется подкомандой send-test-notification.
+func (h *Handlers) SendTestNotification(ctx context.Context, userID common.UserID) error {
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	h.notifyUser(ctx, *user)
+	return nil
 }
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestStartWizard проходит мастер /start и проверяет, что каждый шаг пишет только свои столбцы.
+func TestStartWizard(t *testing.T) {
+	const userID common.UserID = 13
+	ctx := context.Background()
+	h, repo, _, users := newColumnTestHandlers(t)
+	if err := repo.AddUser(db.User{ID: userID, ChatID: 13, Language: string(i18n.Russian)}); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	callback := func(handler func(context.Context, *tgbotapi.CallbackQuery), data string) func() {
+		return func() {
+			handler(ctx, &tgbotapi.CallbackQuery{
+				ID:      "1",
+				From:    &tgbotapi.User{ID: int64(userID)},
+				Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: int64(userID)}},
+				Data:    data,
+			})
+		}
+	}
+	enable := func(ctx context.Context, c *tgbotapi.CallbackQuery) { h.EnableNotificationsCallback(ctx, c, true) }
+
+	steps := []struct {
+		step        func()
+		wantColumns []string
+	}{
+		{
+			step:        callback(h.SetTimerMinutesCallback, "start__set_timer_minutes 30"),
+			wantColumns: []string{"timer_minutes"},
+		},
+		{
+			step:        callback(h.SetScheduleMorningStartHourCallback, "start__schedule_morning_start_hour 9"),
+			wantColumns: []string{"schedule_morning_start_hour", "schedule_morning_start_minute"},
+		},
+		{
+			step:        callback(h.SetScheduleEveningFinishHourCallback, "start__schedule_evening_finish_hour 18"),
+			wantColumns: []string{"schedule_evening_finish_hour", "schedule_evening_finish_minute"},
+		},
+		{step: callback(enable, "start__enable_notifications"), wantColumns: []string{"timer_enabled"}},
+	}
+	for i, step := range steps {
+		users.columns = nil
+		step.step()
+		if !slices.Equal(users.columns, step.wantColumns) {
+			t.Errorf("step %d wrote columns %v, want %v", i+1, users.columns, step.wantColumns)
+		}
+	}
+
+	user, err := repo.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.TimerMinutes.Int64 != 30 || user.ScheduleMorningStartHour.Int64 != 9 ||
+		user.ScheduleEveningFinishHour.Int64 != 18 || !user.TimerEnabled {
+		t.Errorf("user after /start = %+v, want 30 minutes from 9 to 18 with notifications on", user)
+	}
+}
@@ -0,0 +1,423 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxTimelineButtons — сколько кнопок редактирования слотов помещается под хронологией
+// (у Telegram ограничение в 100 кнопок на сообщение). Если слотов больше, кнопки
+// остаются только у последних.
+const maxTimelineButtons = 96
+
+// timelineButtonsPerRow — кнопок редактирования слотов в одном ряду.
+const timelineButtonsPerRow = 4
+
+// TimelineSlotData — слот хронологии для скрипта построения диаграммы Ганта.
+// Время задаётся в минутах от начала дня.
+type TimelineSlotData struct {
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	Name     string  `json:"name"`
+	Answered bool    `json:"answered"`
+}
+
+// TimelineData — данные для скрипта generate_timeline_chart.py.
+type TimelineData struct {
+	Date string `json:"date"`
+	// Zone — часовой пояс, в котором отсчитываются минуты слотов.
+	Zone  string             `json:"zone"`
+	Slots []TimelineSlotData `json:"slots"`
+}
+
+// TodayCommand обрабатывает команду /today и присылает хронологию сегодняшнего дня.
+func (h *Handlers) TodayCommand(ctx context.Context, message *tgbotapi.Message) {
+	today := startOfDay(time.Now(), h.location())
+	h.sendTimeline(ctx, common.UserID(message.From.ID), today, message.MessageID)
+}
+
+// DayCommand обрабатывает команду /day YYYY-MM-DD и присылает хронологию указанного дня.
+func (h *Handlers) DayCommand(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.Fields(message.Text)[1:]
+	var day time.Time
+	var err error
+	if len(args) == 1 {
+		day, err = time.ParseInLocation(time.DateOnly, args[0], h.location())
+	}
+	if len(args) != 1 || err != nil {
+		_, err = h.sender.Send(tgbotapi.NewMessage(message.Chat.ID,
+			i18n.T(h.langOf(common.UserID(message.From.ID)), "timeline.day_usage")))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	h.sendTimeline(ctx, common.UserID(message.From.ID), day, message.MessageID)
+}
+
+// sendTimeline присылает хронологию дня, начинающегося в day (полночь по часовому поясу
+// бота): текстом с кнопками редактирования
+// слотов и диаграммой Ганта. requestID нужен только для имени временного файла диаграммы.
+func (h *Handlers) sendTimeline(ctx context.Context, userID common.UserID, day time.Time, requestID int) {
+	user, err := h.users.GetUserByID(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	slots, err := h.logs.GetTimeline(user.ID, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения хронологии", "err", err)
+		h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID), i18n.T(lang, "timeline.error")))
+		return
+	}
+
+	if len(slots) == 0 {
+		_, err = h.sender.Send(tgbotapi.NewMessage(int64(user.ChatID),
+			i18n.T(lang, "timeline.empty", day.Format(time.DateOnly))))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		}
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), formatTimeline(lang, day, slots))
+	msgconf.ReplyMarkup = buildTimelineKeyboardMarkup(slots, day.Location())
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	outputFile := fmt.Sprintf("timeline_chart_%d_%d.png", user.ID, requestID)
+	if err := h.runChartScript(ctx, "generate_timeline_chart.py", buildTimelineData(day, slots), outputFile); err != nil {
+		slog.ErrorContext(ctx, "Ошибка построения диаграммы хронологии", "err", err)
+		return
+	}
+	defer removeChartFile(ctx, outputFile)
+
+	_, err = h.sender.Send(tgbotapi.NewPhoto(int64(user.ChatID), tgbotapi.FilePath(outputFile)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки изображения", "err", err)
+	}
+}
+
+// formatTimeline форматирует хронологию дня day; время показывается в часовом поясе day.
+// Подряд идущие слоты одной активности (и подряд идущие пропуски) объединяются в одну строку.
+func formatTimeline(lang i18n.Lang, day time.Time, slots []db.TimelineSlot) string {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(lang, "timeline.title", day.Format(time.DateOnly), day.Format("MST")))
+
+	var answeredMinutes, missedMinutes int64
+	for i := 0; i < len(slots); {
+		j := i + 1
+		for j < len(slots) && sameTimelineEntry(slots[i], slots[j]) && !slots[j].Start.After(slots[j-1].End) {
+			j++
+		}
+
+		label := slots[i].ActivityName
+		if !slots[i].Answered {
+			label = i18n.T(lang, "timeline.no_answer")
+		} else if label == "" {
+			label = i18n.T(lang, "unanswered.deleted_activity")
+		}
+		fmt.Fprintf(&sb, "%s–%s  %s\n",
+			slots[i].Start.In(day.Location()).Format("15:04"), slots[j-1].End.In(day.Location()).Format("15:04"), label)
+
+		for _, slot := range slots[i:j] {
+			if slot.Answered {
+				answeredMinutes += slot.IntervalMinutes
+			} else {
+				missedMinutes += slot.IntervalMinutes
+			}
+		}
+		i = j
+	}
+
+	sb.WriteString(i18n.T(lang, "timeline.totals",
+		formatMinutes(lang, answeredMinutes), formatMinutes(lang, missedMinutes)))
+	return sb.String()
+}
+
+func sameTimelineEntry(a, b db.TimelineSlot) bool {
+	return a.Answered == b.Answered && a.ActivityID == b.ActivityID
+}
+
+// buildTimelineKeyboardMarkup строит кнопки перехода к редактированию слотов хронологии
+// со временем начала слотов в часовом поясе loc.
+func buildTimelineKeyboardMarkup(slots []db.TimelineSlot, loc *time.Location) tgbotapi.InlineKeyboardMarkup {
+	if len(slots) > maxTimelineButtons {
+		slots = slots[len(slots)-maxTimelineButtons:]
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for i, slot := range slots {
+		// Части разделённого интервала относятся к одному уведомлению — кнопка одна.
+		if i > 0 && slots[i-1].MessageID == slot.MessageID {
+			continue
+		}
+		text := "✏️ " + slot.Start.In(loc).Format("15:04")
+		if !slot.Answered {
+			text = "⏳ " + slot.Start.In(loc).Format("15:04")
+		}
+		buttons = append(buttons,
+			tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("timeline__edit %d", slot.MessageID)))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for row := range slices.Chunk(buttons, timelineButtonsPerRow) {
+		rows = append(rows, row)
+	}
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// buildTimelineData готовит хронологию для скрипта диаграммы Ганта.
+func buildTimelineData(day time.Time, slots []db.TimelineSlot) TimelineData {
+	data := TimelineData{Date: day.Format(time.DateOnly), Zone: day.Format("MST")}
+	for _, slot := range slots {
+		data.Slots = append(data.Slots, TimelineSlotData{
+			Start:    slot.Start.Sub(day).Minutes(),
+			End:      slot.End.Sub(day).Minutes(),
+			Name:     slot.ActivityName,
+			Answered: slot.Answered,
+		})
+	}
+	return data
+}
+
+// TimelineEditCallback присылает дерево активностей, чтобы указать активность для слота хронологии.
+func (h *Handlers) TimelineEditCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var messageID int64
+	_, err := fmt.Sscanf(callback.Data, "timeline__edit %d", &messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	slot, err := h.logs.GetTimelineSlot(user.ID, messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения слота хронологии", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "timeline.slot_not_found")))
+		return
+	}
+
+	msgconf := tgbotapi.NewMessage(int64(user.ChatID), slotLogText(userLang(*user), *slot, h.location()))
+	msgconf.ReplyMarkup = h.buildSlotLogKeyboardMarkup(ctx, *user, *slot, -1)
+
+	_, err = h.sender.Send(msgconf)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка отправки сообщения", "err", err)
+		return
+	}
+
+	h.sender.Request(tgbotapi.NewCallback(callback.ID, ""))
+}
+
+// TimelineChangeCallback снова показывает дерево активностей под сохранённым слотом.
+func (h *Handlers) TimelineChangeCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var messageID int64
+	_, err := fmt.Sscanf(callback.Data, "timeline__change %d", &messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	slot, err := h.logs.GetTimelineSlot(user.ID, messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения слота хронологии", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "timeline.slot_not_found")))
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		slotLogText(userLang(*user), *slot, h.location()), h.buildSlotLogKeyboardMarkup(ctx, *user, *slot, -1)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// TimelineRemoveCallback удаляет ответ, записанный в слот хронологии. Слот
+// уведомления после этого снова считается пропуском.
+func (h *Handlers) TimelineRemoveCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var messageID int64
+	_, err := fmt.Sscanf(callback.Data, "timeline__remove %d", &messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	slot, err := h.logs.GetTimelineSlot(user.ID, messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения слота хронологии", "err", err)
+		h.sender.Request(tgbotapi.NewCallback(callback.ID, i18n.T(userLang(*user), "timeline.slot_not_found")))
+		return
+	}
+
+	if err := h.logs.DeleteActivityLog(user.ID, messageID); err != nil {
+		slog.ErrorContext(ctx, "Ошибка удаления лога активности", "err", err)
+		return
+	}
+
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		i18n.T(userLang(*user), "timeline.removed", formatSlotInterval(*slot, h.location())),
+		tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(userLang(*user), "timeline.button_fill"),
+				fmt.Sprintf("timeline__change %d", messageID)),
+		)),
+	))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// SlotLogCallback записывает выбранную активность в слот хронологии. Callback data:
+// "slot_log <message_id> <activity_id> <timer_minutes>"; длительность берётся из самого слота.
+func (h *Handlers) SlotLogCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	var messageID, nodeID, timerMinutes int64
+	_, err := fmt.Sscanf(callback.Data, "slot_log %d %d %d", &messageID, &nodeID, &timerMinutes)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка разбора callback data", "err", err)
+		return
+	}
+
+	user, err := h.users.GetUserByID(common.UserID(callback.From.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения пользователя", "err", err)
+		return
+	}
+
+	isMuted := false
+	activities, err := h.activities.GetSimpleActivities(user.ID, &isMuted, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения активностей", "err", err)
+		return
+	}
+
+	idx := slices.IndexFunc(activities, func(a db.Activity) bool { return a.ID == nodeID })
+	if idx == -1 {
+		slog.ErrorContext(ctx, "Активность не найдена среди активностей пользователя", "activity_id", nodeID)
+		return
+	}
+
+	slot, err := h.logs.GetTimelineSlot(user.ID, messageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения слота хронологии", "err", err)
+		return
+	}
+
+	if !activities[idx].IsLeaf {
+		_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+			callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text,
+			h.buildSlotLogKeyboardMarkup(ctx, *user, *slot, nodeID)))
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+		}
+		return
+	}
+
+	err = h.logs.ReplaceActivityLogs(user.ID, messageID, []db.ActivityLog{{
+		ActivityID:      nodeID,
+		Timestamp:       slot.End,
+		IntervalMinutes: slot.IntervalMinutes,
+	}})
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения лога активности", "err", err)
+		return
+	}
+
+	activityName, err := h.activities.GetFullActivityNameByID(nodeID, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка получения названия активности", "err", err)
+		return
+	}
+
+	lang := userLang(*user)
+	_, err = h.sender.Send(tgbotapi.NewEditMessageTextAndMarkup(
+		callback.Message.Chat.ID, callback.Message.MessageID,
+		i18n.T(lang, "timeline.saved", formatSlotInterval(*slot, h.location()), activityName),
+		tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.change"), fmt.Sprintf("timeline__change %d", messageID)),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.remove"), fmt.Sprintf("timeline__remove %d", messageID)),
+		)),
+	))
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка редактирования сообщения", "err", err)
+	}
+}
+
+// TimelineCancelCallback удаляет сообщение выбора активности для слота.
+func (h *Handlers) TimelineCancelCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) {
+	_, err := h.sender.Request(
+		tgbotapi.NewDeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка запроса к Telegram", "err", err)
+	}
+}
+
+// slotLogText — вопрос об активности для слота хронологии со временем в часовом поясе loc.
+func slotLogText(lang i18n.Lang, slot db.TimelineSlot, loc *time.Location) string {
+	text := i18n.T(lang, "timeline.slot_question", formatSlotInterval(slot, loc))
+	if slot.Answered {
+		text += i18n.T(lang, "timeline.slot_current", slot.ActivityName)
+	}
+	return text
+}
+
+// buildSlotLogKeyboardMarkup строит дерево активностей для слота хронологии. Если в слот
+// уже записан ответ, в последнем ряду есть кнопка его удаления.
+func (h *Handlers) buildSlotLogKeyboardMarkup(
+	ctx context.Context, user db.User, slot db.TimelineSlot, parentActivityID int64,
+) tgbotapi.InlineKeyboardMarkup {
+	lang := userLang(user)
+	lastRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.cancel"), "timeline__cancel"))
+	if slot.Answered {
+		lastRow = append(lastRow,
+			tgbotapi.NewInlineKeyboardButtonData(
+				i18n.T(lang, "button.remove"), fmt.Sprintf("timeline__remove %d", slot.MessageID)))
+	}
+
+	isMuted := false
+	return h.buildActivitiesKeyboardMarkupForUser(
+		ctx, user, parentActivityID, &isMuted, nil, fmt.Sprintf("slot_log %d", slot.MessageID), lastRow)
+}
+
+// formatSlotInterval форматирует интервал слота в часовом поясе loc: "2025-01-31 09:00–10:00 MSK".
+func formatSlotInterval(slot db.TimelineSlot, loc *time.Location) string {
+	start, end := slot.Start.In(loc), slot.End.In(loc)
+	return fmt.Sprintf("%s %s–%s %s",
+		end.Format(time.DateOnly), start.Format("15:04"), end.Format("15:04"), end.Format("MST"))
+}
@@ -0,0 +1,226 @@
+package routes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// timelineSlot — слот хронологии длиной minutes, заканчивающийся в end.
+func timelineSlot(messageID int64, end time.Time, minutes int64, activityID int64, name string) db.TimelineSlot {
+	return db.TimelineSlot{
+		MessageID:       messageID,
+		Start:           end.Add(-time.Duration(minutes) * time.Minute),
+		End:             end,
+		IntervalMinutes: minutes,
+		Answered:        activityID != 0,
+		ActivityID:      activityID,
+		ActivityName:    name,
+	}
+}
+
+func TestFormatTimeline(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, moscow)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	noAnswer := i18n.T(i18n.Russian, "timeline.no_answer")
+
+	tests := []struct {
+		name         string
+		slots        []db.TimelineSlot
+		wantLines    []string
+		wantAnswered int64
+		wantMissed   int64
+	}{
+		{
+			name: "consecutive slots of one activity are merged",
+			slots: []db.TimelineSlot{
+				timelineSlot(1, at(9, 30), 30, 1, "Работа"),
+				timelineSlot(2, at(10, 0), 30, 1, "Работа"),
+				timelineSlot(3, at(10, 30), 30, 2, "Обед"),
+			},
+			wantLines:    []string{"09:00–10:00  Работа", "10:00–10:30  Обед"},
+			wantAnswered: 90,
+		},
+		{
+			name: "gap between slots splits an activity",
+			slots: []db.TimelineSlot{
+				timelineSlot(1, at(9, 30), 30, 1, "Работа"),
+				timelineSlot(2, at(11, 0), 30, 1, "Работа"),
+			},
+			wantLines:    []string{"09:00–09:30  Работа", "10:30–11:00  Работа"},
+			wantAnswered: 60,
+		},
+		{
+			name: "missed prompts are merged and counted separately",
+			slots: []db.TimelineSlot{
+				timelineSlot(1, at(9, 30), 30, 0, ""),
+				timelineSlot(2, at(10, 0), 30, 0, ""),
+				timelineSlot(3, at(10, 30), 30, 1, "Работа"),
+			},
+			wantLines:    []string{"09:00–10:00  " + noAnswer, "10:00–10:30  Работа"},
+			wantAnswered: 30,
+			wantMissed:   60,
+		},
+		{
+			name:         "deleted activity",
+			slots:        []db.TimelineSlot{timelineSlot(1, at(9, 30), 30, 5, "")},
+			wantLines:    []string{"09:00–09:30  " + i18n.T(i18n.Russian, "unanswered.deleted_activity")},
+			wantAnswered: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Слоты хранятся в UTC, а показываться должны в часовом поясе дня.
+			for i := range tt.slots {
+				tt.slots[i].Start = tt.slots[i].Start.UTC()
+				tt.slots[i].End = tt.slots[i].End.UTC()
+			}
+
+			got := formatTimeline(i18n.Russian, day, tt.slots)
+
+			title := i18n.T(i18n.Russian, "timeline.title", "2024-05-10", "MSK")
+			totals := i18n.T(i18n.Russian, "timeline.totals",
+				formatMinutes(i18n.Russian, tt.wantAnswered), formatMinutes(i18n.Russian, tt.wantMissed))
+			want := title + strings.Join(tt.wantLines, "\n") + "\n" + totals
+			if got != want {
+				t.Errorf("formatTimeline() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestBuildTimelineKeyboardMarkup(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	end := time.Date(2024, 5, 10, 6, 30, 0, 0, time.UTC)
+
+	t.Run("split parts share a button", func(t *testing.T) {
+		slots := []db.TimelineSlot{
+			timelineSlot(1, end, 30, 1, "Работа"),
+			timelineSlot(1, end, 30, 2, "Обед"),
+			timelineSlot(2, end.Add(30*time.Minute), 30, 0, ""),
+		}
+		markup := buildTimelineKeyboardMarkup(slots, moscow)
+
+		if len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 2 {
+			t.Fatalf("keyboard = %+v, want one row of two buttons", markup.InlineKeyboard)
+		}
+		wantTexts := []string{"✏️ 09:00", "⏳ 09:30"}
+		wantData := []string{"timeline__edit 1", "timeline__edit 2"}
+		for i, button := range markup.InlineKeyboard[0] {
+			if button.Text != wantTexts[i] || *button.CallbackData != wantData[i] {
+				t.Errorf("button %d = (%q, %q), want (%q, %q)",
+					i, button.Text, *button.CallbackData, wantTexts[i], wantData[i])
+			}
+		}
+	})
+
+	t.Run("only the latest slots get buttons", func(t *testing.T) {
+		var slots []db.TimelineSlot
+		for i := range maxTimelineButtons + 10 {
+			slots = append(slots, timelineSlot(int64(i+1), end.Add(time.Duration(i)*time.Minute), 1, 1, "Работа"))
+		}
+		markup := buildTimelineKeyboardMarkup(slots, time.UTC)
+
+		var buttons int
+		for _, row := range markup.InlineKeyboard {
+			if len(row) > timelineButtonsPerRow {
+				t.Errorf("row has %d buttons, want at most %d", len(row), timelineButtonsPerRow)
+			}
+			buttons += len(row)
+		}
+		if buttons != maxTimelineButtons {
+			t.Errorf("got %d buttons, want %d", buttons, maxTimelineButtons)
+		}
+		if first := *markup.InlineKeyboard[0][0].CallbackData; first != "timeline__edit 11" {
+			t.Errorf("first button = %q, want %q", first, "timeline__edit 11")
+		}
+	})
+}
+
+func TestBuildTimelineData(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, moscow)
+	slots := []db.TimelineSlot{
+		timelineSlot(1, day.Add(90*time.Minute).UTC(), 30, 1, "Работа"),
+		timelineSlot(2, day.Add(2*time.Hour).UTC(), 30, 0, ""),
+	}
+
+	data := buildTimelineData(day, slots)
+
+	if data.Date != "2024-05-10" || data.Zone != "MSK" {
+		t.Errorf("date, zone = %q, %q, want %q, %q", data.Date, data.Zone, "2024-05-10", "MSK")
+	}
+	want := []TimelineSlotData{
+		{Start: 60, End: 90, Name: "Работа", Answered: true},
+		{Start: 90, End: 120},
+	}
+	if len(data.Slots) != len(want) {
+		t.Fatalf("got %d slots, want %d", len(data.Slots), len(want))
+	}
+	for i := range want {
+		if data.Slots[i] != want[i] {
+			t.Errorf("slot %d = %+v, want %+v", i, data.Slots[i], want[i])
+		}
+	}
+}
+
+// TestTimelineEditAndRemove проверяет удаление ответа из хронологии и повторное заполнение слота.
+func TestTimelineEditAndRemove(t *testing.T) {
+	ctx := context.Background()
+	h, repo, sender := newTestHandlers(t)
+	prompt, callback := notifyTestUser(t, h, repo, sender)
+
+	h.LogUserActivityCallback(ctx, callback(buttonData(t, prompt.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup), "Работа")))
+	h.LogUserActivityCallback(ctx, callback(buttonData(t, *lastEdit(t, sender).ReplyMarkup, "Код")))
+
+	slot, err := repo.GetTimelineSlot(testNotifyUserID, 1)
+	if err != nil {
+		t.Fatalf("GetTimelineSlot: %v", err)
+	}
+	interval := formatSlotInterval(*slot, time.UTC)
+
+	h.TimelineRemoveCallback(ctx, callback("timeline__remove 1"))
+	removed := lastEdit(t, sender)
+	if want := i18n.T(i18n.Russian, "timeline.removed", interval); removed.Text != want {
+		t.Errorf("removed text = %q, want %q", removed.Text, want)
+	}
+	slot, err = repo.GetTimelineSlot(testNotifyUserID, 1)
+	if err != nil {
+		t.Fatalf("GetTimelineSlot: %v", err)
+	}
+	if slot.Answered {
+		t.Errorf("slot is still answered after remove: %+v", slot)
+	}
+
+	// Заполняем пропуск заново через дерево активностей слота.
+	h.TimelineEditCallback(ctx, callback("timeline__edit 1"))
+	sent := sender.Sent()
+	question, ok := sent[len(sent)-1].(tgbotapi.MessageConfig)
+	if !ok {
+		t.Fatalf("last sent %T, want tgbotapi.MessageConfig", sent[len(sent)-1])
+	}
+	h.SlotLogCallback(ctx, callback(buttonData(t, question.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup), "Работа")))
+	h.SlotLogCallback(ctx, callback(buttonData(t, *lastEdit(t, sender).ReplyMarkup, "Код")))
+
+	saved := lastEdit(t, sender)
+	if want := i18n.T(i18n.Russian, "timeline.saved", interval, "Работа / Код"); saved.Text != want {
+		t.Errorf("saved text = %q, want %q", saved.Text, want)
+	}
+	slot, err = repo.GetTimelineSlot(testNotifyUserID, 1)
+	if err != nil {
+		t.Fatalf("GetTimelineSlot: %v", err)
+	}
+	if !slot.Answered || slot.ActivityName != "Работа / Код" || slot.IntervalMinutes != 30 {
+		t.Errorf("slot after refill = %+v, want 30 minutes of %q", slot, "Работа / Код")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"TimeCounterBot/config"
+	"TimeCounterBot/db"
+	"TimeCounterBot/routes"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// runSendTestNotificationCommand выполняет подкоманду `send-test-notification USER_ID`:
+// присылает пользователю уведомление с выбором активности вне расписания.
+func runSendTestNotificationCommand(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: bot send-test-notification USER_ID")
+	}
+	userID, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := cfg.RequireTelegram(); err != nil {
+		return err
+	}
+
+	repo, err := db.InitDB(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	botAPI, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	handlers := routes.NewHandlers(repo, repo, repo, repo, outboundQueue(ctx, cfg, botAPI), handlerSettings(cfg))
+	if err := handlers.SendTestNotification(ctx, userID); err != nil {
+		return fmt.Errorf("notify user %d: %w", userID, err)
+	}
+	fmt.Printf("notification sent to user %d\n", userID)
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"TimeCounterBot/config"
+	"TimeCounterBot/db"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/routes"
+	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/tg/router"
+	"TimeCounterBot/web"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// runServeCommand запускает бота: приём обновлений, рассылку уведомлений и, если
+// заданы http.addr и http.internal_addr, HTTP-серверы ICS-лент и метрик. По SIGINT/SIGTERM
+// бот перестаёт принимать обновления и рассылать уведомления, дообрабатывает уже
+// полученные обновления, затем отменяет контекст обработчиков и ждёт завершения
+// начатой работы не дольше scheduler.shutdown_timeout (см. stopServing).
+func runServeCommand(cfg config.Config) error {
+	if err := cfg.RequireTelegram(); err != nil {
+		return err
+	}
+
+	repo, err := db.InitDB(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+
+	botAPI, err := tgbotapi.NewBotAPI(cfg.Telegram.Token)
+	if err != nil {
+		return err
+	}
+
+	// Set this to true to log all interactions with telegram servers
+	botAPI.Debug = cfg.Telegram.Debug
+
+	// Создаем контекст с возможностью отмены: он отменяется по сигналу.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // гарантируем вызов cancel при завершении
+
+	// Контекст обработчиков и фоновых циклов отменяется отдельно, уже после того,
+	// как обработаны все полученные обновления.
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+
+	// Циклы рассылки уведомлений и автозаполнения останавливаются первыми.
+	loopsCtx, stopLoops := context.WithCancel(handlersCtx)
+	defer stopLoops()
+
+	// Все исходящие запросы идут через очередь с ограничением частоты и повторами.
+	sender := outboundQueue(handlersCtx, cfg, botAPI)
+	handlers := routes.NewHandlers(repo, repo, repo, repo, sender, handlerSettings(cfg))
+	updateRouter := router.New(handlers, repo, sender)
+
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = 60
+
+	// Настраиваем обработку сигналов для корректного завершения работы.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		slog.Info("Получен сигнал, завершаем работу", "signal", sig.String())
+		cancel()
+	}()
+
+	updates := botAPI.GetUpdatesChan(updateConfig)
+
+	// received закрывается, когда роутер прочитал канал обновлений до конца.
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		updateRouter.ReceiveUpdates(logging.WithComponent(handlersCtx, "router"), updates)
+	}()
+
+	// workers — долгоживущие горутины, которые завершаются после отмены handlersCtx.
+	var workers sync.WaitGroup
+	runWorker := func(f func()) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			f()
+		}()
+	}
+
+	runWorker(updateRouter.SetCommands)
+	runWorker(func() { handlers.DispatchNotifications(logging.WithComponent(loopsCtx, "dispatcher")) })
+	runWorker(func() { handlers.AutoFillMissedPrompts(logging.WithComponent(loopsCtx, "auto_fill")) })
+
+	// HTTP-серверы запускаются по желанию: публичный отдаёт ICS-ленты, служебный —
+	// метрики и проверки здоровья.
+	if cfg.HTTP.Addr != "" {
+		runWorker(func() { web.ListenAndServe(handlersCtx, cfg.HTTP.Addr, web.PublicHandler(repo, repo)) })
+	}
+	if cfg.HTTP.InternalAddr != "" {
+		dbCheck := web.Check{Name: "database", Func: repo.Ping}
+		telegramCheck := web.Check{Name: "telegram", Func: func(context.Context) error {
+			_, err := botAPI.GetMe()
+			return err
+		}}
+		runWorker(func() {
+			web.ListenAndServe(handlersCtx, cfg.HTTP.InternalAddr, web.InternalHandler(web.HealthChecks{
+				Liveness:  []web.Check{dbCheck},
+				Readiness: []web.Check{dbCheck, telegramCheck},
+			}))
+		})
+	}
+
+	slog.Info("Бот запущен, ожидаем обновления")
+
+	// Блокируем выполнение до получения cancel.
+	<-ctx.Done()
+	botAPI.StopReceivingUpdates()
+	slog.Info("Приём обновлений остановлен, ждём завершения начатой работы",
+		"timeout", cfg.Scheduler.ShutdownTimeout)
+
+	drained := make(chan struct{})
+	go func() {
+		stopServing(received, stopLoops, cancelHandlers, updateRouter, handlers, &workers)
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		slog.Info("Бот остановлен")
+	case <-time.After(cfg.Scheduler.ShutdownTimeout):
+		slog.Warn("Не дождались завершения обработчиков, останавливаемся принудительно")
+	}
+	return nil
+}
+
+// stopServing останавливает работу бота после того, как перестали приниматься обновления.
+// Порядок важен:
+//   - сначала останавливаются циклы рассылки и автозаполнения (stopLoops), чтобы во время
+//     остановки пользователям не приходили новые уведомления;
+//   - когда роутер дочитал канал обновлений (received), диалоги перестают ждать ответов:
+//     ответов уже не будет, а ждущий обработчик не дал бы дождаться остальных;
+//   - после обработчиков обновлений отменяется контекст остальной работы (cancelHandlers)
+//     и дожидаются долгоживущие горутины (workers) и фоновые задачи обработчиков.
+func stopServing(
+	received <-chan struct{}, stopLoops, cancelHandlers context.CancelFunc,
+	updateRouter *router.Router, handlers *routes.Handlers, workers *sync.WaitGroup,
+) {
+	stopLoops()
+	<-received
+	handlers.StopDialogs()
+	updateRouter.Wait()
+	cancelHandlers()
+	workers.Wait()
+	handlers.Wait()
+}
+
+// outboundQueue оборачивает sender в очередь исходящих запросов с настройками из cfg.
+// Отмена ctx прерывает ожидание в очереди.
+func outboundQueue(ctx context.Context, cfg config.Config, sender bot.Sender) *bot.Queue {
+	return bot.NewQueue(ctx, sender, bot.QueueOptions{
+		MessagesPerSecond: cfg.Telegram.MessagesPerSecond,
+		ChatInterval:      cfg.Telegram.ChatInterval,
+		MaxRetries:        cfg.Telegram.MaxRetries,
+	})
+}
+
+// handlerSettings переносит в обработчики относящуюся к ним часть конфигурации.
+func handlerSettings(cfg config.Config) routes.Settings {
+	// Часовой пояс уже проверен в config.Validate.
+	location, _ := cfg.Scheduler.Location()
+	return routes.Settings{
+		DispatchInterval:     cfg.Scheduler.DispatchInterval,
+		DayStatsWaitDuration: cfg.Scheduler.DayStatsWaitDuration,
+		AutoFillInterval:     cfg.Scheduler.AutoFillInterval,
+		PythonBin:            cfg.Charts.PythonBin,
+		ChartScriptsDir:      cfg.Charts.ScriptsDir,
+		PublicURL:            cfg.HTTP.PublicURL,
+		Location:             location,
+	}
+}
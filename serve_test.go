@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+	"TimeCounterBot/routes"
+	"TimeCounterBot/tg/bot"
+	"TimeCounterBot/tg/router"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestStopServingWithOpenDialog проверяет, что остановка не ждёт ответа в открытом
+// диалоге до shutdown_timeout, а рассылка уведомлений останавливается сразу.
+func TestStopServingWithOpenDialog(t *testing.T) {
+	const userID common.UserID = 77
+
+	repo, err := db.NewMemoryRepository()
+	if err != nil {
+		t.Fatalf("NewMemoryRepository: %v", err)
+	}
+	sender := bot.NewFakeSender()
+	handlers := routes.NewHandlers(repo, repo, repo, repo, sender, routes.Settings{
+		DispatchInterval: 10 * time.Millisecond,
+		AutoFillInterval: 10 * time.Millisecond,
+		Location:         time.UTC,
+	})
+	updateRouter := router.New(handlers, repo, sender)
+	t.Cleanup(func() { common.SetUserState(userID, common.UserState{}) })
+
+	handlersCtx, cancelHandlers := context.WithCancel(context.Background())
+	defer cancelHandlers()
+	loopsCtx, stopLoops := context.WithCancel(handlersCtx)
+	defer stopLoops()
+
+	var workers sync.WaitGroup
+	for _, loop := range []func(context.Context){handlers.DispatchNotifications, handlers.AutoFillMissedPrompts} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			loop(loopsCtx)
+		}()
+	}
+
+	updates := make(chan tgbotapi.Update, 1)
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		updateRouter.ReceiveUpdates(handlersCtx, updates)
+	}()
+	updates <- tgbotapi.Update{
+		UpdateID: 1,
+		Message: &tgbotapi.Message{
+			MessageID: 1,
+			From:      &tgbotapi.User{ID: int64(userID), LanguageCode: "ru"},
+			Chat:      &tgbotapi.Chat{ID: int64(userID)},
+			Text:      "/register_new_activity",
+		},
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for common.GetUserState(userID).WaitingChannel == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("dialog was not opened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(updates)
+
+	stopped := make(chan struct{})
+	go func() {
+		stopServing(received, stopLoops, cancelHandlers, updateRouter, handlers, &workers)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopServing is still waiting for the open dialog")
+	}
+
+	if state := common.GetUserState(userID); state.WaitingChannel != nil {
+		t.Errorf("dialog state after shutdown = %+v, want no waiting channel", state)
+	}
+	if common.DeliverReply(userID, "Работа / Код") {
+		t.Error("a reply after shutdown was delivered to the closed dialog")
+	}
+	if handlersCtx.Err() == nil {
+		t.Error("handlers context is not cancelled after shutdown")
+	}
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// IsBlocked сообщает, что Telegram не даёт писать в чат: пользователь заблокировал
+// бота, удалил аккаунт или бот исключён из группы.
+func IsBlocked(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden
+}
+
+// IsChatNotFound сообщает, что чата, в который отправляется сообщение, больше нет.
+func IsChatNotFound(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(apiErr.Message), "chat not found")
+}
+
+// IsPermanent сообщает, что Telegram отклонил запрос и повтор вернёт ту же ошибку:
+// это ответы 4xx, кроме 429 (слишком много запросов).
+func IsPermanent(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code >= http.StatusBadRequest &&
+		apiErr.Code < http.StatusInternalServerError && apiErr.Code != http.StatusTooManyRequests
+}
+
+// MigratedChatID возвращает новый ID чата, если группа, в которую отправлялось
+// сообщение, преобразована в супергруппу.
+func MigratedChatID(err error) (int64, bool) {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.MigrateToChatID != 0 {
+		return apiErr.MigrateToChatID, true
+	}
+	return 0, false
+}
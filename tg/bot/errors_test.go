@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestDeliveryErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		wantBlocked      bool
+		wantChatNotFound bool
+		wantPermanent    bool
+		wantMigratedTo   int64
+	}{
+		{name: "nil"},
+		{name: "other error", err: errors.New("boom")},
+		{
+			name:          "blocked",
+			err:           &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"},
+			wantBlocked:   true,
+			wantPermanent: true,
+		},
+		{
+			name:          "wrapped blocked",
+			err:           fmt.Errorf("send: %w", &tgbotapi.Error{Code: 403, Message: "Forbidden: user is deactivated"}),
+			wantBlocked:   true,
+			wantPermanent: true,
+		},
+		{
+			name:             "chat not found",
+			err:              &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"},
+			wantChatNotFound: true,
+			wantPermanent:    true,
+		},
+		{
+			name:          "other bad request",
+			err:           &tgbotapi.Error{Code: 400, Message: "Bad Request: message is too long"},
+			wantPermanent: true,
+		},
+		{name: "too many requests", err: &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after 5"}},
+		{name: "server error", err: &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}},
+		{
+			name: "chat migrated",
+			err: &tgbotapi.Error{
+				Code:               400,
+				Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+				ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -1001},
+			},
+			wantMigratedTo: -1001,
+			wantPermanent:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBlocked(tt.err); got != tt.wantBlocked {
+				t.Errorf("IsBlocked() = %v, want %v", got, tt.wantBlocked)
+			}
+			if got := IsChatNotFound(tt.err); got != tt.wantChatNotFound {
+				t.Errorf("IsChatNotFound() = %v, want %v", got, tt.wantChatNotFound)
+			}
+			if got := IsPermanent(tt.err); got != tt.wantPermanent {
+				t.Errorf("IsPermanent() = %v, want %v", got, tt.wantPermanent)
+			}
+			chatID, ok := MigratedChatID(tt.err)
+			if chatID != tt.wantMigratedTo || ok != (tt.wantMigratedTo != 0) {
+				t.Errorf("MigratedChatID() = (%d, %v), want %d", chatID, ok, tt.wantMigratedTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FakeSender — Sender для тестов: ничего не отправляет в Telegram, а запоминает
+// все запросы. Send возвращает сообщение с новым MessageID, как настоящий API.
+type FakeSender struct {
+	mu            sync.Mutex
+	lastMessageID int
+	sent          []tgbotapi.Chattable
+	requests      []tgbotapi.Chattable
+
+	// FileURLs — ссылки на файлы по FileID, которые вернёт GetFileDirectURL
+	// (в тестах удобно указывать адрес httptest.Server).
+	FileURLs map[string]string
+}
+
+var _ Sender = (*FakeSender)(nil)
+
+// NewFakeSender создаёт пустой FakeSender.
+func NewFakeSender() *FakeSender {
+	return &FakeSender{FileURLs: make(map[string]string)}
+}
+
+// Send запоминает запрос и возвращает «отправленное» сообщение.
+func (f *FakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, c)
+	f.lastMessageID++
+
+	message := tgbotapi.Message{
+		MessageID: f.lastMessageID,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatIDOf(c)},
+	}
+	if msg, ok := c.(tgbotapi.MessageConfig); ok {
+		message.Text = msg.Text
+		if markup, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+			message.ReplyMarkup = &markup
+		}
+	}
+	return message, nil
+}
+
+// Request запоминает запрос и возвращает успешный ответ.
+func (f *FakeSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, c)
+	return &tgbotapi.APIResponse{Ok: true, Result: json.RawMessage("true")}, nil
+}
+
+// GetFileDirectURL возвращает ссылку на файл из FileURLs.
+func (f *FakeSender) GetFileDirectURL(fileID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	url, ok := f.FileURLs[fileID]
+	if !ok {
+		return "", fmt.Errorf("file %q not found", fileID)
+	}
+	return url, nil
+}
+
+// Sent возвращает копию всех запросов, переданных в Send.
+func (f *FakeSender) Sent() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), f.sent...)
+}
+
+// Requests возвращает копию всех запросов, переданных в Request.
+func (f *FakeSender) Requests() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), f.requests...)
+}
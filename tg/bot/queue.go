@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"TimeCounterBot/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// retryBackoff — пауза перед первым повтором после временной ошибки; дальше удваивается.
+const retryBackoff = time.Second
+
+// maxChatSlots — сколько чатов помнить для ограничения частоты, прежде чем
+// выбросить те, чьи слоты уже прошли.
+const maxChatSlots = 1024
+
+// QueueOptions — ограничения частоты и повторов исходящих запросов.
+type QueueOptions struct {
+	// MessagesPerSecond — общий предел запросов к Telegram в секунду.
+	MessagesPerSecond int
+	// ChatInterval — минимальный интервал между запросами в один чат.
+	ChatInterval time.Duration
+	// MaxRetries — сколько раз повторять запрос после 429 или временной ошибки.
+	MaxRetries int
+}
+
+// Queue — очередь исходящих запросов к Telegram. Реализует Sender поверх другого
+// Sender: каждый запрос ждёт своего слота с учётом общего предела и предела на чат,
+// после ответа 429 очередь приостанавливается на retry_after, временные ошибки
+// (сеть, 5xx) повторяются с экспоненциальной паузой. Запросы, которые не удалось
+// отправить и после повторов, пишутся в лог как dead letter.
+//
+// Send и Request блокируются до отправки: обработчикам нужен ответ Telegram
+// (например, MessageID отправленного сообщения). После отмены контекста очереди
+// ожидания прерываются, и запросы, которым пришлось бы ждать, завершаются ошибкой.
+type Queue struct {
+	ctx     context.Context
+	sender  Sender
+	options QueueOptions
+
+	mu          sync.Mutex
+	nextGlobal  time.Time
+	nextForChat map[int64]time.Time
+}
+
+var _ Sender = (*Queue)(nil)
+
+// NewQueue создаёт очередь поверх sender; ctx прерывает ожидание слотов и пауз перед повторами.
+func NewQueue(ctx context.Context, sender Sender, options QueueOptions) *Queue {
+	return &Queue{
+		ctx:         ctx,
+		sender:      sender,
+		options:     options,
+		nextForChat: make(map[int64]time.Time),
+	}
+}
+
+// Send отправляет c через очередь.
+func (q *Queue) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var message tgbotapi.Message
+	err := q.do(c, func() error {
+		var err error
+		message, err = q.sender.Send(c)
+		return err
+	})
+	return message, err
+}
+
+// Request выполняет запрос c через очередь.
+func (q *Queue) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	var response *tgbotapi.APIResponse
+	err := q.do(c, func() error {
+		var err error
+		response, err = q.sender.Request(c)
+		return err
+	})
+	return response, err
+}
+
+// GetFileDirectURL не отправляет сообщений в чаты, поэтому идёт мимо очереди.
+func (q *Queue) GetFileDirectURL(fileID string) (string, error) {
+	return q.sender.GetFileDirectURL(fileID)
+}
+
+// do выполняет call с ожиданием слота и повторами.
+func (q *Queue) do(c tgbotapi.Chattable, call func() error) error {
+	method := methodOf(c)
+	chatID := chatIDOf(c)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = q.waitForSlot(chatID); err != nil {
+			break
+		}
+
+		err = call()
+		if err == nil {
+			metrics.OutboundRequests.WithLabelValues(method, "sent").Inc()
+			return nil
+		}
+
+		retryAfter, retryable := retryDelay(err, attempt)
+		if !retryable {
+			break
+		}
+		if attempt >= q.options.MaxRetries {
+			metrics.OutboundDeadLetters.WithLabelValues(method).Inc()
+			slog.Error("Запрос к Telegram не отправлен после повторов (dead letter)",
+				"method", method, "chat_id", chatID, "attempts", attempt+1, "err", err)
+			break
+		}
+
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == 429 {
+			metrics.OutboundRetries.WithLabelValues("rate_limited").Inc()
+			// Telegram просит подождать: приостанавливаем всю очередь, а не только этот запрос.
+			q.pause(retryAfter)
+		} else {
+			metrics.OutboundRetries.WithLabelValues("transient").Inc()
+			if err := sleep(q.ctx, retryAfter); err != nil {
+				break
+			}
+		}
+		slog.Warn("Повторяем запрос к Telegram",
+			"method", method, "chat_id", chatID, "attempt", attempt+1, "retry_after", retryAfter, "err", err)
+	}
+
+	metrics.OutboundRequests.WithLabelValues(method, "failed").Inc()
+	return err
+}
+
+// waitForSlot ждёт, пока можно будет отправить запрос в чат chatID, и занимает слот.
+// Слоты раздаются по порядку обращения, поэтому запросы в один чат не обгоняют друг друга.
+// Возвращает ошибку, если контекст очереди отменили до наступления слота.
+func (q *Queue) waitForSlot(chatID int64) error {
+	metrics.OutboundQueueLength.Inc()
+	defer metrics.OutboundQueueLength.Dec()
+
+	q.mu.Lock()
+	now := time.Now()
+	slot := now
+	if q.nextGlobal.After(slot) {
+		slot = q.nextGlobal
+	}
+	if next := q.nextForChat[chatID]; chatID != 0 && next.After(slot) {
+		slot = next
+	}
+
+	if q.options.MessagesPerSecond > 0 {
+		q.nextGlobal = slot.Add(time.Second / time.Duration(q.options.MessagesPerSecond))
+	}
+	if chatID != 0 {
+		if len(q.nextForChat) >= maxChatSlots {
+			for id, next := range q.nextForChat {
+				if next.Before(now) {
+					delete(q.nextForChat, id)
+				}
+			}
+		}
+		q.nextForChat[chatID] = slot.Add(q.options.ChatInterval)
+	}
+	q.mu.Unlock()
+
+	return sleep(q.ctx, time.Until(slot))
+}
+
+// sleep ждёт duration или отмены ctx; во втором случае возвращает ошибку контекста.
+func sleep(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause откладывает все следующие запросы на duration.
+func (q *Queue) pause(duration time.Duration) {
+	q.mu.Lock()
+	if until := time.Now().Add(duration); until.After(q.nextGlobal) {
+		q.nextGlobal = until
+	}
+	q.mu.Unlock()
+}
+
+// retryDelay решает, стоит ли повторять запрос после ошибки err, и через сколько.
+// Повторяются ответы 429 (через retry_after), ошибки сервера Telegram и сетевые ошибки.
+// Остальные ошибки (400, 403 и т.п.) постоянные: повтор вернёт то же самое.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	backoff := retryBackoff << attempt
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == 429:
+			if apiErr.RetryAfter > 0 {
+				return time.Duration(apiErr.RetryAfter) * time.Second, true
+			}
+			return backoff, true
+		case apiErr.Code >= 500:
+			return backoff, true
+		default:
+			return 0, false
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// methodOf возвращает имя типа запроса для логов и метрик ("MessageConfig", "PhotoConfig", …).
+func methodOf(c tgbotapi.Chattable) string {
+	name := fmt.Sprintf("%T", c)
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,205 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestSleep(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		duration time.Duration
+		wantErr  error
+	}{
+		{name: "zero duration", ctx: cancelled, duration: 0},
+		{name: "negative duration", ctx: cancelled, duration: -time.Second},
+		{name: "elapsed", ctx: context.Background(), duration: time.Millisecond},
+		{name: "cancelled", ctx: cancelled, duration: time.Hour, wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sleep(tt.ctx, tt.duration); !errors.Is(err, tt.wantErr) {
+				t.Errorf("sleep() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestQueueCancelled проверяет, что после отмены контекста очереди запрос,
+// которому пришлось бы ждать слота, сразу завершается ошибкой и не отправляется.
+func TestQueueCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := NewFakeSender()
+	queue := NewQueue(ctx, sender, QueueOptions{ChatInterval: time.Hour})
+
+	if _, err := queue.Send(tgbotapi.NewMessage(1, "первое")); err != nil {
+		t.Fatalf("first Send: %v", err)
+	}
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := queue.Send(tgbotapi.NewMessage(1, "второе"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("second Send = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Send is still waiting for its slot after cancel")
+	}
+	if got := len(sender.Sent()); got != 1 {
+		t.Errorf("sent %d messages, want 1", got)
+	}
+}
+
+// scriptedSender возвращает ошибки из errs по очереди, затем отвечает успехом.
+type scriptedSender struct {
+	*FakeSender
+	errs  []error
+	calls int
+}
+
+func (s *scriptedSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	s.calls++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+	return s.FakeSender.Send(c)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		attempt       int
+		wantDelay     time.Duration
+		wantRetryable bool
+	}{
+		{
+			name:          "rate limited with retry_after",
+			err:           &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 7}},
+			wantDelay:     7 * time.Second,
+			wantRetryable: true,
+		},
+		{
+			name:          "rate limited without retry_after",
+			err:           &tgbotapi.Error{Code: 429},
+			attempt:       1,
+			wantDelay:     2 * retryBackoff,
+			wantRetryable: true,
+		},
+		{
+			name:          "server error backs off exponentially",
+			err:           &tgbotapi.Error{Code: 502},
+			attempt:       2,
+			wantDelay:     4 * retryBackoff,
+			wantRetryable: true,
+		},
+		{
+			name:          "network error",
+			err:           fmt.Errorf("post: %w", timeoutError{}),
+			wantDelay:     retryBackoff,
+			wantRetryable: true,
+		},
+		{name: "forbidden", err: &tgbotapi.Error{Code: 403}},
+		{name: "bad request", err: &tgbotapi.Error{Code: 400}},
+		{name: "other error", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retryable := retryDelay(tt.err, tt.attempt)
+			if delay != tt.wantDelay || retryable != tt.wantRetryable {
+				t.Errorf("retryDelay() = (%v, %v), want (%v, %v)", delay, retryable, tt.wantDelay, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestQueueRetries(t *testing.T) {
+	serverError := &tgbotapi.Error{Code: 500, Message: "Internal Server Error"}
+	forbidden := &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+
+	tests := []struct {
+		name       string
+		errs       []error
+		maxRetries int
+		wantErr    error
+		wantCalls  int
+	}{
+		{name: "sent first time", wantCalls: 1},
+		{name: "transient error retried", errs: []error{serverError}, maxRetries: 1, wantCalls: 2},
+		{name: "dead letter after retries", errs: []error{serverError}, maxRetries: 0, wantErr: serverError, wantCalls: 1},
+		{name: "permanent error not retried", errs: []error{forbidden}, maxRetries: 3, wantErr: forbidden, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &scriptedSender{FakeSender: NewFakeSender(), errs: tt.errs}
+			queue := NewQueue(context.Background(), sender, QueueOptions{MaxRetries: tt.maxRetries})
+
+			_, err := queue.Send(tgbotapi.NewMessage(1, "текст"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Send() = %v, want %v", err, tt.wantErr)
+			}
+			if sender.calls != tt.wantCalls {
+				t.Errorf("sender called %d times, want %d", sender.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestQueueChatInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	queue := NewQueue(context.Background(), NewFakeSender(), QueueOptions{ChatInterval: interval})
+
+	start := time.Now()
+	for _, text := range []string{"первое", "второе", "третье"} {
+		if _, err := queue.Send(tgbotapi.NewMessage(1, text)); err != nil {
+			t.Fatalf("Send(%q): %v", text, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*interval {
+		t.Errorf("three messages to one chat took %v, want at least %v", elapsed, 2*interval)
+	}
+}
+
+func TestMethodOf(t *testing.T) {
+	tests := []struct {
+		chattable tgbotapi.Chattable
+		want      string
+	}{
+		{chattable: tgbotapi.NewMessage(1, "текст"), want: "MessageConfig"},
+		{chattable: tgbotapi.NewEditMessageText(1, 2, "текст"), want: "EditMessageTextConfig"},
+		{chattable: tgbotapi.NewCallback("id", ""), want: "CallbackConfig"},
+	}
+
+	for _, tt := range tests {
+		if got := methodOf(tt.chattable); got != tt.want {
+			t.Errorf("methodOf(%T) = %q, want %q", tt.chattable, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"TimeCounterBot/common"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestReceiveUpdatesDrains проверяет, что все обновления, прочитанные до закрытия
+// канала, обрабатываются до конца, а Wait дожидается их обработчиков.
+func TestReceiveUpdatesDrains(t *testing.T) {
+	r, repo, sender := newTestRouter(t)
+
+	userIDs := []common.UserID{1, 2, 3}
+	updates := make(chan tgbotapi.Update, len(userIDs))
+	for i, userID := range userIDs {
+		updates <- tgbotapi.Update{
+			UpdateID: i + 1,
+			Message: &tgbotapi.Message{
+				MessageID: i + 1,
+				From:      &tgbotapi.User{ID: int64(userID), LanguageCode: "ru"},
+				Chat:      &tgbotapi.Chat{ID: int64(userID)},
+				Text:      "/start",
+				Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/start")}},
+			},
+		}
+	}
+	close(updates)
+
+	// ReceiveUpdates возвращается, только вычитав закрытый канал до конца.
+	r.ReceiveUpdates(context.Background(), updates)
+	r.Wait()
+
+	for _, userID := range userIDs {
+		if _, err := repo.GetUserByID(userID); err != nil {
+			t.Errorf("user %d is not registered after drain: %v", userID, err)
+		}
+	}
+	if got := len(sender.Sent()); got < len(userIDs) {
+		t.Errorf("sent %d messages, want at least %d", got, len(userIDs))
+	}
+}
@@ -3,93 +3,163 @@ package router
 import (
 	"context"
 	"database/sql"
-	"log"
+	"log/slog"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	"TimeCounterBot/common"
 	"TimeCounterBot/db"
+	"TimeCounterBot/i18n"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/metrics"
 	"TimeCounterBot/routes"
 	"TimeCounterBot/tg/bot"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-func SetCommands() {
-	commands := []tgbotapi.BotCommand{
-		{
-			Command:     "start",
-			Description: "Начать работу с ботом",
-		},
-		{
-			Command:     "register_new_activity",
-			Description: "Зарегистрировать новую активность",
-		},
-		{
-			Command:     "start_notify",
-			Description: "Начать присылать уведомления",
-		},
-		{
-			Command:     "stop_notify",
-			Description: "Закончить присылать уведомления",
-		},
-		{
-			Command:     "mute_activity",
-			Description: "Замьютить активность (чтобы не отображалась в регулярных опросах)",
-		},
-		{
-			Command:     "unmute_activity",
-			Description: "Размьютить активность (чтобы снова появилась в регулярных опросах)",
-		},
-		{
-			Command:     "analytics",
-			Description: "Аналитика и статистика активностей",
-		},
-		{
-			Command:     "export_activities",
-			Description: "Экспортировать дерево активностей в YAML файл",
-		},
-		{
-			Command:     "import_activities",
-			Description: "Импортировать активности из YAML файла",
-		},
-		{
-			Command:     "delete_activity",
-			Description: "Удалить активность и все её подактивности",
-		},
-	}
+// Router разбирает входящие обновления Telegram и передаёт их обработчикам.
+type Router struct {
+	handlers         *routes.Handlers
+	users            db.UserStore
+	sender           bot.Sender
+	callbackHandlers map[string]CallbackHandler
+	commandHandlers  map[string]CommandHandler
+
+	// inFlight — обновления, обработка которых ещё не закончилась.
+	inFlight sync.WaitGroup
+}
 
-	setCmd := tgbotapi.NewSetMyCommands(commands...)
-	_, err := bot.Bot.Request(setCmd)
-	if err != nil {
-		log.Printf("Ошибка установки команд: %v", err)
+// New создаёт Router поверх обработчиков, хранилища пользователей и отправителя.
+func New(handlers *routes.Handlers, users db.UserStore, sender bot.Sender) *Router {
+	r := &Router{
+		handlers: handlers,
+		users:    users,
+		sender:   sender,
 	}
+	r.callbackHandlers = r.buildCallbackHandlers()
+	r.commandHandlers = r.buildCommandHandlers()
+	return r
 }
 
-func ReceiveUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel) {
-	for {
-		select {
-		// stop looping if ctx is cancelled
-		case <-ctx.Done():
-			return
-		// receive update from channel and then handle it
-		case update := <-updates:
-			go handleUpdate(update)
+// botCommands — команды бота в порядке показа в меню Telegram. Описания берутся
+// из каталогов i18n по ключу "command.<команда>".
+var botCommands = []string{
+	"start",
+	"settings",
+	"register_new_activity",
+	"start_notify",
+	"stop_notify",
+	"mute_activity",
+	"unmute_activity",
+	"analytics",
+	"today",
+	"day",
+	"unanswered",
+	"adaptive",
+	"dnd",
+	"schedule",
+	"day_off",
+	"days_off",
+	"export_activities",
+	"import_activities",
+	"delete_activity",
+	"export_ics",
+	"ics_feed",
+	"import_rules",
+	"prompt",
+}
+
+// SetCommands регистрирует список команд бота в Telegram: по списку на каждый
+// поддерживаемый язык и список по умолчанию для остальных языков.
+func (r *Router) SetCommands() {
+	for _, lang := range i18n.Supported {
+		setCmd := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(
+			tgbotapi.NewBotCommandScopeDefault(), string(lang), localizedCommands(lang)...)
+		if _, err := r.sender.Request(setCmd); err != nil {
+			slog.Error("Ошибка установки команд", "lang", lang, "err", err)
 		}
 	}
+
+	setCmd := tgbotapi.NewSetMyCommands(localizedCommands(i18n.Default)...)
+	if _, err := r.sender.Request(setCmd); err != nil {
+		slog.Error("Ошибка установки команд", "err", err)
+	}
 }
 
-func handleUpdate(update tgbotapi.Update) {
+func localizedCommands(lang i18n.Lang) []tgbotapi.BotCommand {
+	commands := make([]tgbotapi.BotCommand, 0, len(botCommands))
+	for _, command := range botCommands {
+		commands = append(commands, tgbotapi.BotCommand{
+			Command:     command,
+			Description: i18n.T(lang, "command."+command),
+		})
+	}
+	return commands
+}
+
+// ReceiveUpdates читает обновления из канала, пока его не закроют (StopReceivingUpdates):
+// уже полученные от Telegram обновления не теряются при остановке. Каждое обновление
+// обрабатывается в своей горутине с контекстом ctx; дождаться их позволяет Wait.
+func (r *Router) ReceiveUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel) {
+	for update := range updates {
+		r.inFlight.Add(1)
+		go func() {
+			defer r.inFlight.Done()
+			r.handleUpdate(ctx, update)
+		}()
+	}
+}
+
+// Wait блокируется, пока не закончится обработка всех принятых обновлений.
+func (r *Router) Wait() {
+	r.inFlight.Wait()
+}
+
+// handleUpdate обрабатывает одно обновление. Все записи лога в рамках обновления
+// получают общий request_id и user_id отправителя.
+func (r *Router) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	if from := update.SentFrom(); from != nil {
+		ctx = logging.WithUserID(ctx, common.UserID(from.ID))
+	}
+	// Обработчики не возвращают ошибки, а пишут их в лог: по этим записям и считаем.
+	ctx, errorCount := logging.WithErrorCount(ctx)
+
+	updateType, route := r.updateRoute(update)
+	slog.DebugContext(ctx, "Получено обновление", "update_id", update.UpdateID, "type", updateType, "route", route)
+
+	start := time.Now()
+	defer func() {
+		// Паника в одном обработчике не должна останавливать весь бот.
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "Паника при обработке обновления",
+				"update_id", update.UpdateID, "type", updateType, "route", route,
+				"panic", recovered, "stack", string(debug.Stack()))
+		}
+		if errorCount() > 0 {
+			metrics.HandlerErrors.WithLabelValues(updateType, route).Inc()
+		}
+		metrics.UpdatesTotal.WithLabelValues(updateType, route).Inc()
+		metrics.HandlerDuration.WithLabelValues(updateType, route).Observe(time.Since(start).Seconds())
+	}()
+
 	switch {
 	// Handle messages
 	case update.Message != nil:
-		handleMessage(update.Message)
+		r.handleMessage(ctx, update.Message)
 
 	case update.CallbackQuery != nil:
-		handleCallbackQuery(update.CallbackQuery)
+		r.handleCallbackQuery(ctx, update.CallbackQuery)
+
+	case update.MyChatMember != nil:
+		r.handlers.MyChatMemberUpdated(ctx, update.MyChatMember)
 	}
 }
 
-func handleMessage(message *tgbotapi.Message) {
+func (r *Router) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 	user := message.From
 	if user == nil {
 		return
@@ -97,144 +167,264 @@ func handleMessage(message *tgbotapi.Message) {
 
 	userID := common.UserID(user.ID)
 
-	maybeAddNewUser(userID, common.ChatID(message.Chat.ID))
+	if message.MigrateToChatID != 0 {
+		r.handlers.MigrateChat(ctx, message)
+		return
+	}
+
+	if err := r.maybeAddNewUser(userID, common.ChatID(message.Chat.ID), user.LanguageCode); err != nil {
+		slog.ErrorContext(ctx, "Ошибка регистрации пользователя", "err", err)
+		return
+	}
 
-	// Print to console
-	log.Printf("%s wrote %s", user.UserName, message.Text)
+	slog.InfoContext(ctx, "Получено сообщение", "text", logging.Text(message.Text))
 
 	if strings.HasPrefix(message.Text, "/") {
-		handleCommand(message)
+		r.handleCommand(ctx, message)
 	} else if message.Document != nil {
-		// Обрабатываем загруженный документ (возможно, для импорта активностей)
-		routes.ProcessImportFile(message)
+		// Обрабатываем загруженный документ (импорт активностей или календаря)
+		r.handlers.ProcessImportFile(ctx, message)
 	} else if len(message.Text) > 0 {
 		// chech user state and send info to waiting channel
-		if common.UserStates[userID].WaitingChannel != nil {
-			*common.UserStates[userID].WaitingChannel <- message.Text
+		if !common.DeliverReply(userID, message.Text) {
+			slog.DebugContext(ctx, "Ответа от пользователя никто не ждёт")
 		}
 	}
 }
 
-type CallbackHandler func(*tgbotapi.CallbackQuery)
+type CallbackHandler func(context.Context, *tgbotapi.CallbackQuery)
+
+// buildCallbackHandlers сопоставляет префиксы callback data с обработчиками.
+func (r *Router) buildCallbackHandlers() map[string]CallbackHandler {
+	h := r.handlers
+	return map[string]CallbackHandler{
+		"activity_log":          h.LogUserActivityCallback,
+		"activity_log__change":  h.ChangeActivityLogCallback,
+		"activity_log__remove":  h.RemoveActivityLogCallback,
+		"register_new_activity": h.AddNewActivityCallback,
+		"refresh_activities":    h.RefreshActivitiesCallback,
+
+		"day_stats__send_chart":    h.SendDayStatsRoutineCallback,
+		"day_stats__refresh_chart": h.RefreshDayStatsChartCallback,
+
+		"start__set_timer_minutes":            h.SetTimerMinutesCallback,
+		"start__schedule_morning_start_hour":  h.SetScheduleMorningStartHourCallback,
+		"start__schedule_evening_finish_hour": h.SetScheduleEveningFinishHourCallback,
+		"start__enable_notifications": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.EnableNotificationsCallback(ctx, c, true)
+		},
+		"start__disable_notifications": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.EnableNotificationsCallback(ctx, c, false)
+		},
 
-var callbackHandlers = map[string]CallbackHandler{
-	"activity_log":          routes.LogUserActivityCallback,
-	"register_new_activity": routes.AddNewActivityCallback,
-	"refresh_activities":    routes.RefreshActivitiesCallback,
+		"mute_activity__mute": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.MuteActivityCallback(ctx, c, true)
+		},
+		"mute_activity__cancel": h.MuteActivityCancelCallback,
+		"mute_activity__refresh": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.MuteActivityRefreshCallback(ctx, c, true)
+		},
 
-	"day_stats__send_chart":    routes.SendDayStatsRoutineCallback,
-	"day_stats__refresh_chart": routes.RefreshDayStatsChartCallback,
+		"unmute_activity__unmute": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.MuteActivityCallback(ctx, c, false)
+		},
+		"unmute_activity__cancel": h.MuteActivityCancelCallback,
+		"unmute_activity__refresh": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.MuteActivityRefreshCallback(ctx, c, false)
+		},
 
-	"start__set_timer_minutes":            routes.SetTimerMinutesCallback,
-	"start__schedule_morning_start_hour":  routes.SetScheduleMorningStartHourCallback,
-	"start__schedule_evening_finish_hour": routes.SetScheduleEveningFinishHourCallback,
-	"start__enable_notifications": func(c *tgbotapi.CallbackQuery) {
-		routes.EnableNotificationsCallback(c, true)
-	},
-	"start__disable_notifications": func(c *tgbotapi.CallbackQuery) {
-		routes.EnableNotificationsCallback(c, false)
-	},
+		"delete_activity__delete":  h.DeleteActivityCallback,
+		"delete_activity__cancel":  h.DeleteActivityCancelCallback,
+		"delete_activity__refresh": h.DeleteActivityRefreshCallback,
 
-	"mute_activity__mute":    func(c *tgbotapi.CallbackQuery) { routes.MuteActivityCallback(c, true) },
-	"mute_activity__cancel":  routes.MuteActivityCancelCallback,
-	"mute_activity__refresh": func(c *tgbotapi.CallbackQuery) { routes.MuteActivityRefreshCallback(c, true) },
+		"analytics__day_stats":       h.AnalyticsGetDayStatsCallback,
+		"analytics__compare_periods": h.AnalyticsComperiodsCallback,
+		"analytics__back":            h.AnalyticsBackCallback,
+		"analytics__patterns":        h.AnalyticsPatternsCallback,
 
-	"unmute_activity__unmute":  func(c *tgbotapi.CallbackQuery) { routes.MuteActivityCallback(c, false) },
-	"unmute_activity__cancel":  routes.MuteActivityCancelCallback,
-	"unmute_activity__refresh": func(c *tgbotapi.CallbackQuery) { routes.MuteActivityRefreshCallback(c, false) },
+		"patterns__heatmap":  h.PatternsHeatmapCallback,
+		"patterns__calendar": h.PatternsCalendarCallback,
+		"patterns__hours":    h.PatternsHoursCallback,
 
-	"delete_activity__delete":  routes.DeleteActivityCallback,
-	"delete_activity__cancel":  routes.DeleteActivityCancelCallback,
-	"delete_activity__refresh": routes.DeleteActivityRefreshCallback,
+		"analytics__trends": h.AnalyticsTrendsCallback,
+		"trends__weeks":     h.TrendsCallback,
+		"trends__months":    h.TrendsCallback,
 
-	"analytics__day_stats":       routes.AnalyticsGetDayStatsCallback,
-	"analytics__compare_periods": routes.AnalyticsComperiodsCallback,
-	"analytics__back":            routes.AnalyticsBackCallback,
+		"compare_periods__this_vs_last_week":  h.ComparePeriods_ThisVsLastWeekCallback,
+		"compare_periods__this_vs_last_month": h.ComparePeriods_ThisVsLastMonthCallback,
+		"compare_periods__custom":             h.ComparePeriods_CustomCallback,
+		"compare_periods__back":               h.ComparePeriods_BackCallback,
 
-	"compare_periods__this_vs_last_week":  routes.ComparePeriods_ThisVsLastWeekCallback,
-	"compare_periods__this_vs_last_month": routes.ComparePeriods_ThisVsLastMonthCallback,
-	"compare_periods__custom":             routes.ComparePeriods_CustomCallback,
-	"compare_periods__back":               routes.ComparePeriods_BackCallback,
+		"day_stats__today": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.DayStatsCallback(ctx, c, "today")
+		},
+		"day_stats__yesterday": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.DayStatsCallback(ctx, c, "yesterday")
+		},
+		"day_stats__this_week": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.DayStatsCallback(ctx, c, "this_week")
+		},
+		"day_stats__last_week": func(ctx context.Context, c *tgbotapi.CallbackQuery) {
+			h.DayStatsCallback(ctx, c, "last_week")
+		},
 
-	"day_stats__today":     func(c *tgbotapi.CallbackQuery) { routes.DayStatsCallback(c, "today") },
-	"day_stats__yesterday": func(c *tgbotapi.CallbackQuery) { routes.DayStatsCallback(c, "yesterday") },
-	"day_stats__this_week": func(c *tgbotapi.CallbackQuery) { routes.DayStatsCallback(c, "this_week") },
-	"day_stats__last_week": func(c *tgbotapi.CallbackQuery) { routes.DayStatsCallback(c, "last_week") },
+		"timeline__edit":   h.TimelineEditCallback,
+		"timeline__change": h.TimelineChangeCallback,
+		"timeline__remove": h.TimelineRemoveCallback,
+		"timeline__cancel": h.TimelineCancelCallback,
+		"slot_log":         h.SlotLogCallback,
+
+		"split__start":  h.SplitStartCallback,
+		"split__pick":   h.SplitPickCallback,
+		"split__equal":  h.SplitEqualCallback,
+		"split__custom": h.SplitCustomCallback,
+		"split__cancel": h.SplitCancelCallback,
+
+		"unanswered__set":      h.UnansweredSetCallback,
+		"unanswered__fallback": h.UnansweredFallbackCallback,
+		"unanswered__back":     h.UnansweredBackCallback,
+
+		"missed__fill": h.MissedFillCallback,
+
+		"adaptive__toggle":   h.AdaptiveToggleCallback,
+		"adaptive__edit":     h.AdaptiveBoundCallback,
+		"schedule__edit":     h.ScheduleEditCallback,
+		"dayoff__delete":     h.DayOffDeleteCallback,
+		"settings__edit":     h.SettingsEditCallback,
+		"settings__toggle":   h.SettingsToggleCallback,
+		"settings__language": h.SettingsLanguageCallback,
+		"snooze__for":        h.SnoozeCallback,
+		"snooze__tomorrow":   h.SnoozeCallback,
+		"dnd__for":           h.DndCallback,
+		"dnd__tomorrow":      h.DndCallback,
+		"dnd__off":           h.DndCallback,
+		"prompt__tone":       h.PromptToneCallback,
+		"prompt__since":      h.PromptSinceCallback,
+	}
 }
 
-func handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
+func (r *Router) handleCallbackQuery(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	dataPath := strings.Split(callback.Data, " ")[0]
-	if handler, ok := callbackHandlers[dataPath]; ok {
-		handler(callback)
+	if handler, ok := r.callbackHandlers[dataPath]; ok {
+		handler(ctx, callback)
 	} else {
-		log.Printf("Unknown callback: %q", dataPath)
+		slog.WarnContext(ctx, "Неизвестный callback", "callback", dataPath)
 	}
 }
 
 // When we get a command, we react accordingly.
-func handleCommand(message *tgbotapi.Message) {
-	switch strings.Split(message.Text, " ")[0] {
-	case "/start":
-		routes.StartCommand(message)
-
-	case "/start_notify":
-		routes.NotifyCommand(message, true)
-
-	case "/stop_notify":
-		routes.NotifyCommand(message, false)
-
-	case "/test_notify":
-		routes.TestNotifyCommand(message)
-
-	case "/register_new_activity":
-		routes.RegisterNewActivityCommand(message)
-
-	case "/analytics":
-		routes.AnalyticsMenuCommand(message)
-
-	case "/get_day_statistics":
-		routes.GetDayStatisticsCommand(message)
+func (r *Router) handleCommand(ctx context.Context, message *tgbotapi.Message) {
+	if handler, ok := r.commandHandlers[commandName(message.Text)]; ok {
+		handler(ctx, message)
+	}
+}
 
-	case "/test_day_stats_routine":
-		routes.TestDayStatsRoutine(message)
+type CommandHandler func(context.Context, *tgbotapi.Message)
+
+// buildCommandHandlers сопоставляет команды с обработчиками.
+func (r *Router) buildCommandHandlers() map[string]CommandHandler {
+	h := r.handlers
+	return map[string]CommandHandler{
+		"/start":        h.StartCommand,
+		"/start_notify": func(ctx context.Context, m *tgbotapi.Message) { h.NotifyCommand(ctx, m, true) },
+		"/stop_notify":  func(ctx context.Context, m *tgbotapi.Message) { h.NotifyCommand(ctx, m, false) },
+		"/test_notify":  h.TestNotifyCommand,
+		"/settings":     h.SettingsCommand,
+
+		"/register_new_activity": h.RegisterNewActivityCommand,
+		"/mute_activity":         func(ctx context.Context, m *tgbotapi.Message) { h.MuteActivityCommand(ctx, m, true) },
+		"/unmute_activity":       func(ctx context.Context, m *tgbotapi.Message) { h.MuteActivityCommand(ctx, m, false) },
+		"/export_activities":     h.ExportActivitiesCommand,
+		"/import_activities":     h.ImportActivitiesCommand,
+		"/delete_activity":       h.DeleteActivityCommand,
+
+		"/analytics":              h.AnalyticsMenuCommand,
+		"/get_day_statistics":     h.GetDayStatisticsCommand,
+		"/test_day_stats_routine": h.TestDayStatsRoutine,
+		"/today":                  h.TodayCommand,
+		"/day":                    h.DayCommand,
+		"/unanswered":             h.UnansweredCommand,
+		"/adaptive":               h.AdaptiveCommand,
+		"/schedule":               h.ScheduleCommand,
+		"/dnd":                    h.DndCommand,
+		"/day_off":                h.DayOffCommand,
+		"/days_off":               h.DaysOffCommand,
+
+		"/export_ics":   h.ExportICSCommand,
+		"/ics_feed":     h.CalendarFeedCommand,
+		"/import_rules": h.ImportRulesCommand,
+		"/prompt":       h.PromptCommand,
+	}
+}
 
-	case "/mute_activity":
-		routes.MuteActivityCommand(message, true)
+// commandName возвращает команду из текста сообщения ("/start arg" → "/start").
+func commandName(text string) string {
+	return strings.Split(text, " ")[0]
+}
 
-	case "/unmute_activity":
-		routes.MuteActivityCommand(message, false)
+// updateRoute возвращает тип обновления и маршрут (команду или префикс callback-а)
+// для метрик. Неизвестные команды и callback-и схлопываются в "unknown",
+// чтобы произвольный ввод пользователей не раздувал число рядов метрик.
+func (r *Router) updateRoute(update tgbotapi.Update) (string, string) {
+	switch {
+	case update.Message != nil:
+		message := update.Message
+		switch {
+		case strings.HasPrefix(message.Text, "/"):
+			if _, ok := r.commandHandlers[commandName(message.Text)]; ok {
+				return "message", commandName(message.Text)
+			}
+			return "message", "unknown"
+		case message.Document != nil:
+			return "message", "document"
+		default:
+			return "message", "text"
+		}
 
-	case "/export_activities":
-		routes.ExportActivitiesCommand(message)
+	case update.CallbackQuery != nil:
+		dataPath := strings.Split(update.CallbackQuery.Data, " ")[0]
+		if _, ok := r.callbackHandlers[dataPath]; ok {
+			return "callback_query", dataPath
+		}
+		return "callback_query", "unknown"
 
-	case "/import_activities":
-		routes.ImportActivitiesCommand(message)
+	case update.MyChatMember != nil:
+		return "my_chat_member", update.MyChatMember.NewChatMember.Status
 
-	case "/delete_activity":
-		routes.DeleteActivityCommand(message)
+	default:
+		return "other", ""
 	}
 }
 
-func maybeAddNewUser(userID common.UserID, chatID common.ChatID) {
-	_, err := db.GetUserByID(userID)
-	if err != nil && !strings.Contains(err.Error(), "record not found") {
-		log.Fatal(err)
+// maybeAddNewUser регистрирует пользователя при первом сообщении. Язык интерфейса
+// определяется по языку Telegram, пока пользователь не выбрал его сам.
+func (r *Router) maybeAddNewUser(userID common.UserID, chatID common.ChatID, languageCode string) error {
+	user, err := r.users.GetUserByID(userID)
+	if err == nil {
+		if user.Language != "" || languageCode == "" {
+			return nil
+		}
+		// Пишем только язык: обработчики этого пользователя могут менять его строку одновременно.
+		return r.users.UpdateUserColumns(userID, map[string]any{"language": string(i18n.Detect(languageCode))})
+	}
+	if !strings.Contains(err.Error(), "record not found") {
+		return err
 	}
 
-	if err != nil && strings.Contains(err.Error(), "record not found") {
-		err = db.AddUser(
-			db.User{
-				ID:                        userID,
-				ChatID:                    chatID,
-				TimerEnabled:              false,
-				TimerMinutes:              sql.NullInt64{},
-				ScheduleMorningStartHour:  sql.NullInt64{},
-				ScheduleEveningFinishHour: sql.NullInt64{},
-				LastNotify:                sql.NullTime{},
-			},
-		)
-		if err != nil {
-			log.Fatal(err)
-		}
+	language := ""
+	if languageCode != "" {
+		language = string(i18n.Detect(languageCode))
 	}
+	return r.users.AddUser(
+		db.User{
+			ID:                        userID,
+			ChatID:                    chatID,
+			TimerEnabled:              false,
+			TimerMinutes:              sql.NullInt64{},
+			ScheduleMorningStartHour:  sql.NullInt64{},
+			ScheduleEveningFinishHour: sql.NullInt64{},
+			LastNotify:                sql.NullTime{},
+			Language:                  language,
+		},
+	)
 }
@@ -0,0 +1,145 @@
+package router
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/logging"
+	"TimeCounterBot/metrics"
+	"TimeCounterBot/routes"
+	"TimeCounterBot/tg/bot"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestRouter создаёт роутер поверх хранилища в памяти и FakeSender.
+func newTestRouter(t *testing.T) (*Router, db.Repository, *bot.FakeSender) {
+	t.Helper()
+
+	repo, err := db.NewMemoryRepository()
+	if err != nil {
+		t.Fatalf("NewMemoryRepository: %v", err)
+	}
+	sender := bot.NewFakeSender()
+	settings := routes.Settings{DayStatsWaitDuration: time.Minute, Location: time.UTC}
+	return New(routes.NewHandlers(repo, repo, repo, repo, sender, settings), repo, sender), repo, sender
+}
+
+func TestUpdateRoute(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+
+	tests := []struct {
+		name      string
+		update    tgbotapi.Update
+		wantType  string
+		wantRoute string
+	}{
+		{
+			name:      "known command with argument",
+			update:    tgbotapi.Update{Message: &tgbotapi.Message{Text: "/start abc"}},
+			wantType:  "message",
+			wantRoute: "/start",
+		},
+		{
+			name:      "unknown command",
+			update:    tgbotapi.Update{Message: &tgbotapi.Message{Text: "/whatever"}},
+			wantType:  "message",
+			wantRoute: "unknown",
+		},
+		{
+			name:      "document",
+			update:    tgbotapi.Update{Message: &tgbotapi.Message{Document: &tgbotapi.Document{FileID: "file"}}},
+			wantType:  "message",
+			wantRoute: "document",
+		},
+		{
+			name:      "plain text",
+			update:    tgbotapi.Update{Message: &tgbotapi.Message{Text: "привет"}},
+			wantType:  "message",
+			wantRoute: "text",
+		},
+		{
+			name:      "known callback",
+			update:    tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "analytics__back 1"}},
+			wantType:  "callback_query",
+			wantRoute: "analytics__back",
+		},
+		{
+			name:      "unknown callback",
+			update:    tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{Data: "nope__nope"}},
+			wantType:  "callback_query",
+			wantRoute: "unknown",
+		},
+		{
+			name: "chat member",
+			update: tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+				NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+			}},
+			wantType:  "my_chat_member",
+			wantRoute: "kicked",
+		},
+		{
+			name:     "other",
+			update:   tgbotapi.Update{},
+			wantType: "other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotRoute := r.updateRoute(tt.update)
+			if gotType != tt.wantType || gotRoute != tt.wantRoute {
+				t.Errorf("updateRoute() = (%q, %q), want (%q, %q)", gotType, gotRoute, tt.wantType, tt.wantRoute)
+			}
+		})
+	}
+}
+
+// handlerErrors возвращает текущее значение счётчика handler_errors_total для маршрута.
+func handlerErrors(t *testing.T, updateType, route string) float64 {
+	t.Helper()
+
+	var metric dto.Metric
+	if err := metrics.HandlerErrors.WithLabelValues(updateType, route).Write(&metric); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestHandlerErrorsMetric(t *testing.T) {
+	logger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(logger) })
+	if err := logging.Setup(io.Discard, "info", logging.FormatText, true); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	r, _, _ := newTestRouter(t)
+
+	tests := []struct {
+		name  string
+		data  string
+		route string
+		want  float64
+	}{
+		{name: "handler logs an error", data: "day_stats__send_chart 1 2", route: "day_stats__send_chart", want: 1},
+		{name: "only a warning", data: "nope__nope", route: "unknown", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := handlerErrors(t, "callback_query", tt.route)
+			r.handleUpdate(context.Background(), tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+				ID:   "1",
+				From: &tgbotapi.User{ID: 1},
+				Data: tt.data,
+			}})
+			if got := handlerErrors(t, "callback_query", tt.route) - before; got != tt.want {
+				t.Errorf("handler_errors_total grew by %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package router
+
+import (
+	"testing"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/db"
+)
+
+func TestMaybeAddNewUser(t *testing.T) {
+	const userID common.UserID = 21
+
+	tests := []struct {
+		name string
+		// registered — пользователь уже есть в базе с языком language.
+		registered   bool
+		language     string
+		languageCode string
+		wantLanguage string
+	}{
+		{name: "new user", languageCode: "en-US", wantLanguage: "en"},
+		{name: "new user without language", wantLanguage: ""},
+		{name: "language detected later", registered: true, languageCode: "uk", wantLanguage: "ru"},
+		{name: "chosen language is kept", registered: true, language: "en", languageCode: "ru", wantLanguage: "en"},
+		{name: "unknown language stays unset", registered: true, wantLanguage: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, repo, _ := newTestRouter(t)
+			if tt.registered {
+				err := repo.AddUser(db.User{ID: userID, ChatID: 21, TimerEnabled: true, Language: tt.language})
+				if err != nil {
+					t.Fatalf("AddUser: %v", err)
+				}
+			}
+
+			if err := r.maybeAddNewUser(userID, 21, tt.languageCode); err != nil {
+				t.Fatalf("maybeAddNewUser: %v", err)
+			}
+
+			user, err := repo.GetUserByID(userID)
+			if err != nil {
+				t.Fatalf("GetUserByID: %v", err)
+			}
+			if user.Language != tt.wantLanguage {
+				t.Errorf("language = %q, want %q", user.Language, tt.wantLanguage)
+			}
+			// Остальные настройки зарегистрированного пользователя не меняются.
+			if tt.registered && !user.TimerEnabled {
+				t.Error("timer_enabled was reset")
+			}
+		})
+	}
+}
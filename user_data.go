@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"TimeCounterBot/common"
+	"TimeCounterBot/config"
+	"TimeCounterBot/db"
+)
+
+// runExportUserCommand выполняет подкоманду `export-user USER_ID [FILE]`.
+func runExportUserCommand(cfg config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: bot export-user USER_ID [FILE]")
+	}
+	userID, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+
+	repo, err := db.InitDB(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	data, err := repo.ExportUserData(userID)
+	if err != nil {
+		return fmt.Errorf("export user %d: %w", userID, err)
+	}
+
+	if len(args) == 1 {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(args[1], data, 0o600)
+}
+
+// runImportUserCommand выполняет подкоманду `import-user FILE`.
+func runImportUserCommand(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: bot import-user FILE")
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	repo, err := db.InitDB(cfg.Database.Driver, cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	userID, err := repo.ImportUserData(data)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", args[0], err)
+	}
+	fmt.Printf("imported user %d\n", userID)
+	return nil
+}
+
+// parseUserID разбирает идентификатор пользователя Telegram из аргумента командной строки.
+func parseUserID(arg string) (common.UserID, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid user id %q", arg)
+	}
+	return common.UserID(id), nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"TimeCounterBot/common"
+)
+
+func TestParseUserID(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    common.UserID
+		wantErr bool
+	}{
+		{arg: "123456789", want: 123456789},
+		{arg: "0", wantErr: true},
+		{arg: "-5", wantErr: true},
+		{arg: "abc", wantErr: true},
+		{arg: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := parseUserID(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUserID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseUserID() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/ics"
+
+	"gorm.io/gorm"
+)
+
+// calendarFeedDays — за сколько последних дней отдаются события в ICS-ленте.
+const calendarFeedDays = 90
+
+// calendarFeedHandler отдаёт приватную ICS-ленту пользователя по адресу /ics/<token>.ics.
+func calendarFeedHandler(users db.UserStore, logs db.ActivityLogStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/ics/"), ".ics")
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		user, err := users.GetUserByCalendarToken(token)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Ошибка получения пользователя по токену календаря", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		end := time.Now()
+		start := end.AddDate(0, 0, -calendarFeedDays)
+		events, err := logs.GetCalendarEvents(user.ID, start, end)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Ошибка построения событий календаря", "user_id", user.ID, "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		_, err = w.Write(ics.Encode("Time Counter", db.CalendarEventsToICS(user.ID, events)))
+		if err != nil {
+			slog.WarnContext(r.Context(), "Ошибка отправки ICS-ленты", "err", err)
+		}
+	}
+}
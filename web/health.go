@@ -0,0 +1,70 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout — сколько ждём ответа одной проверки.
+const healthCheckTimeout = 3 * time.Second
+
+// Check — проверка одной зависимости бота (база, Telegram).
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// HealthChecks — проверки для /healthz (процесс жив и может работать с базой)
+// и /readyz (бот готов обрабатывать обновления).
+type HealthChecks struct {
+	Liveness  []Check
+	Readiness []Check
+}
+
+// healthResponse — тело ответа /healthz и /readyz.
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// healthHandler выполняет проверки и отвечает 200, если все прошли, иначе 503.
+func healthHandler(checks []Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := healthResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+		for _, check := range checks {
+			if err := runCheck(r.Context(), check); err != nil {
+				response.Status = "fail"
+				response.Checks[check.Name] = err.Error()
+			} else {
+				response.Checks[check.Name] = "ok"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if response.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.WarnContext(r.Context(), "Ошибка отправки ответа проверки здоровья", "err", err)
+		}
+	}
+}
+
+// runCheck выполняет проверку с таймаутом, даже если она сама не учитывает контекст.
+func runCheck(ctx context.Context, check Check) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- check.Func(ctx) }()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	ok := Check{Name: "database", Func: func(context.Context) error { return nil }}
+	failing := Check{Name: "telegram", Func: func(context.Context) error { return errors.New("unauthorized") }}
+	// Проверка, которая ждёт контекст: отменённый запрос не должен её ждать.
+	blocking := Check{Name: "slow", Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		cancel     bool
+		wantCode   int
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "no checks",
+			wantCode:   http.StatusOK,
+			wantStatus: "ok",
+			wantChecks: map[string]string{},
+		},
+		{
+			name:       "all pass",
+			checks:     []Check{ok},
+			wantCode:   http.StatusOK,
+			wantStatus: "ok",
+			wantChecks: map[string]string{"database": "ok"},
+		},
+		{
+			name:       "one fails",
+			checks:     []Check{ok, failing},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "fail",
+			wantChecks: map[string]string{"database": "ok", "telegram": "unauthorized"},
+		},
+		{
+			name:       "request cancelled",
+			checks:     []Check{blocking},
+			cancel:     true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: "fail",
+			wantChecks: map[string]string{"slow": context.Canceled.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			request := httptest.NewRequest(http.MethodGet, "/healthz", nil).WithContext(ctx)
+			recorder := httptest.NewRecorder()
+
+			healthHandler(tt.checks)(recorder, request)
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", recorder.Code, tt.wantCode)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var response healthResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if response.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", response.Status, tt.wantStatus)
+			}
+			if len(response.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks = %v, want %v", response.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := response.Checks[name]; got != want {
+					t.Errorf("check %q = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
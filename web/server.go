@@ -0,0 +1,66 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"TimeCounterBot/db"
+	"TimeCounterBot/logging"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// shutdownTimeout — время на завершение активных HTTP-запросов при остановке.
+const shutdownTimeout = 5 * time.Second
+
+// PublicHandler отдаёт то, что можно открыть в интернет по http.public_url: ICS-ленты.
+func PublicHandler(users db.UserStore, logs db.ActivityLogStore) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/ics/", calendarFeedHandler(users, logs))
+	return mux
+}
+
+// InternalHandler отдаёт служебные адреса для инфраструктуры: метрики Prometheus
+// (/metrics) и проверки здоровья (/healthz, /readyz). Он слушает отдельный адрес,
+// чтобы метрики не оказались доступны из интернета вместе с ICS-лентами.
+func InternalHandler(health HealthChecks) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/healthz", healthHandler(health.Liveness))
+	mux.Handle("/readyz", healthHandler(health.Readiness))
+	return mux
+}
+
+// ListenAndServe запускает HTTP-сервер с обработчиком handler на адресе addr
+// и останавливает его при отмене контекста.
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Запросы получают поля логирования сервера, но не его отмену: при остановке
+	// активные запросы дорабатывают в пределах shutdownTimeout.
+	ctx = logging.WithComponent(ctx, "http")
+	baseCtx := context.WithoutCancel(ctx)
+	server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.ErrorContext(ctx, "Ошибка остановки HTTP-сервера", "addr", addr, "err", err)
+		}
+	}()
+
+	slog.InfoContext(ctx, "HTTP-сервер запущен", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.ErrorContext(ctx, "Ошибка HTTP-сервера", "addr", addr, "err", err)
+	}
+}
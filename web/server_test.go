@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"TimeCounterBot/db"
+)
+
+// TestHandlerRoutes проверяет, что служебные адреса не отдаются на публичном сервере.
+func TestHandlerRoutes(t *testing.T) {
+	repo, err := db.NewMemoryRepository()
+	if err != nil {
+		t.Fatalf("NewMemoryRepository: %v", err)
+	}
+	public := PublicHandler(repo, repo)
+	internal := InternalHandler(HealthChecks{})
+
+	tests := []struct {
+		name     string
+		handler  http.Handler
+		path     string
+		wantCode int
+	}{
+		{name: "feed on public", handler: public, path: "/ics/unknown-token.ics", wantCode: http.StatusNotFound},
+		{name: "metrics on public", handler: public, path: "/metrics", wantCode: http.StatusNotFound},
+		{name: "healthz on public", handler: public, path: "/healthz", wantCode: http.StatusNotFound},
+		{name: "readyz on public", handler: public, path: "/readyz", wantCode: http.StatusNotFound},
+		{name: "metrics on internal", handler: internal, path: "/metrics", wantCode: http.StatusOK},
+		{name: "healthz on internal", handler: internal, path: "/healthz", wantCode: http.StatusOK},
+		{name: "readyz on internal", handler: internal, path: "/readyz", wantCode: http.StatusOK},
+		{name: "feed on internal", handler: internal, path: "/ics/unknown-token.ics", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			tt.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantCode {
+				t.Errorf("GET %s = %d, want %d", tt.path, recorder.Code, tt.wantCode)
+			}
+		})
+	}
+}